@@ -0,0 +1,388 @@
+package main
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/eywa-protocol/chain/cmd/utils"
+	"github.com/eywa-protocol/chain/common"
+	"github.com/eywa-protocol/chain/core/genesis"
+	"github.com/eywa-protocol/chain/core/ledger"
+	scom "github.com/eywa-protocol/chain/core/store/common"
+	"github.com/eywa-protocol/chain/core/types"
+	"github.com/eywa-protocol/chain/rpc"
+)
+
+const progressInterval = 10000 // Count of blocks between export and import progress messages
+
+var commands = make(map[string]*command)
+
+func init() {
+	for _, cmd := range []*command{
+		{name: "init", usage: "initialize ledger with genesis block built from config", run: initCmd},
+		{name: "info", usage: "show ledger heights, hashes, processed height and epoch", run: infoCmd},
+		{name: "block get", args: "<height|hash>", usage: "show block", run: blockGetCmd},
+		{name: "tx get", args: "<hash>", usage: "show transaction", run: txGetCmd},
+		{name: "request get", args: "<request id>", usage: "show request state and history", run: requestGetCmd},
+		{name: "export", usage: "export blocks to file", run: exportCmd},
+		{name: "import", args: "<file>", usage: "import blocks from export file", run: importCmd},
+		{name: "verify", usage: "verify the chain and report all inconsistencies found", run: verifyCmd},
+		{name: "rollback", args: "<height>", usage: "revert ledger to block height", run: rollbackCmd},
+		{name: "db stats", usage: "show count and size of database keys by prefix", run: dbStatsCmd},
+	} {
+		commands[cmd.name] = cmd
+	}
+}
+
+// InfoResult is json view of ledger state printed by info command
+type InfoResult struct {
+	rpc.HeightsInfo
+	ChainId      uint64
+	PrunedHeight uint64
+	Epoch        *EpochResult `json:",omitempty"`
+}
+
+// EpochResult is json view of epoch active at current block height
+type EpochResult struct {
+	Number     uint32
+	BlockHash  string
+	Height     uint64
+	PublicKeys int
+}
+
+// ExportResult is json view of export command result
+type ExportResult struct {
+	File        string
+	StartHeight uint64
+	EndHeight   uint64
+}
+
+// ImportResult is json view of import command result
+type ImportResult struct {
+	StartHeight uint64
+	EndHeight   uint64
+	BlockHeight uint64
+	BlockHash   string
+}
+
+func initCmd(opts *options, args []string, stdout io.Writer) error {
+	genesisFile := opts.fs.String("genesis", "", "genesis config file, JSON or YAML")
+	sourceHeight := opts.fs.Uint64("source-height", 0, "source chain height of empty genesis block built without config")
+	if _, err := opts.parse(args, 0); err != nil {
+		return err
+	}
+	var config *genesis.Config
+	var genesisBlock *types.Block
+	var err error
+	if *genesisFile != "" {
+		config, err = genesis.LoadConfig(*genesisFile)
+		if err != nil {
+			return err
+		}
+		opts.chainId = config.ChainId
+		genesisBlock, err = genesis.BuildGenesisBlockFromConfig(config)
+	} else {
+		genesisBlock, err = genesis.BuildGenesisBlock(opts.chainId, *sourceHeight)
+	}
+	if err != nil {
+		return fmt.Errorf("build genesis block error %s", err)
+	}
+	l, err := opts.openLedger(false)
+	if err != nil {
+		return err
+	}
+	defer l.Close()
+	if config != nil {
+		err = l.InitWithChainParams(genesisBlock, config.Params.State())
+	} else {
+		err = l.Init(genesisBlock)
+	}
+	if err != nil {
+		return err
+	}
+	if err = opts.applyQuorum(l); err != nil {
+		return err
+	}
+	return printJson(stdout, heightsInfo(l))
+}
+
+func infoCmd(opts *options, args []string, stdout io.Writer) error {
+	if _, err := opts.parse(args, 0); err != nil {
+		return err
+	}
+	l, err := opts.openLedger(true)
+	if err != nil {
+		return err
+	}
+	defer l.Close()
+	header, err := l.GetHeaderByHeight(0)
+	if err != nil {
+		return fmt.Errorf("GetHeaderByHeight error %s", err)
+	}
+	info := &InfoResult{
+		HeightsInfo:  *heightsInfo(l),
+		ChainId:      header.ChainID,
+		PrunedHeight: l.GetPrunedHeight(),
+	}
+	epoch, err := l.GetEpochAtHeight(info.BlockHeight)
+	if err == nil {
+		info.Epoch = &EpochResult{
+			Number:     epoch.Number,
+			BlockHash:  epoch.BlockHash.ToHexString(),
+			Height:     epoch.Height,
+			PublicKeys: len(epoch.PublicKeys),
+		}
+	} else if !errors.Is(err, scom.ErrNotFound) {
+		return fmt.Errorf("GetEpochAtHeight error %s", err)
+	}
+	return printJson(stdout, info)
+}
+
+func blockGetCmd(opts *options, args []string, stdout io.Writer) error {
+	args, err := opts.parse(args, 1)
+	if err != nil {
+		return err
+	}
+	l, err := opts.openLedger(true)
+	if err != nil {
+		return err
+	}
+	defer l.Close()
+	var block *types.Block
+	if height, parseErr := strconv.ParseUint(args[0], 10, 64); parseErr == nil {
+		if height > l.GetCurrentBlockHeight() {
+			return fmt.Errorf("block height %d above current block height %d", height, l.GetCurrentBlockHeight())
+		}
+		block, err = l.GetBlockByHeight(height)
+	} else {
+		blockHash, hashErr := parseHash(args[0])
+		if hashErr != nil {
+			return hashErr
+		}
+		block, err = l.GetBlockByHash(blockHash)
+	}
+	if err != nil {
+		return fmt.Errorf("get block error %s", err)
+	}
+	info, err := rpc.NewBlockInfo(block)
+	if err != nil {
+		return err
+	}
+	return printJson(stdout, info)
+}
+
+func txGetCmd(opts *options, args []string, stdout io.Writer) error {
+	args, err := opts.parse(args, 1)
+	if err != nil {
+		return err
+	}
+	txHash, err := parseHash(args[0])
+	if err != nil {
+		return err
+	}
+	l, err := opts.openLedger(true)
+	if err != nil {
+		return err
+	}
+	defer l.Close()
+	tx, height, err := l.GetTransactionWithHeight(txHash)
+	if err != nil {
+		return fmt.Errorf("GetTransactionWithHeight error %s", err)
+	}
+	info, err := rpc.NewTransactionInfo(tx, height)
+	if err != nil {
+		return err
+	}
+	return printJson(stdout, info)
+}
+
+func requestGetCmd(opts *options, args []string, stdout io.Writer) error {
+	args, err := opts.parse(args, 1)
+	if err != nil {
+		return err
+	}
+	data, err := hex.DecodeString(strings.TrimPrefix(args[0], "0x"))
+	if err != nil || len(data) != 32 {
+		return fmt.Errorf("invalid request id %s", args[0])
+	}
+	var reqId [32]byte
+	copy(reqId[:], data)
+	l, err := opts.openLedger(true)
+	if err != nil {
+		return err
+	}
+	defer l.Close()
+	state, err := l.GetRequestState(reqId)
+	if err != nil {
+		return fmt.Errorf("GetRequestState error %s", err)
+	}
+	tx, err := l.GetTransactionByReqId(reqId)
+	if err != nil {
+		return fmt.Errorf("GetTransactionByReqId error %s", err)
+	}
+	history, err := l.GetRequestHistory(reqId)
+	if err != nil {
+		return fmt.Errorf("GetRequestHistory error %s", err)
+	}
+	transaction := types.ToTransaction(tx)
+	txHash := transaction.Hash()
+	info := &rpc.RequestInfo{
+		RequestId: hex.EncodeToString(reqId[:]),
+		State:     state.String(),
+		TxHash:    txHash.ToHexString(),
+		History:   make([]*rpc.RequestStateInfo, 0, len(history)),
+	}
+	for _, entry := range history {
+		info.History = append(info.History, rpc.NewRequestStateInfo(entry))
+	}
+	return printJson(stdout, info)
+}
+
+func exportCmd(opts *options, args []string, stdout io.Writer) error {
+	start := opts.fs.Uint64("start", 0, "first exported block height")
+	end := opts.fs.Uint64("end", 0, "last exported block height, 0 for current block height")
+	compress := opts.fs.String("compress", "zlib", "block compression: zlib, zstd or snappy")
+	out := opts.fs.String("out", "blocks.dat", "export file name, heights are appended to it")
+	if _, err := opts.parse(args, 0); err != nil {
+		return err
+	}
+	compressType, err := utils.ParseCompressType(*compress)
+	if err != nil {
+		return err
+	}
+	l, err := opts.openLedger(true)
+	if err != nil {
+		return err
+	}
+	defer l.Close()
+	if *end == 0 {
+		*end = l.GetCurrentBlockHeight()
+	}
+	fileName, err := utils.ExportBlocksToFile(l, *out, *start, *end, compressType, opts.progress("exported"))
+	if err != nil {
+		return err
+	}
+	return printJson(stdout, &ExportResult{
+		File:        fileName,
+		StartHeight: *start,
+		EndHeight:   *end,
+	})
+}
+
+func importCmd(opts *options, args []string, stdout io.Writer) error {
+	args, err := opts.parse(args, 1)
+	if err != nil {
+		return err
+	}
+	l, err := opts.openLedger(true)
+	if err != nil {
+		return err
+	}
+	defer l.Close()
+	metadata, err := utils.ImportBlocksFromFile(l, args[0], opts.progress("imported"))
+	if err != nil {
+		return err
+	}
+	blockHash := l.GetCurrentBlockHash()
+	return printJson(stdout, &ImportResult{
+		StartHeight: metadata.StartBlockHeight,
+		EndHeight:   metadata.EndBlockHeight,
+		BlockHeight: l.GetCurrentBlockHeight(),
+		BlockHash:   blockHash.ToHexString(),
+	})
+}
+
+// verifyCmd print the report of chain inconsistencies, it fails if any inconsistency is found
+func verifyCmd(opts *options, args []string, stdout io.Writer) error {
+	from := opts.fs.Uint64("from", 0, "first verified block height")
+	to := opts.fs.Uint64("to", 0, "last verified block height, 0 for current block height")
+	if _, err := opts.parse(args, 0); err != nil {
+		return err
+	}
+	l, err := opts.openLedger(true)
+	if err != nil {
+		return err
+	}
+	defer l.Close()
+	if *to == 0 {
+		*to = l.GetCurrentBlockHeight()
+	}
+	report, err := l.VerifyChain(*from, *to)
+	if err != nil {
+		return err
+	}
+	if err = printJson(stdout, report); err != nil {
+		return err
+	}
+	if len(report.Issues) > 0 {
+		return fmt.Errorf("%d inconsistencies found", len(report.Issues))
+	}
+	return nil
+}
+
+func rollbackCmd(opts *options, args []string, stdout io.Writer) error {
+	args, err := opts.parse(args, 1)
+	if err != nil {
+		return err
+	}
+	height, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid height %s", args[0])
+	}
+	l, err := opts.openLedger(true)
+	if err != nil {
+		return err
+	}
+	defer l.Close()
+	if err = l.RollbackTo(height); err != nil {
+		return err
+	}
+	return printJson(stdout, heightsInfo(l))
+}
+
+func dbStatsCmd(opts *options, args []string, stdout io.Writer) error {
+	if _, err := opts.parse(args, 0); err != nil {
+		return err
+	}
+	l, err := opts.openLedger(true)
+	if err != nil {
+		return err
+	}
+	defer l.Close()
+	stats, err := l.GetDBStats()
+	if err != nil {
+		return err
+	}
+	return printJson(stdout, stats)
+}
+
+// progress return export or import progress func writing to stderr each progressInterval blocks
+func (opts *options) progress(action string) utils.ProgressFunc {
+	return func(height, endHeight uint64) {
+		if height%progressInterval == 0 || height == endHeight {
+			fmt.Fprintf(opts.stderr, "%s block %d of %d\n", action, height, endHeight)
+		}
+	}
+}
+
+func heightsInfo(l *ledger.Ledger) *rpc.HeightsInfo {
+	blockHash := l.GetCurrentBlockHash()
+	return &rpc.HeightsInfo{
+		BlockHeight:     l.GetCurrentBlockHeight(),
+		BlockHash:       blockHash.ToHexString(),
+		HeaderHeight:    l.GetCurrentHeaderHeight(),
+		ProcessedHeight: l.GetProcessedHeight(),
+	}
+}
+
+func parseHash(str string) (common.Uint256, error) {
+	hash, err := common.Uint256FromHexString(strings.TrimPrefix(str, "0x"))
+	if err != nil {
+		return common.UINT256_EMPTY, fmt.Errorf("invalid hash %s: %s", str, err)
+	}
+	return hash, nil
+}
@@ -0,0 +1,173 @@
+// eywa-chain inspects and maintains the ledger kept in a data directory without running the node
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/eywa-protocol/chain/core/ledger"
+	scom "github.com/eywa-protocol/chain/core/store/common"
+	"github.com/eywa-protocol/chain/core/store/ledgerstore"
+)
+
+// command is a subcommand of eywa-chain, name may consist of group and action such as "block get"
+type command struct {
+	name  string
+	args  string
+	usage string
+	run   func(opts *options, args []string, stdout io.Writer) error
+}
+
+// options are flags shared by all commands
+type options struct {
+	dataDir  string
+	chainId  uint64
+	singleDB bool
+	quorum   uint64
+	fs       *flag.FlagSet
+	stderr   io.Writer
+}
+
+var errUsage = errors.New("usage")
+
+func main() {
+	err := run(os.Args[1:], os.Stdout, os.Stderr)
+	if err == errUsage {
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "eywa-chain: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+// run execute the command given by args, command result is written to stdout as json
+func run(args []string, stdout, stderr io.Writer) error {
+	cmd, rest := findCommand(args)
+	if cmd == nil {
+		printUsage(stderr)
+		return errUsage
+	}
+	opts := &options{fs: flag.NewFlagSet(cmd.name, flag.ContinueOnError), stderr: stderr}
+	opts.fs.SetOutput(stderr)
+	opts.fs.Usage = func() {
+		fmt.Fprintf(stderr, "usage: eywa-chain %s [flags] %s\n  %s\n", cmd.name, cmd.args, cmd.usage)
+		opts.fs.PrintDefaults()
+	}
+	opts.fs.StringVar(&opts.dataDir, "datadir", "./data", "ledger data directory")
+	opts.fs.Uint64Var(&opts.chainId, "chain-id", 0, "chain id of the ledger")
+	opts.fs.BoolVar(&opts.singleDB, "single-db", false, "ledger stores are kept in single database")
+	opts.fs.Uint64Var(&opts.quorum, "quorum", ledgerstore.DefaultQuorumThreshold, "percent of epoch participants required to sign block header unless chain params fix it, 0 for ledgers without epochs")
+	err := cmd.run(opts, rest, stdout)
+	if err == flag.ErrHelp {
+		return nil
+	}
+	return err
+}
+
+// findCommand return the command named by the first one or two args and the rest of args
+func findCommand(args []string) (*command, []string) {
+	if len(args) >= 2 {
+		if cmd, ok := commands[args[0]+" "+args[1]]; ok {
+			return cmd, args[2:]
+		}
+	}
+	if len(args) >= 1 {
+		if cmd, ok := commands[args[0]]; ok {
+			return cmd, args[1:]
+		}
+	}
+	return nil, nil
+}
+
+func printUsage(w io.Writer) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Fprintln(w, "usage: eywa-chain <command> [flags] [args]")
+	fmt.Fprintln(w, "commands:")
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-14s %s\n", name, commands[name].usage)
+	}
+}
+
+// openLedger open the ledger of data directory, the ledger must be initialized if load is set
+func (opts *options) openLedger(load bool) (*ledger.Ledger, error) {
+	l, err := ledger.NewLedgerWithConfig(opts.dataDir, opts.chainId, ledgerstore.StoreConfig{SingleDB: opts.singleDB})
+	if err != nil {
+		return nil, err
+	}
+	if load {
+		if err = l.Load(); err != nil {
+			l.Close()
+			return nil, err
+		}
+		if err = opts.applyQuorum(l); err != nil {
+			l.Close()
+			return nil, err
+		}
+	}
+	return l, nil
+}
+
+// applyQuorum set quorum threshold of --quorum flag unless chain params saved at genesis fix it,
+// the flag is rejected if it's given and differs. Ledgers without epoch history, initialized before it
+// or from genesis block without epoch, must be opened with --quorum 0
+func (opts *options) applyQuorum(l *ledger.Ledger) error {
+	given := false
+	opts.fs.Visit(func(f *flag.Flag) {
+		given = given || f.Name == "quorum"
+	})
+	params, err := l.GetChainParams()
+	if err != nil && err != scom.ErrNotFound {
+		return fmt.Errorf("GetChainParams error %s", err)
+	}
+	if err == nil && params.QuorumThreshold != nil {
+		if given && *params.QuorumThreshold != opts.quorum {
+			return fmt.Errorf("quorum %d differs from quorum threshold %d of chain params", opts.quorum, *params.QuorumThreshold)
+		}
+		return nil
+	}
+	if opts.quorum != 0 {
+		_, err = l.GetEpochAtHeight(l.GetCurrentBlockHeight())
+		if err == scom.ErrNotFound {
+			return fmt.Errorf("ledger has no epoch to verify headers with quorum %d, open it with --quorum 0", opts.quorum)
+		} else if err != nil {
+			return fmt.Errorf("GetEpochAtHeight error %s", err)
+		}
+	}
+	l.SetQuorumThreshold(opts.quorum)
+	return nil
+}
+
+// parse parse command flags defined on opts.fs and check count of positional args
+func (opts *options) parse(args []string, argCount int) ([]string, error) {
+	if err := opts.fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return nil, err
+		}
+		return nil, errUsage
+	}
+	if opts.fs.NArg() != argCount {
+		opts.fs.Usage()
+		return nil, fmt.Errorf("%s expects %d args, got %d: %s", opts.fs.Name(), argCount, opts.fs.NArg(), strings.Join(opts.fs.Args(), " "))
+	}
+	return opts.fs.Args(), nil
+}
+
+func printJson(w io.Writer, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", data)
+	return err
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"github.com/eywa-protocol/bls-crypto/bls"
+	"github.com/eywa-protocol/wrappers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eywa-protocol/chain/common"
+	"github.com/eywa-protocol/chain/core/ledger"
+	"github.com/eywa-protocol/chain/core/payload"
+	"github.com/eywa-protocol/chain/core/store"
+	"github.com/eywa-protocol/chain/core/types"
+	"github.com/eywa-protocol/chain/rpc"
+)
+
+// runJson run the command on dataDir and decode its json output to result
+func runJson(t *testing.T, dataDir string, result interface{}, args ...string) {
+	cmd, rest := findCommand(args)
+	require.NotNil(t, cmd, args)
+	// common flags are inserted before command flags and args
+	full := append(args[:len(args)-len(rest):len(args)-len(rest)], "--datadir", dataDir, "--quorum", "0")
+	stdout := new(bytes.Buffer)
+	require.NoError(t, run(append(full, rest...), stdout, ioutil.Discard), args)
+	if result != nil {
+		require.NoError(t, json.Unmarshal(stdout.Bytes(), result), stdout.String())
+	}
+}
+
+func TestCommands(t *testing.T) {
+	dataDir := t.TempDir()
+	var heights rpc.HeightsInfo
+	runJson(t, dataDir, &heights, "init", "--source-height", "10")
+	assert.Equal(t, uint64(0), heights.BlockHeight)
+	assert.Equal(t, uint64(10), heights.ProcessedHeight)
+	// genesis block without epoch can't be verified with default quorum
+	require.Error(t, run([]string{"info", "--datadir", dataDir}, ioutil.Discard, ioutil.Discard))
+
+	reqId := [32]byte{1, 2, 3}
+	l, err := ledger.NewLedger(dataDir, 0)
+	require.NoError(t, err)
+	require.NoError(t, l.Load())
+	event := &payload.BridgeEvent{
+		OriginData: wrappers.BridgeOracleRequest{
+			RequestType: "setRequest",
+			RequestId:   reqId,
+			ChainId:     big.NewInt(94),
+		}}
+	block, err := l.CreateBlockFromEvents(types.Transactions{types.ToTransaction(event)}, 11, common.UINT256_EMPTY)
+	require.NoError(t, err)
+	require.NoError(t, l.ExecAndSaveBlock(block))
+	require.NoError(t, l.Close())
+	blockHash := block.Hash()
+
+	var info InfoResult
+	runJson(t, dataDir, &info, "info")
+	assert.Equal(t, uint64(1), info.BlockHeight)
+	assert.Equal(t, blockHash.ToHexString(), info.BlockHash)
+	assert.Equal(t, uint64(11), info.ProcessedHeight)
+
+	var blockInfo rpc.BlockInfo
+	runJson(t, dataDir, &blockInfo, "block", "get", "1")
+	assert.Equal(t, blockHash.ToHexString(), blockInfo.Hash)
+	require.Len(t, blockInfo.Transactions, 1)
+	runJson(t, dataDir, &blockInfo, "block", "get", blockHash.ToHexString())
+	assert.Equal(t, uint64(1), blockInfo.Header.Height)
+
+	var txInfo rpc.TransactionInfo
+	runJson(t, dataDir, &txInfo, "tx", "get", blockInfo.Transactions[0].Hash)
+	assert.Equal(t, uint64(1), txInfo.Height)
+
+	var requestInfo rpc.RequestInfo
+	runJson(t, dataDir, &requestInfo, "request", "get", hex.EncodeToString(reqId[:]))
+	assert.Equal(t, payload.ReqStateReceived.String(), requestInfo.State)
+	assert.Equal(t, txInfo.Hash, requestInfo.TxHash)
+
+	var report store.ChainReport
+	runJson(t, dataDir, &report, "verify")
+	assert.Equal(t, uint64(2), report.Headers)
+	assert.Empty(t, report.Issues)
+
+	var stats []*store.PrefixStats
+	runJson(t, dataDir, &stats, "db", "stats")
+	assert.NotEmpty(t, stats)
+
+	var exported ExportResult
+	runJson(t, dataDir, &exported, "export", "--compress", "zstd", "--out", filepath.Join(t.TempDir(), "blocks.dat"))
+	assert.Equal(t, uint64(1), exported.EndHeight)
+
+	importDir := t.TempDir()
+	runJson(t, importDir, nil, "init", "--source-height", "10")
+	var imported ImportResult
+	runJson(t, importDir, &imported, "import", exported.File)
+	assert.Equal(t, blockHash.ToHexString(), imported.BlockHash)
+
+	runJson(t, dataDir, &heights, "rollback", "0")
+	assert.Equal(t, uint64(0), heights.BlockHeight)
+	assert.Equal(t, uint64(10), heights.ProcessedHeight)
+}
+
+func TestInitGenesisConfig(t *testing.T) {
+	_, pubKey := bls.GenerateRandomKey()
+	genesisFile := filepath.Join(t.TempDir(), "genesis.yaml")
+	config := "chain_id: 7\nepoch:\n  public_keys: [\"" + hex.EncodeToString(pubKey.Marshal()) + "\"]\n  host_ids: [one]\n"
+	require.NoError(t, ioutil.WriteFile(genesisFile, []byte(config), 0644))
+
+	dataDir := t.TempDir()
+	var heights rpc.HeightsInfo
+	runJson(t, dataDir, &heights, "init", "--genesis", genesisFile)
+	var info InfoResult
+	runJson(t, dataDir, &info, "info")
+	assert.Equal(t, heights.BlockHash, info.BlockHash)
+	assert.Equal(t, uint64(7), info.ChainId)
+	require.NotNil(t, info.Epoch)
+	assert.Equal(t, 1, info.Epoch.PublicKeys)
+
+	// quorum flag can not override quorum threshold of chain params
+	require.NoError(t, ioutil.WriteFile(genesisFile, []byte(config+"params:\n  quorum_threshold: 50\n"), 0644))
+	dataDir = t.TempDir()
+	require.NoError(t, run([]string{"init", "--datadir", dataDir, "--genesis", genesisFile}, ioutil.Discard, ioutil.Discard))
+	require.NoError(t, run([]string{"info", "--datadir", dataDir}, ioutil.Discard, ioutil.Discard))
+	require.NoError(t, run([]string{"info", "--datadir", dataDir, "--quorum", "50"}, ioutil.Discard, ioutil.Discard))
+	require.Error(t, run([]string{"info", "--datadir", dataDir, "--quorum", "30"}, ioutil.Discard, ioutil.Discard))
+
+	// ledger without quorum in chain params verifies headers with default quorum against genesis epoch
+	require.NoError(t, ioutil.WriteFile(genesisFile, []byte(config), 0644))
+	dataDir = t.TempDir()
+	require.NoError(t, run([]string{"init", "--datadir", dataDir, "--genesis", genesisFile}, ioutil.Discard, ioutil.Discard))
+	require.NoError(t, run([]string{"info", "--datadir", dataDir}, ioutil.Discard, ioutil.Discard))
+}
+
+func TestUsage(t *testing.T) {
+	stderr := new(bytes.Buffer)
+	assert.Equal(t, errUsage, run([]string{"block"}, ioutil.Discard, stderr))
+	assert.Contains(t, stderr.String(), "block get")
+	assert.Error(t, run([]string{"block", "get", "--datadir", t.TempDir()}, ioutil.Discard, ioutil.Discard))
+	assert.Error(t, run([]string{"info", "--datadir", t.TempDir()}, ioutil.Discard, ioutil.Discard))
+}
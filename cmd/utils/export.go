@@ -4,26 +4,49 @@ import (
 	"bytes"
 	"compress/zlib"
 	"fmt"
-	"github.com/eywa-protocol/chain/common/serialization"
+	"hash/crc32"
 	"io"
 	"io/ioutil"
+	"sync"
+
+	"github.com/eywa-protocol/chain/common/serialization"
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
 )
 
 const (
 	COMPRESS_TYPE_ZLIB = iota
+	COMPRESS_TYPE_ZSTD
+	COMPRESS_TYPE_SNAPPY
+)
+
+// Names of compress types
+var compressTypeNames = map[string]byte{
+	"zlib":   COMPRESS_TYPE_ZLIB,
+	"zstd":   COMPRESS_TYPE_ZSTD,
+	"snappy": COMPRESS_TYPE_SNAPPY,
+}
+
+var crc32Table = crc32.MakeTable(crc32.Castagnoli)
+
+var (
+	zstdOnce    sync.Once
+	zstdEncoder *zstd.Encoder
+	zstdDecoder *zstd.Decoder
+	zstdErr     error
 )
 
 const (
 	DEFAULT_COMPRESS_TYPE         = COMPRESS_TYPE_ZLIB
 	EXPORT_BLOCK_METADATA_LEN     = 256
-	EXPORT_BLOCK_METADATA_VERSION = 1
+	EXPORT_BLOCK_METADATA_VERSION = 2
 )
 
 type ExportBlockMetadata struct {
 	Version          byte
 	CompressType     byte
-	StartBlockHeight uint32
-	EndBlockHeight   uint32
+	StartBlockHeight uint64
+	EndBlockHeight   uint64
 }
 
 func NewExportBlockMetadata() *ExportBlockMetadata {
@@ -44,11 +67,11 @@ func (this *ExportBlockMetadata) Serialize(w io.Writer) error {
 	if err != nil {
 		return err
 	}
-	err = serialization.WriteUint32(buf, this.StartBlockHeight)
+	err = serialization.WriteUint64(buf, this.StartBlockHeight)
 	if err != nil {
 		return err
 	}
-	err = serialization.WriteUint32(buf, this.EndBlockHeight)
+	err = serialization.WriteUint64(buf, this.EndBlockHeight)
 	if err != nil {
 		return err
 	}
@@ -81,12 +104,12 @@ func (this *ExportBlockMetadata) Deserialize(r io.Reader) error {
 		return err
 	}
 	this.CompressType = compressType
-	height, err := serialization.ReadUint32(reader)
+	height, err := serialization.ReadUint64(reader)
 	if err != nil {
 		return err
 	}
 	this.StartBlockHeight = height
-	height, err = serialization.ReadUint32(reader)
+	height, err = serialization.ReadUint64(reader)
 	if err != nil {
 		return err
 	}
@@ -94,10 +117,23 @@ func (this *ExportBlockMetadata) Deserialize(r io.Reader) error {
 	return nil
 }
 
+// ParseCompressType return compress type by its name: zlib, zstd or snappy
+func ParseCompressType(name string) (byte, error) {
+	compressType, ok := compressTypeNames[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown compress type %s", name)
+	}
+	return compressType, nil
+}
+
 func CompressBlockData(data []byte, compressType byte) ([]byte, error) {
 	switch compressType {
 	case COMPRESS_TYPE_ZLIB:
 		return ZLibCompress(data)
+	case COMPRESS_TYPE_ZSTD:
+		return ZstdCompress(data)
+	case COMPRESS_TYPE_SNAPPY:
+		return snappy.Encode(nil, data), nil
 	default:
 		return nil, fmt.Errorf("unknown compress type")
 	}
@@ -107,11 +143,64 @@ func DecompressBlockData(data []byte, compressType byte) ([]byte, error) {
 	switch compressType {
 	case COMPRESS_TYPE_ZLIB:
 		return ZLibDecompress(data)
+	case COMPRESS_TYPE_ZSTD:
+		return ZstdDecompress(data)
+	case COMPRESS_TYPE_SNAPPY:
+		return snappy.Decode(nil, data)
 	default:
 		return nil, fmt.Errorf("unknown compress type")
 	}
 }
 
+// WriteBlockFrame compress block data and write it as frame of compressed data size, its crc32 checksum
+// and compressed data. Every frame is compressed separately, so a block is decompressed without previous ones
+func WriteBlockFrame(w io.Writer, data []byte, compressType byte) error {
+	data, err := CompressBlockData(data, compressType)
+	if err != nil {
+		return err
+	}
+	err = serialization.WriteUint32(w, uint32(len(data)))
+	if err != nil {
+		return err
+	}
+	err = serialization.WriteUint32(w, crc32.Checksum(data, crc32Table))
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// ReadBlockFrame read frame written by WriteBlockFrame, verify its checksum and return decompressed block data
+func ReadBlockFrame(r io.Reader, compressType byte) ([]byte, error) {
+	size, err := serialization.ReadUint32(r)
+	if err != nil {
+		return nil, err
+	}
+	checksum, err := serialization.ReadUint32(r)
+	if err != nil {
+		return nil, err
+	}
+	data, err := serialization.ReadBytes(r, uint64(size))
+	if err != nil {
+		return nil, err
+	}
+	if crc32.Checksum(data, crc32Table) != checksum {
+		return nil, fmt.Errorf("block frame checksum mismatch")
+	}
+	return DecompressBlockData(data, compressType)
+}
+
+// SkipBlockFrame seek to the next frame without reading the current one
+func SkipBlockFrame(r io.ReadSeeker) error {
+	size, err := serialization.ReadUint32(r)
+	if err != nil {
+		return err
+	}
+	_, err = r.Seek(int64(size)+4, io.SeekCurrent)
+	return err
+}
+
 func ZLibCompress(data []byte) ([]byte, error) {
 	buf := bytes.NewBuffer(nil)
 	zlibWriter := zlib.NewWriter(buf)
@@ -133,3 +222,28 @@ func ZLibDecompress(data []byte) ([]byte, error) {
 
 	return ioutil.ReadAll(zlibReader)
 }
+
+func initZstd() error {
+	zstdOnce.Do(func() {
+		zstdEncoder, zstdErr = zstd.NewWriter(nil)
+		if zstdErr != nil {
+			return
+		}
+		zstdDecoder, zstdErr = zstd.NewReader(nil)
+	})
+	return zstdErr
+}
+
+func ZstdCompress(data []byte) ([]byte, error) {
+	if err := initZstd(); err != nil {
+		return nil, fmt.Errorf("zstd init error %s", err)
+	}
+	return zstdEncoder.EncodeAll(data, nil), nil
+}
+
+func ZstdDecompress(data []byte) ([]byte, error) {
+	if err := initZstd(); err != nil {
+		return nil, fmt.Errorf("zstd init error %s", err)
+	}
+	return zstdDecoder.DecodeAll(data, nil)
+}
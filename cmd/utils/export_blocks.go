@@ -0,0 +1,168 @@
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/eywa-protocol/chain/core/ledger"
+	"github.com/eywa-protocol/chain/core/types"
+)
+
+// ProgressFunc is called after each exported or imported block
+type ProgressFunc func(height, endHeight uint64)
+
+// ExportBlocks write blocks from start to end height inclusive to w. Export block metadata is followed
+// by the block frames compressed with compressType
+func ExportBlocks(ldg *ledger.Ledger, w io.Writer, start, end uint64, compressType byte, progress ProgressFunc) error {
+	if start > end {
+		return fmt.Errorf("start height %d above end height %d", start, end)
+	}
+	if currHeight := ldg.GetCurrentBlockHeight(); end > currHeight {
+		return fmt.Errorf("end height %d above current block height %d", end, currHeight)
+	}
+	metadata := NewExportBlockMetadata()
+	metadata.CompressType = compressType
+	metadata.StartBlockHeight = start
+	metadata.EndBlockHeight = end
+	buf := bufio.NewWriter(w)
+	err := metadata.Serialize(buf)
+	if err != nil {
+		return fmt.Errorf("metadata serialize error %s", err)
+	}
+	for height := start; height <= end; height++ {
+		block, err := ldg.GetBlockByHeight(height)
+		if err != nil {
+			return fmt.Errorf("GetBlockByHeight %d error %s", height, err)
+		}
+		if block == nil {
+			return fmt.Errorf("block at height %d not found", height)
+		}
+		data, err := block.ToArray()
+		if err != nil {
+			return fmt.Errorf("block height %d serialize error %s", height, err)
+		}
+		err = WriteBlockFrame(buf, data, compressType)
+		if err != nil {
+			return fmt.Errorf("block height %d write error %s", height, err)
+		}
+		if progress != nil {
+			progress(height, end)
+		}
+	}
+	return buf.Flush()
+}
+
+// ExportBlocksToFile write blocks from start to end height inclusive to the file named by GenExportBlocksFileName.
+// File is written under temporary name and renamed when export is done, so interrupted export leaves no file
+func ExportBlocksToFile(ldg *ledger.Ledger, name string, start, end uint64, compressType byte, progress ProgressFunc) (string, error) {
+	fileName := GenExportBlocksFileName(name, start, end)
+	tmpName := fileName + ".tmp"
+	file, err := os.Create(tmpName)
+	if err != nil {
+		return "", err
+	}
+	err = ExportBlocks(ldg, file, start, end, compressType, progress)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpName)
+		return "", err
+	}
+	return fileName, os.Rename(tmpName, fileName)
+}
+
+// ImportBlocks execute and submit blocks read from r to ledger initialized with the genesis block.
+// Blocks up to current block height must be the saved ones and are skipped, so interrupted import
+// is resumed by importing the same blocks again
+func ImportBlocks(ldg *ledger.Ledger, r io.Reader, progress ProgressFunc) (*ExportBlockMetadata, error) {
+	buf := bufio.NewReader(r)
+	metadata := NewExportBlockMetadata()
+	err := metadata.Deserialize(buf)
+	if err != nil {
+		return nil, fmt.Errorf("metadata deserialize error %s", err)
+	}
+	start, end := metadata.StartBlockHeight, metadata.EndBlockHeight
+	if start > end {
+		return nil, fmt.Errorf("start height %d above end height %d", start, end)
+	}
+	if currHeight := ldg.GetCurrentBlockHeight(); start > currHeight+1 {
+		return nil, fmt.Errorf("start height %d above next block height %d", start, currHeight+1)
+	}
+	for height := start; height <= end; height++ {
+		block, err := readExportedBlock(buf, metadata.CompressType)
+		if err != nil {
+			return nil, fmt.Errorf("read block height %d error %s", height, err)
+		}
+		if block.Header.Height != height {
+			return nil, fmt.Errorf("block height %d, expected %d", block.Header.Height, height)
+		}
+		err = importBlock(ldg, block)
+		if err != nil {
+			return nil, fmt.Errorf("import block height %d error %s", height, err)
+		}
+		if progress != nil {
+			progress(height, end)
+		}
+	}
+	return metadata, nil
+}
+
+// ImportBlocksFromFile execute and submit blocks read from the file to ledger
+func ImportBlocksFromFile(ldg *ledger.Ledger, fileName string, progress ProgressFunc) (*ExportBlockMetadata, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ImportBlocks(ldg, file, progress)
+}
+
+// ReadExportedBlock read the block at height from export file without decompressing previous blocks
+func ReadExportedBlock(r io.ReadSeeker, height uint64) (*types.Block, error) {
+	metadata := NewExportBlockMetadata()
+	err := metadata.Deserialize(r)
+	if err != nil {
+		return nil, fmt.Errorf("metadata deserialize error %s", err)
+	}
+	if height < metadata.StartBlockHeight || height > metadata.EndBlockHeight {
+		return nil, fmt.Errorf("height %d out of exported heights %d-%d", height, metadata.StartBlockHeight, metadata.EndBlockHeight)
+	}
+	for h := metadata.StartBlockHeight; h < height; h++ {
+		err = SkipBlockFrame(r)
+		if err != nil {
+			return nil, fmt.Errorf("skip block height %d error %s", h, err)
+		}
+	}
+	return readExportedBlock(r, metadata.CompressType)
+}
+
+func readExportedBlock(r io.Reader, compressType byte) (*types.Block, error) {
+	data, err := ReadBlockFrame(r, compressType)
+	if err != nil {
+		return nil, err
+	}
+	return types.BlockFromRawBytes(data)
+}
+
+// importBlock skip the block already saved to ledger or execute and submit the next block
+func importBlock(ldg *ledger.Ledger, block *types.Block) error {
+	height := block.Header.Height
+	if height <= ldg.GetCurrentBlockHeight() {
+		if savedHash, blockHash := ldg.GetBlockHash(height), block.Hash(); savedHash != blockHash {
+			return fmt.Errorf("block %s differs from saved block %s", blockHash.ToHexString(), savedHash.ToHexString())
+		}
+		return nil
+	}
+	if currHash := ldg.GetCurrentBlockHash(); block.Header.PrevBlockHash != currHash {
+		return fmt.Errorf("previous block hash %s is not current block hash %s",
+			block.Header.PrevBlockHash.ToHexString(), currHash.ToHexString())
+	}
+	result, err := ldg.ExecuteBlock(block)
+	if err != nil {
+		return fmt.Errorf("ExecuteBlock error %s", err)
+	}
+	return ldg.SubmitBlock(block, result)
+}
@@ -0,0 +1,118 @@
+package utils
+
+import (
+	"bytes"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/eywa-protocol/wrappers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eywa-protocol/chain/common"
+	"github.com/eywa-protocol/chain/core/ledger"
+	"github.com/eywa-protocol/chain/core/payload"
+	"github.com/eywa-protocol/chain/core/types"
+)
+
+func newExportTestLedger(t *testing.T) *ledger.Ledger {
+	l, err := ledger.NewLedger(t.TempDir(), 0)
+	require.NoError(t, err)
+	genesisBlock := types.NewBlock(0, common.UINT256_EMPTY, common.UINT256_EMPTY, 10, 0, types.Transactions{})
+	require.NoError(t, l.Init(genesisBlock))
+	return l
+}
+
+func TestExportBlockMetadata(t *testing.T) {
+	metadata := NewExportBlockMetadata()
+	metadata.StartBlockHeight = 1 << 40
+	metadata.EndBlockHeight = 1<<40 + 5
+	buf := bytes.NewBuffer(nil)
+	require.NoError(t, metadata.Serialize(buf))
+	assert.Equal(t, EXPORT_BLOCK_METADATA_LEN, buf.Len())
+
+	decoded := NewExportBlockMetadata()
+	require.NoError(t, decoded.Deserialize(buf))
+	assert.Equal(t, metadata, decoded)
+}
+
+func TestBlockFrame(t *testing.T) {
+	data := bytes.Repeat([]byte("block data "), 100)
+	for _, name := range []string{"zlib", "zstd", "snappy"} {
+		compressType, err := ParseCompressType(name)
+		require.NoError(t, err)
+		buf := bytes.NewBuffer(nil)
+		require.NoError(t, WriteBlockFrame(buf, data, compressType))
+		frame := buf.Bytes()
+		decoded, err := ReadBlockFrame(bytes.NewReader(frame), compressType)
+		require.NoError(t, err, name)
+		assert.Equal(t, data, decoded, name)
+
+		frame[len(frame)-1] ^= 0xff
+		_, err = ReadBlockFrame(bytes.NewReader(frame), compressType)
+		assert.Error(t, err, name)
+	}
+	_, err := ParseCompressType("lz4")
+	assert.Error(t, err)
+}
+
+func TestExportImportBlocks(t *testing.T) {
+	src := newExportTestLedger(t)
+	defer src.Close()
+	for i := byte(1); i <= 3; i++ {
+		event := &payload.BridgeEvent{
+			OriginData: wrappers.BridgeOracleRequest{
+				RequestType: "setRequest",
+				RequestId:   [32]byte{i},
+				ChainId:     big.NewInt(94),
+			}}
+		block, err := src.CreateBlockFromEvents(types.Transactions{types.ToTransaction(event)}, 10+uint64(i), common.UINT256_EMPTY)
+		require.NoError(t, err)
+		require.NoError(t, src.ExecAndSaveBlock(block))
+	}
+	require.Error(t, ExportBlocks(src, bytes.NewBuffer(nil), 0, 4, COMPRESS_TYPE_ZLIB, nil))
+
+	partial := bytes.NewBuffer(nil)
+	require.NoError(t, ExportBlocks(src, partial, 0, 1, COMPRESS_TYPE_SNAPPY, nil))
+	name, err := ExportBlocksToFile(src, filepath.Join(t.TempDir(), "blocks.dat"), 0, 3, COMPRESS_TYPE_ZSTD, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "blocks_0_3.dat", filepath.Base(name))
+
+	file, err := os.Open(name)
+	require.NoError(t, err)
+	block, err := ReadExportedBlock(file, 2)
+	require.NoError(t, err)
+	assert.Equal(t, src.GetBlockHash(2), block.Hash())
+	require.NoError(t, file.Close())
+
+	// import is resumed from the block next to the current one
+	dst := newExportTestLedger(t)
+	defer dst.Close()
+	_, err = ImportBlocks(dst, partial, nil)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), dst.GetCurrentBlockHeight())
+	var imported []uint64
+	metadata, err := ImportBlocksFromFile(dst, name, func(height, endHeight uint64) {
+		imported = append(imported, height)
+		assert.Equal(t, uint64(3), endHeight)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), metadata.StartBlockHeight)
+	assert.Equal(t, uint64(3), metadata.EndBlockHeight)
+	assert.Equal(t, []uint64{0, 1, 2, 3}, imported)
+	require.Equal(t, src.GetCurrentBlockHash(), dst.GetCurrentBlockHash())
+	state, err := dst.GetRequestState([32]byte{3})
+	require.NoError(t, err)
+	assert.Equal(t, payload.ReqStateReceived, state)
+
+	// blocks of another chain are rejected
+	other := newExportTestLedger(t)
+	defer other.Close()
+	block, err = other.CreateBlockFromEvents(types.Transactions{}, 11, common.UINT256_EMPTY)
+	require.NoError(t, err)
+	require.NoError(t, other.ExecAndSaveBlock(block))
+	_, err = ImportBlocksFromFile(other, name, nil)
+	require.Error(t, err)
+}
@@ -28,7 +28,7 @@ func GetStoreDirPath(dataDir, networkName string) string {
 	return dataDir + string(os.PathSeparator) + networkName
 }
 
-func GenExportBlocksFileName(name string, start, end uint32) string {
+func GenExportBlocksFileName(name string, start, end uint64) string {
 	index := strings.LastIndex(name, ".")
 	fileName := ""
 	fileExt := ""
@@ -7,8 +7,8 @@ import (
 
 func TestGenExportBlocksFileName(t *testing.T) {
 	name := "blocks.dat"
-	start := uint32(0)
-	end := uint32(100)
+	start := uint64(0)
+	end := uint64(100)
 	fileName := GenExportBlocksFileName(name, start, end)
 	assert.Equal(t, "blocks_0_100.dat", fileName)
 	name = "blocks"
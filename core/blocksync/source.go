@@ -0,0 +1,91 @@
+package blocksync
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/eywa-protocol/chain/core/ledger"
+	"github.com/eywa-protocol/chain/core/types"
+)
+
+// BlockSource provide headers and blocks of the chain to sync from, implemented by peers
+type BlockSource interface {
+	Height() (uint64, error)                                 // Current block height of the source
+	Headers(from uint64, count int) ([]*types.Header, error) // Up to count headers in height order starting from the height
+	Block(height uint64) (*types.Block, error)
+}
+
+// MemorySource is block source of blocks kept in memory
+type MemorySource struct {
+	blocks []*types.Block
+	lock   sync.RWMutex
+}
+
+// NewMemorySource return source of the blocks starting from genesis block
+func NewMemorySource(blocks ...*types.Block) *MemorySource {
+	return &MemorySource{blocks: blocks}
+}
+
+// Add append the next block to source
+func (s *MemorySource) Add(block *types.Block) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.blocks = append(s.blocks, block)
+}
+
+func (s *MemorySource) Height() (uint64, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if len(s.blocks) == 0 {
+		return 0, fmt.Errorf("source has no blocks")
+	}
+	return uint64(len(s.blocks) - 1), nil
+}
+
+func (s *MemorySource) Headers(from uint64, count int) ([]*types.Header, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	var headers []*types.Header
+	for height := from; height < uint64(len(s.blocks)) && len(headers) < count; height++ {
+		headers = append(headers, s.blocks[height].Header)
+	}
+	return headers, nil
+}
+
+func (s *MemorySource) Block(height uint64) (*types.Block, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if height >= uint64(len(s.blocks)) {
+		return nil, fmt.Errorf("block %d not found", height)
+	}
+	return s.blocks[height], nil
+}
+
+// LedgerSource is block source of local ledger, used to sync from another data directory
+type LedgerSource struct {
+	ledger *ledger.Ledger
+}
+
+func NewLedgerSource(l *ledger.Ledger) *LedgerSource {
+	return &LedgerSource{ledger: l}
+}
+
+func (s *LedgerSource) Height() (uint64, error) {
+	return s.ledger.GetCurrentBlockHeight(), nil
+}
+
+func (s *LedgerSource) Headers(from uint64, count int) ([]*types.Header, error) {
+	var headers []*types.Header
+	for height := from; height <= s.ledger.GetCurrentBlockHeight() && len(headers) < count; height++ {
+		header, err := s.ledger.GetHeaderByHeight(height)
+		if err != nil {
+			return nil, fmt.Errorf("GetHeaderByHeight %d error %s", height, err)
+		}
+		headers = append(headers, header)
+	}
+	return headers, nil
+}
+
+func (s *LedgerSource) Block(height uint64) (*types.Block, error) {
+	return s.ledger.GetBlockByHeight(height)
+}
@@ -0,0 +1,210 @@
+package blocksync
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/eywa-protocol/chain/core/ledger"
+	"github.com/eywa-protocol/chain/core/types"
+)
+
+const (
+	DefaultHeaderBatch = 500 // Default count of headers requested from source at once
+	DefaultWorkers     = 4   // Default count of blocks downloaded in parallel
+)
+
+var ErrStopped = errors.New("sync stopped")
+
+// Config of block sync, zero values are replaced by defaults
+type Config struct {
+	HeaderBatch int // Count of headers requested from source at once
+	Workers     int // Count of blocks downloaded in parallel
+}
+
+// Progress of block sync
+type Progress struct {
+	HeaderHeight uint64 // Height of the last verified header
+	BlockHeight  uint64 // Height of the last committed block
+	TargetHeight uint64 // Source height sync goes to
+}
+
+// ProgressFunc is called after each header batch and committed block
+type ProgressFunc func(progress Progress)
+
+// Syncer download headers from the source ahead of blocks and verify them, then download block bodies
+// of verified headers in parallel and commit them in height order.
+// Sync state is the ledger itself, so sync resumes from the current block after restart
+type Syncer struct {
+	ledger   *ledger.Ledger
+	source   BlockSource
+	config   Config
+	progress ProgressFunc
+	quit     chan struct{}
+	once     sync.Once
+}
+
+func NewSyncer(l *ledger.Ledger, source BlockSource, config Config, progress ProgressFunc) *Syncer {
+	if config.HeaderBatch <= 0 {
+		config.HeaderBatch = DefaultHeaderBatch
+	}
+	if config.Workers <= 0 {
+		config.Workers = DefaultWorkers
+	}
+	if progress == nil {
+		progress = func(Progress) {}
+	}
+	return &Syncer{
+		ledger:   l,
+		source:   source,
+		config:   config,
+		progress: progress,
+		quit:     make(chan struct{}),
+	}
+}
+
+// Stop interrupt running sync, Sync return ErrStopped then. It is safe to call it several times
+func (s *Syncer) Stop() {
+	s.once.Do(func() {
+		close(s.quit)
+	})
+}
+
+func (s *Syncer) stopped() bool {
+	select {
+	case <-s.quit:
+		return true
+	default:
+		return false
+	}
+}
+
+// Sync commit blocks up to the source height at the call time
+func (s *Syncer) Sync() error {
+	target, err := s.source.Height()
+	if err != nil {
+		return fmt.Errorf("source Height error %s", err)
+	}
+	for s.ledger.GetCurrentBlockHeight() < target {
+		if s.stopped() {
+			return ErrStopped
+		}
+		if err := s.syncHeaders(target); err != nil {
+			return err
+		}
+		if err := s.syncBlocks(target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// syncHeaders add the next batch of source headers to ledger header cache.
+// Header signed by epoch of not committed block can't be verified yet, so headers are added until such one
+// and it is retried after bodies of the added headers are committed
+func (s *Syncer) syncHeaders(target uint64) error {
+	from := s.ledger.GetCurrentHeaderHeight() + 1
+	if from > target {
+		return nil
+	}
+	count := uint64(s.config.HeaderBatch)
+	if target-from+1 < count {
+		count = target - from + 1
+	}
+	headers, err := s.source.Headers(from, int(count))
+	if err != nil {
+		return fmt.Errorf("source Headers from %d error %s", from, err)
+	}
+	if len(headers) == 0 {
+		return fmt.Errorf("source has no headers from %d", from)
+	}
+	for i, header := range headers {
+		if header.Height != from+uint64(i) {
+			return fmt.Errorf("source header height %d, expected %d", header.Height, from+uint64(i))
+		}
+		if err := s.ledger.AddHeaders([]*types.Header{header}); err != nil {
+			if s.ledger.GetCurrentHeaderHeight() > s.ledger.GetCurrentBlockHeight() {
+				break
+			}
+			return fmt.Errorf("header %d error %s", header.Height, err)
+		}
+	}
+	s.report(target)
+	return nil
+}
+
+// blockResult is downloaded block of the height
+type blockResult struct {
+	block *types.Block
+	err   error
+}
+
+// syncBlocks download bodies of verified headers in parallel and commit them in height order
+func (s *Syncer) syncBlocks(target uint64) error {
+	from, to := s.ledger.GetCurrentBlockHeight()+1, s.ledger.GetCurrentHeaderHeight()
+	if from > to {
+		return nil
+	}
+	results := make([]chan blockResult, to-from+1)
+	heights := make(chan uint64, len(results))
+	for i := range results {
+		results[i] = make(chan blockResult, 1)
+		heights <- from + uint64(i)
+	}
+	close(heights)
+	done := make(chan struct{})
+	defer close(done)
+	for i := 0; i < s.config.Workers; i++ {
+		go func() {
+			for height := range heights {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				block, err := s.source.Block(height)
+				results[height-from] <- blockResult{block: block, err: err}
+			}
+		}()
+	}
+
+	for height := from; height <= to; height++ {
+		var result blockResult
+		select {
+		case result = <-results[height-from]:
+		case <-s.quit:
+			return ErrStopped
+		}
+		if result.err != nil {
+			return fmt.Errorf("source Block %d error %s", height, result.err)
+		}
+		if err := s.commitBlock(height, result.block); err != nil {
+			return err
+		}
+		s.report(target)
+	}
+	return nil
+}
+
+// commitBlock check the block matches verified header of the height and save it to ledger
+func (s *Syncer) commitBlock(height uint64, block *types.Block) error {
+	if block == nil || block.Header == nil {
+		return fmt.Errorf("source block %d is empty", height)
+	}
+	expected := s.ledger.GetBlockHash(height)
+	if blockHash := block.Hash(); blockHash != expected {
+		return fmt.Errorf("source block %d hash %s not equal header hash %s", height, blockHash.ToHexString(), expected.ToHexString())
+	}
+	if err := s.ledger.ExecAndSaveBlock(block); err != nil {
+		return fmt.Errorf("block %d error %s", height, err)
+	}
+	return nil
+}
+
+func (s *Syncer) report(target uint64) {
+	s.progress(Progress{
+		HeaderHeight: s.ledger.GetCurrentHeaderHeight(),
+		BlockHeight:  s.ledger.GetCurrentBlockHeight(),
+		TargetHeight: target,
+	})
+}
@@ -0,0 +1,100 @@
+package blocksync
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/eywa-protocol/wrappers"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eywa-protocol/chain/common"
+	"github.com/eywa-protocol/chain/core/genesis"
+	"github.com/eywa-protocol/chain/core/ledger"
+	"github.com/eywa-protocol/chain/core/payload"
+	"github.com/eywa-protocol/chain/core/types"
+)
+
+func newTestLedger(t *testing.T, dataDir string, genesisBlock *types.Block) *ledger.Ledger {
+	l, err := ledger.NewLedger(dataDir, 0)
+	require.NoError(t, err)
+	if genesisBlock != nil {
+		require.NoError(t, l.Init(genesisBlock))
+	} else {
+		require.NoError(t, l.Load())
+	}
+	return l
+}
+
+// addTestBlocks save count blocks with a request each to the ledger
+func addTestBlocks(t *testing.T, l *ledger.Ledger, count int) {
+	for i := 0; i < count; i++ {
+		height := l.GetCurrentBlockHeight() + 1
+		event := &payload.BridgeEvent{
+			OriginData: wrappers.BridgeOracleRequest{
+				RequestType: "setRequest",
+				RequestId:   [32]byte{byte(height), byte(height >> 8)},
+				ChainId:     big.NewInt(94),
+			}}
+		block, err := l.CreateBlockFromEvents(types.Transactions{types.ToTransaction(event)}, 10+height, common.UINT256_EMPTY)
+		require.NoError(t, err)
+		require.NoError(t, l.ExecAndSaveBlock(block))
+	}
+}
+
+func TestSync(t *testing.T) {
+	genesisBlock, err := genesis.BuildGenesisBlock(0, 10)
+	require.NoError(t, err)
+	source := newTestLedger(t, t.TempDir(), genesisBlock)
+	defer source.Close()
+	addTestBlocks(t, source, 25)
+
+	dataDir := t.TempDir()
+	l := newTestLedger(t, dataDir, genesisBlock)
+	var progress []Progress
+	syncer := NewSyncer(l, NewLedgerSource(source), Config{HeaderBatch: 10, Workers: 3}, func(p Progress) {
+		progress = append(progress, p)
+	})
+	require.NoError(t, syncer.Sync())
+	require.Equal(t, uint64(25), l.GetCurrentBlockHeight())
+	require.Equal(t, source.GetCurrentBlockHash(), l.GetCurrentBlockHash())
+	require.Equal(t, uint64(35), l.GetProcessedHeight())
+	// headers of the batch are verified before its blocks are committed
+	require.Equal(t, Progress{HeaderHeight: 10, BlockHeight: 0, TargetHeight: 25}, progress[0])
+	require.Equal(t, Progress{HeaderHeight: 25, BlockHeight: 25, TargetHeight: 25}, progress[len(progress)-1])
+	require.NoError(t, l.Close())
+
+	// sync resumes from the committed block after restart
+	addTestBlocks(t, source, 5)
+	l = newTestLedger(t, dataDir, nil)
+	defer l.Close()
+	progress = nil
+	require.NoError(t, NewSyncer(l, NewLedgerSource(source), Config{}, func(p Progress) {
+		progress = append(progress, p)
+	}).Sync())
+	require.Equal(t, source.GetCurrentBlockHash(), l.GetCurrentBlockHash())
+	require.Equal(t, Progress{HeaderHeight: 30, BlockHeight: 25, TargetHeight: 30}, progress[0])
+
+	// block body must match verified header
+	addTestBlocks(t, source, 2)
+	blocks := []*types.Block{genesisBlock}
+	for height := uint64(1); height <= 31; height++ {
+		block, err := source.GetBlockByHeight(height)
+		require.NoError(t, err)
+		blocks = append(blocks, block)
+	}
+	block32, err := source.GetBlockByHeight(32)
+	require.NoError(t, err)
+	sink := common.NewZeroCopySink(nil)
+	require.NoError(t, block32.Header.Serialization(sink))
+	header, err := types.HeaderFromRawBytes(sink.Bytes())
+	require.NoError(t, err)
+	memory := NewMemorySource(blocks...)
+	memory.Add(&types.Block{Header: header, Transactions: types.Transactions{}})
+	require.Error(t, NewSyncer(l, memory, Config{}, nil).Sync())
+	require.Equal(t, uint64(31), l.GetCurrentBlockHeight())
+	require.Equal(t, uint64(32), l.GetCurrentHeaderHeight())
+
+	syncer = NewSyncer(l, memory, Config{}, nil)
+	syncer.Stop()
+	require.Equal(t, ErrStopped, syncer.Sync())
+}
@@ -0,0 +1,119 @@
+package events
+
+import (
+	"fmt"
+
+	"github.com/eywa-protocol/chain/common"
+	"github.com/eywa-protocol/chain/core/payload"
+	"github.com/eywa-protocol/chain/core/states"
+	"github.com/eywa-protocol/chain/core/types"
+)
+
+// EventType of ledger event
+type EventType byte
+
+const (
+	EventHeader       EventType = iota + 1 // header of committed block
+	EventBlock                             // committed block
+	EventTransaction                       // transaction of committed block
+	EventRequestState                      // request state change in committed block
+)
+
+func (et EventType) String() string {
+	switch et {
+	case EventHeader:
+		return "header"
+	case EventBlock:
+		return "block"
+	case EventTransaction:
+		return "transaction"
+	case EventRequestState:
+		return "request_state"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseEventType return event type by its name
+func ParseEventType(name string) (EventType, error) {
+	for _, et := range []EventType{EventHeader, EventBlock, EventTransaction, EventRequestState} {
+		if et.String() == name {
+			return et, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown event type %s", name)
+}
+
+// Event is published after block is committed to ledger
+type Event struct {
+	Type         EventType
+	Height       uint64                    // Ledger block height
+	Header       *types.Header             // Block header, set for all events
+	Block        *types.Block              // Set for EventBlock
+	Tx           payload.Payload           // Set for EventTransaction
+	TxHash       common.Uint256            // Set for EventTransaction
+	RequestState *states.RequestStateEntry // Set for EventRequestState
+	DstChainId   uint64                    // Destination chain id of the request, set for EventRequestState
+}
+
+// Filter select events delivered to subscription. Empty filter fields match any event
+type Filter struct {
+	Types       []EventType               // Event types
+	TxTypes     []payload.TransactionType // Transaction types of EventTransaction
+	RequestIds  [][32]byte                // Request ids of EventRequestState
+	DstChainIds []uint64                  // Destination chain ids of EventRequestState
+}
+
+// Match report whether event pass the filter
+func (f *Filter) Match(e *Event) bool {
+	if len(f.Types) > 0 && !containsEventType(f.Types, e.Type) {
+		return false
+	}
+	switch e.Type {
+	case EventTransaction:
+		return len(f.TxTypes) == 0 || containsTxType(f.TxTypes, e.Tx.TxType())
+	case EventRequestState:
+		if len(f.RequestIds) > 0 && !containsRequestId(f.RequestIds, e.RequestState.RequestId) {
+			return false
+		}
+		return len(f.DstChainIds) == 0 || containsUint64(f.DstChainIds, e.DstChainId)
+	default:
+		return true
+	}
+}
+
+func containsEventType(list []EventType, value EventType) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsTxType(list []payload.TransactionType, value payload.TransactionType) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsRequestId(list [][32]byte, value [32]byte) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsUint64(list []uint64, value uint64) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,157 @@
+package events
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+const DefaultBufferSize = 256 // Default count of events buffered for subscriber
+
+var (
+	ErrSlowConsumer = errors.New("subscriber buffer overflow")
+	ErrHubClosed    = errors.New("event hub closed")
+)
+
+// OverflowPolicy define what happens when subscriber buffer is full
+type OverflowPolicy byte
+
+const (
+	OverflowDisconnect OverflowPolicy = iota // close subscription with ErrSlowConsumer
+	OverflowDrop                             // drop the event and count it
+)
+
+// Subscription receive filtered events in publish order until it is closed
+type Subscription struct {
+	id      uint64
+	hub     *Hub
+	filter  Filter
+	policy  OverflowPolicy
+	ch      chan *Event
+	dropped uint64
+	err     error
+}
+
+// Id return subscription id unique within the hub
+func (s *Subscription) Id() uint64 {
+	return s.id
+}
+
+// Events return channel of events, it is closed when subscription is closed
+func (s *Subscription) Events() <-chan *Event {
+	return s.ch
+}
+
+// Err return reason subscription was closed by hub, nil if it is open or unsubscribed
+func (s *Subscription) Err() error {
+	s.hub.lock.RLock()
+	defer s.hub.lock.RUnlock()
+	return s.err
+}
+
+// Dropped return count of events dropped by OverflowDrop policy
+func (s *Subscription) Dropped() uint64 {
+	return atomic.LoadUint64(&s.dropped)
+}
+
+// Unsubscribe close subscription, it is safe to call it several times
+func (s *Subscription) Unsubscribe() {
+	s.hub.lock.Lock()
+	defer s.hub.lock.Unlock()
+	s.hub.remove(s, nil)
+}
+
+// Hub deliver published events to subscribers without blocking publisher
+type Hub struct {
+	lock   sync.RWMutex
+	subs   map[uint64]*Subscription
+	nextId uint64
+	closed bool
+}
+
+// NewHub return empty hub
+func NewHub() *Hub {
+	return &Hub{subs: make(map[uint64]*Subscription)}
+}
+
+// Subscribe return subscription buffering up to bufferSize events, DefaultBufferSize is used if bufferSize is not positive
+func (h *Hub) Subscribe(filter Filter, bufferSize int, policy OverflowPolicy) *Subscription {
+	if bufferSize <= 0 {
+		bufferSize = DefaultBufferSize
+	}
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.nextId++
+	sub := &Subscription{
+		id:     h.nextId,
+		hub:    h,
+		filter: filter,
+		policy: policy,
+		ch:     make(chan *Event, bufferSize),
+	}
+	if h.closed {
+		sub.err = ErrHubClosed
+		close(sub.ch)
+		return sub
+	}
+	h.subs[sub.id] = sub
+	return sub
+}
+
+// Publish deliver events to matching subscribers
+func (h *Hub) Publish(events ...*Event) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	for _, sub := range h.subs {
+		for _, e := range events {
+			if !sub.filter.Match(e) {
+				continue
+			}
+			if h.send(sub, e) {
+				continue
+			}
+			if sub.policy == OverflowDrop {
+				atomic.AddUint64(&sub.dropped, 1)
+				continue
+			}
+			h.remove(sub, ErrSlowConsumer)
+			break
+		}
+	}
+}
+
+// Count return count of active subscriptions
+func (h *Hub) Count() int {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+	return len(h.subs)
+}
+
+// Close close all subscriptions with ErrHubClosed, next subscriptions are closed immediately
+func (h *Hub) Close() {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.closed = true
+	for _, sub := range h.subs {
+		h.remove(sub, ErrHubClosed)
+	}
+}
+
+func (h *Hub) send(sub *Subscription, e *Event) bool {
+	select {
+	case sub.ch <- e:
+		return true
+	default:
+		return false
+	}
+}
+
+// remove close subscription channel, must be called with write lock held
+func (h *Hub) remove(sub *Subscription, err error) {
+	if _, ok := h.subs[sub.id]; !ok {
+		return
+	}
+	delete(h.subs, sub.id)
+	sub.err = err
+	close(sub.ch)
+}
@@ -0,0 +1,69 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/eywa-protocol/chain/core/payload"
+	"github.com/eywa-protocol/chain/core/states"
+)
+
+func TestFilterMatch(t *testing.T) {
+	bridgeTx := &Event{Type: EventTransaction, Tx: &payload.BridgeEvent{}}
+	request := &Event{Type: EventRequestState, RequestState: &states.RequestStateEntry{RequestId: [32]byte{1}}, DstChainId: 94}
+	header := &Event{Type: EventHeader}
+
+	filter := Filter{}
+	require.True(t, filter.Match(bridgeTx))
+	require.True(t, filter.Match(request))
+	require.True(t, filter.Match(header))
+
+	filter = Filter{Types: []EventType{EventTransaction}, TxTypes: []payload.TransactionType{payload.EpochType}}
+	require.False(t, filter.Match(bridgeTx))
+	require.False(t, filter.Match(header))
+	filter.TxTypes = append(filter.TxTypes, payload.BridgeEventType)
+	require.True(t, filter.Match(bridgeTx))
+
+	filter = Filter{RequestIds: [][32]byte{{1}}, DstChainIds: []uint64{95}}
+	require.False(t, filter.Match(request))
+	filter.DstChainIds = append(filter.DstChainIds, 94)
+	require.True(t, filter.Match(request))
+	filter.RequestIds = [][32]byte{{2}}
+	require.False(t, filter.Match(request))
+}
+
+func TestHubOverflow(t *testing.T) {
+	hub := NewHub()
+	drop := hub.Subscribe(Filter{}, 2, OverflowDrop)
+	disconnect := hub.Subscribe(Filter{}, 2, OverflowDisconnect)
+	headers := hub.Subscribe(Filter{Types: []EventType{EventHeader}}, 2, OverflowDisconnect)
+	require.Equal(t, 3, hub.Count())
+
+	hub.Publish(&Event{Type: EventHeader, Height: 1}, &Event{Type: EventBlock, Height: 1}, &Event{Type: EventHeader, Height: 2})
+	require.Equal(t, 2, hub.Count())
+	require.Equal(t, uint64(1), drop.Dropped())
+	require.Equal(t, ErrSlowConsumer, disconnect.Err())
+	require.NoError(t, headers.Err())
+
+	var heights []uint64
+	for e := range disconnect.Events() {
+		heights = append(heights, e.Height)
+	}
+	require.Equal(t, []uint64{1, 1}, heights)
+	require.Equal(t, uint64(1), (<-headers.Events()).Height)
+	require.Equal(t, uint64(2), (<-headers.Events()).Height)
+
+	headers.Unsubscribe()
+	headers.Unsubscribe()
+	_, ok := <-headers.Events()
+	require.False(t, ok)
+	require.NoError(t, headers.Err())
+
+	hub.Close()
+	require.Equal(t, ErrHubClosed, drop.Err())
+	late := hub.Subscribe(Filter{}, 0, OverflowDrop)
+	_, ok = <-late.Events()
+	require.False(t, ok)
+	require.Equal(t, ErrHubClosed, late.Err())
+}
@@ -0,0 +1,137 @@
+package genesis
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/eywa-protocol/bls-crypto/bls"
+	"gopkg.in/yaml.v2"
+
+	"github.com/eywa-protocol/chain/common"
+	"github.com/eywa-protocol/chain/core/payload"
+	"github.com/eywa-protocol/chain/core/states"
+	"github.com/eywa-protocol/chain/core/types"
+)
+
+// Config is genesis configuration, nodes of the chain must use the same one
+type Config struct {
+	ChainId      uint64      `json:"chain_id" yaml:"chain_id"`
+	SourceHeight uint64      `json:"source_height" yaml:"source_height"` // Source chain height of genesis block
+	Epoch        EpochConfig `json:"epoch" yaml:"epoch"`
+	Params       ChainParams `json:"params" yaml:"params"`
+}
+
+// EpochConfig is the initial epoch included to genesis block
+type EpochConfig struct {
+	PublicKeys []string `json:"public_keys" yaml:"public_keys"` // Hex encoded BLS public keys of epoch participants
+	HostIds    []string `json:"host_ids" yaml:"host_ids"`       // Host IDs of epoch participants in the order of public keys
+}
+
+// ChainParams are ledger parameters persisted at genesis, omitted ones keep ledger defaults
+type ChainParams struct {
+	QuorumThreshold    *uint64           `json:"quorum_threshold,omitempty" yaml:"quorum_threshold,omitempty"`       // Percent of epoch participants required to sign block header
+	RequestExpiry      *uint64           `json:"request_expiry,omitempty" yaml:"request_expiry,omitempty"`           // Blocks after which undelivered request is expired
+	ConfirmationDepths map[uint64]uint64 `json:"confirmation_depths,omitempty" yaml:"confirmation_depths,omitempty"` // Source chain id => confirmation depth
+}
+
+// LoadConfig read genesis config from the file, files with .yaml or .yml extension are parsed as YAML, others as JSON.
+// Unknown fields are rejected so misspelled parameters are not silently ignored
+func LoadConfig(fileName string) (*Config, error) {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	config := new(Config)
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".yaml", ".yml":
+		err = yaml.UnmarshalStrict(data, config)
+	default:
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(config)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("genesis config %s parse error %s", fileName, err)
+	}
+	if err = config.Validate(); err != nil {
+		return nil, fmt.Errorf("genesis config %s error %s", fileName, err)
+	}
+	return config, nil
+}
+
+// Validate check the initial epoch and chain parameters
+func (c *Config) Validate() error {
+	if len(c.Epoch.PublicKeys) == 0 {
+		return errors.New("initial epoch has no public keys")
+	}
+	if len(c.Epoch.HostIds) != len(c.Epoch.PublicKeys) {
+		return fmt.Errorf("initial epoch has %d host ids for %d public keys", len(c.Epoch.HostIds), len(c.Epoch.PublicKeys))
+	}
+	if _, err := c.publicKeys(); err != nil {
+		return err
+	}
+	if threshold := c.Params.QuorumThreshold; threshold != nil && *threshold > 100 {
+		return fmt.Errorf("quorum threshold %d above 100 percent", *threshold)
+	}
+	return nil
+}
+
+func (c *Config) publicKeys() ([]bls.PublicKey, error) {
+	keys := make([]bls.PublicKey, 0, len(c.Epoch.PublicKeys))
+	for i, str := range c.Epoch.PublicKeys {
+		data, err := hex.DecodeString(strings.TrimPrefix(str, "0x"))
+		if err != nil {
+			return nil, fmt.Errorf("public key %d decode error %s", i, err)
+		}
+		key, err := bls.UnmarshalPublicKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("public key %d unmarshal error %s", i, err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// State return chain parameters the ledger persists with genesis block
+func (p *ChainParams) State() *states.ChainParams {
+	return &states.ChainParams{
+		QuorumThreshold:    p.QuorumThreshold,
+		RequestExpiry:      p.RequestExpiry,
+		ConfirmationDepths: p.ConfirmationDepths,
+	}
+}
+
+// ParamsHash return hash of chain parameters persisted by the ledger
+func (p *ChainParams) ParamsHash() common.Uint256 {
+	return p.State().Hash()
+}
+
+// BuildGenesisBlockFromConfig returns the genesis block including the initial epoch event.
+// Chain parameters hash is used as the epoch source transaction, so genesis hash binds the parameters as well
+func BuildGenesisBlockFromConfig(config *Config) (*types.Block, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	keys, err := config.publicKeys()
+	if err != nil {
+		return nil, err
+	}
+	epoch := payload.NewEpochEvent(0, config.Params.ParamsHash(), keys, config.Epoch.HostIds)
+	txs := types.Transactions{types.ToTransaction(epoch)}
+	return types.NewBlock(config.ChainId, common.Uint256{}, common.Uint256{}, config.SourceHeight, 0, txs), nil
+}
+
+// GenesisHash returns hash of the genesis block built from config to compare it across nodes
+func (c *Config) GenesisHash() (common.Uint256, error) {
+	block, err := BuildGenesisBlockFromConfig(c)
+	if err != nil {
+		return common.UINT256_EMPTY, err
+	}
+	return block.Hash(), nil
+}
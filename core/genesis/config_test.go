@@ -0,0 +1,99 @@
+package genesis
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/eywa-protocol/bls-crypto/bls"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eywa-protocol/chain/core/ledger"
+)
+
+func writeConfig(t *testing.T, name, data string) string {
+	fileName := filepath.Join(t.TempDir(), name)
+	require.NoError(t, ioutil.WriteFile(fileName, []byte(data), 0644))
+	return fileName
+}
+
+func TestGenesisConfig(t *testing.T) {
+	_, pubKey1 := bls.GenerateRandomKey()
+	_, pubKey2 := bls.GenerateRandomKey()
+	key1, key2 := hex.EncodeToString(pubKey1.Marshal()), hex.EncodeToString(pubKey2.Marshal())
+
+	jsonConfig, err := LoadConfig(writeConfig(t, "genesis.json", `{
+		"chain_id": 1111,
+		"source_height": 100,
+		"epoch": {"public_keys": ["`+key1+`", "0x`+key2+`"], "host_ids": ["one", "two"]},
+		"params": {"quorum_threshold": 50, "confirmation_depths": {"94": 20, "95": 5}}
+	}`))
+	require.NoError(t, err)
+	yamlConfig, err := LoadConfig(writeConfig(t, "genesis.yaml", `
+chain_id: 1111
+source_height: 100
+epoch:
+  public_keys: ["`+key1+`", "`+key2+`"]
+  host_ids: [one, two]
+params:
+  quorum_threshold: 50
+  confirmation_depths:
+    95: 5
+    94: 20
+`))
+	require.NoError(t, err)
+	genesisHash, err := jsonConfig.GenesisHash()
+	require.NoError(t, err)
+	yamlHash, err := yamlConfig.GenesisHash()
+	require.NoError(t, err)
+	require.Equal(t, genesisHash, yamlHash)
+
+	// any chain parameter changes genesis hash
+	expiry := uint64(100)
+	yamlConfig.Params.RequestExpiry = &expiry
+	yamlHash, err = yamlConfig.GenesisHash()
+	require.NoError(t, err)
+	require.NotEqual(t, genesisHash, yamlHash)
+
+	_, err = LoadConfig(writeConfig(t, "unknown.json", `{"chain_id": 1, "quorum": 50}`))
+	require.Error(t, err)
+	_, err = LoadConfig(writeConfig(t, "hosts.yml", "epoch:\n  public_keys: ["+key1+"]\n  host_ids: [one, two]\n"))
+	require.Error(t, err)
+	_, err = LoadConfig(writeConfig(t, "key.yml", "epoch:\n  public_keys: [abcd]\n  host_ids: [one]\n"))
+	require.Error(t, err)
+
+	dataDir := t.TempDir()
+	l, err := ledger.NewLedger(dataDir, jsonConfig.ChainId)
+	require.NoError(t, err)
+	block, err := BuildGenesisBlockFromConfig(jsonConfig)
+	require.NoError(t, err)
+	// parameters must be the ones genesis block is built with
+	require.Error(t, l.InitWithChainParams(block, yamlConfig.Params.State()))
+	require.NoError(t, l.InitWithChainParams(block, jsonConfig.Params.State()))
+	require.Equal(t, genesisHash, l.GetCurrentBlockHash())
+	require.Equal(t, uint64(100), l.GetProcessedHeight())
+	require.Equal(t, uint64(50), l.GetQuorumThreshold())
+	require.Equal(t, uint64(20), l.GetConfirmationDepth(94))
+
+	state, err := l.GetEpochState()
+	require.NoError(t, err)
+	require.Len(t, state.CurrEpoch, 2)
+	epoch, err := l.GetEpochAtHeight(0)
+	require.NoError(t, err)
+	require.Equal(t, genesisHash, epoch.BlockHash)
+	require.Equal(t, []string{"one", "two"}, epoch.HostIds)
+	require.Equal(t, jsonConfig.Params.ParamsHash(), epoch.SourceTx)
+
+	// parameters are restored on load
+	require.NoError(t, l.Close())
+	l, err = ledger.NewLedger(dataDir, jsonConfig.ChainId)
+	require.NoError(t, err)
+	defer l.Close()
+	require.NoError(t, l.Load())
+	require.Equal(t, uint64(50), l.GetQuorumThreshold())
+	require.Equal(t, uint64(5), l.GetConfirmationDepth(95))
+	params, err := l.GetChainParams()
+	require.NoError(t, err)
+	require.Equal(t, jsonConfig.Params.ParamsHash(), params.Hash())
+}
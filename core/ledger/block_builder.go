@@ -0,0 +1,81 @@
+package ledger
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/eywa-protocol/chain/common"
+	"github.com/eywa-protocol/chain/core/payload"
+	scom "github.com/eywa-protocol/chain/core/store/common"
+	"github.com/eywa-protocol/chain/core/types"
+)
+
+const DefaultMaxBlockTxs = 1000 // Default count of transactions included to block
+
+// TxSource provide transactions ordered by source height for the next block, implemented by txpool.TxPool
+type TxSource interface {
+	Batch(maxCount int) types.Transactions
+}
+
+// BlockBuilder assemble the next block on top of the current ledger tip from pending transactions
+type BlockBuilder struct {
+	ledger *Ledger
+	txs    TxSource
+	maxTxs int
+}
+
+func NewBlockBuilder(l *Ledger, txs TxSource, maxTxs int) *BlockBuilder {
+	if maxTxs <= 0 {
+		maxTxs = DefaultMaxBlockTxs
+	}
+	return &BlockBuilder{
+		ledger: l,
+		txs:    txs,
+		maxTxs: maxTxs,
+	}
+}
+
+// Build return unsigned block following the current block. Block source height is the highest source height
+// of selected transactions or processedHeight reported by watchers if it is higher and the block is not full.
+// Source events left out of the block may be covered by its source height, they are included to later blocks:
+// events beyond the limit of full block and request changes waiting for the previous change of the request.
+// Source height must be above source height of the current block
+func (b *BlockBuilder) Build(processedHeight uint64) (*types.Block, error) {
+	prevHash := b.ledger.GetCurrentBlockHash()
+	prevHeader, err := b.ledger.GetHeaderByHash(prevHash)
+	if err != nil {
+		return nil, fmt.Errorf("GetHeaderByHash %s error %s", prevHash.ToHexString(), err)
+	}
+	epochBlockHash, err := b.epochBlockHash(prevHeader.Height)
+	if err != nil {
+		return nil, err
+	}
+
+	txs := b.txs.Batch(b.maxTxs)
+	sourceHeight := uint64(0)
+	if len(txs) < b.maxTxs {
+		sourceHeight = processedHeight
+	}
+	for _, tx := range txs {
+		if event, ok := tx.Payload.(payload.SourceEvent); ok {
+			if height, _ := event.SrcBlock(); height > sourceHeight {
+				sourceHeight = height
+			}
+		}
+	}
+	if sourceHeight <= prevHeader.SourceHeight {
+		return nil, fmt.Errorf("block source height %d is not above current source height %d", sourceHeight, prevHeader.SourceHeight)
+	}
+	return types.NewBlock(b.ledger.GetChainId(), prevHash, epochBlockHash, sourceHeight, prevHeader.Height+1, txs), nil
+}
+
+// epochBlockHash return hash of the block with epoch active after block of the height, empty hash if ledger has no epoch
+func (b *BlockBuilder) epochBlockHash(height uint64) (common.Uint256, error) {
+	epoch, err := b.ledger.GetEpochAtHeight(height)
+	if errors.Is(err, scom.ErrNotFound) {
+		return common.UINT256_EMPTY, nil
+	} else if err != nil {
+		return common.UINT256_EMPTY, fmt.Errorf("GetEpochAtHeight %d error %s", height, err)
+	}
+	return epoch.BlockHash, nil
+}
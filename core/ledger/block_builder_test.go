@@ -0,0 +1,84 @@
+package ledger
+
+import (
+	"math/big"
+	"testing"
+
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/eywa-protocol/bls-crypto/bls"
+	"github.com/eywa-protocol/wrappers"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eywa-protocol/chain/common"
+	"github.com/eywa-protocol/chain/core/payload"
+	"github.com/eywa-protocol/chain/core/txpool"
+	"github.com/eywa-protocol/chain/core/types"
+)
+
+var _ TxSource = (*txpool.TxPool)(nil)
+
+type testTxSource types.Transactions
+
+func (s testTxSource) Batch(maxCount int) types.Transactions {
+	if len(s) > maxCount {
+		return types.Transactions(s[:maxCount])
+	}
+	return types.Transactions(s)
+}
+
+func newBuilderTestRequest(reqId [32]byte, srcHeight uint64) types.Transactions {
+	return types.Transactions{types.ToTransaction(&payload.BridgeEvent{
+		OriginData: wrappers.BridgeOracleRequest{
+			RequestType: "setRequest",
+			RequestId:   reqId,
+			ChainId:     big.NewInt(94),
+			Raw:         ethTypes.Log{BlockNumber: srcHeight},
+		}})}
+}
+
+func TestBlockBuilder(t *testing.T) {
+	l, err := NewLedger(t.TempDir(), 5)
+	require.NoError(t, err)
+	defer l.Close()
+	_, pubKey := bls.GenerateRandomKey()
+	epoch := payload.NewEpochEvent(1, common.UINT256_EMPTY, []bls.PublicKey{pubKey}, []string{"one"})
+	genesisBlock := types.NewBlock(5, common.UINT256_EMPTY, common.UINT256_EMPTY, 10, 0, types.Transactions{types.ToTransaction(epoch)})
+	require.NoError(t, l.Init(genesisBlock))
+
+	var txs types.Transactions
+	txs = append(txs, newBuilderTestRequest([32]byte{1}, 15)...)
+	txs = append(txs, newBuilderTestRequest([32]byte{2}, 12)...)
+	txs = append(txs, newBuilderTestRequest([32]byte{3}, 20)...)
+
+	// full block covers source heights of its transactions only
+	block, err := NewBlockBuilder(l, testTxSource(txs), 2).Build(30)
+	require.NoError(t, err)
+	require.Len(t, block.Transactions, 2)
+	require.Equal(t, uint64(1), block.Header.Height)
+	require.Equal(t, uint64(5), block.Header.ChainID)
+	require.Equal(t, uint64(15), block.Header.SourceHeight)
+	require.Equal(t, genesisBlock.Hash(), block.Header.PrevBlockHash)
+	require.Equal(t, genesisBlock.Hash(), block.Header.EpochBlockHash)
+	require.NoError(t, l.ExecAndSaveBlock(block))
+
+	// processed height below source height of selected transaction doesn't lower block source height
+	builder := NewBlockBuilder(l, testTxSource(txs[2:]), 0)
+	block, err = builder.Build(14)
+	require.NoError(t, err)
+	require.Equal(t, uint64(20), block.Header.SourceHeight)
+	block, err = builder.Build(25)
+	require.NoError(t, err)
+	require.Len(t, block.Transactions, 1)
+	require.Equal(t, uint64(25), block.Header.SourceHeight)
+	require.Equal(t, l.GetCurrentBlockHash(), block.Header.PrevBlockHash)
+	require.NoError(t, l.ExecAndSaveBlock(block))
+
+	// source height must grow
+	builder = NewBlockBuilder(l, testTxSource(nil), 0)
+	_, err = builder.Build(25)
+	require.Error(t, err)
+	block, err = builder.Build(26)
+	require.NoError(t, err)
+	require.Empty(t, block.Transactions)
+	require.Equal(t, uint64(3), block.Header.Height)
+}
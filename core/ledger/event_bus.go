@@ -0,0 +1,277 @@
+package ledger
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/eywa-protocol/chain/core/events"
+	"github.com/eywa-protocol/chain/core/payload"
+	"github.com/eywa-protocol/chain/core/states"
+	"github.com/eywa-protocol/chain/core/types"
+)
+
+const DefaultSubscriptionBufferSize = 64 // Default count of items buffered for ledger subscriber
+
+// subscription deliver committed blocks in height order starting from the given height.
+// Blocks are read from the store, event hub is only used to wake up after commit,
+// so slow subscriber never loses a block and never blocks block saving.
+// Delivery moves back to the block following rollback height when delivered blocks are rolled back,
+// so blocks of the new chain are delivered again from that height
+type subscription struct {
+	ledger   *Ledger
+	notify   *events.Subscription // Header events of committed blocks
+	next     uint64               // Height of the next block to deliver
+	rollback uint64               // Rollback height + 1 not handled yet, 0 if there is no such one
+	wake     chan struct{}
+	quit     chan struct{}
+	done     chan struct{}
+	once     sync.Once
+	lock     sync.Mutex
+	err      error
+}
+
+func (l *Ledger) newSubscription(fromHeight uint64) *subscription {
+	sub := &subscription{
+		ledger: l,
+		notify: l.ldgStore.GetEventHub().Subscribe(events.Filter{Types: []events.EventType{events.EventHeader}}, 1, events.OverflowDrop),
+		next:   fromHeight,
+		wake:   make(chan struct{}, 1),
+		quit:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	l.subsLock.Lock()
+	l.subs[sub] = struct{}{}
+	l.subsLock.Unlock()
+	return sub
+}
+
+// NextHeight return height of the next block to deliver, subscriber may persist it to resume after restart
+func (s *subscription) NextHeight() uint64 {
+	return atomic.LoadUint64(&s.next)
+}
+
+// Err return error delivery was stopped with, nil if subscription is active or unsubscribed
+func (s *subscription) Err() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.err
+}
+
+// Unsubscribe stop delivery and close subscription channel, it is safe to call it several times
+func (s *subscription) Unsubscribe() {
+	s.once.Do(func() {
+		close(s.quit)
+	})
+	<-s.done
+}
+
+// rolledBack record ledger rollback to height, the lowest one is kept until delivery handles it
+func (s *subscription) rolledBack(height uint64) {
+	for {
+		pending := atomic.LoadUint64(&s.rollback)
+		if pending != 0 && pending <= height+1 {
+			break
+		}
+		if atomic.CompareAndSwapUint64(&s.rollback, pending, height+1) {
+			break
+		}
+	}
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// checkRollback move delivery back to the block following rollback height if blocks above it were delivered.
+// Report whether rollback happened since the last check
+func (s *subscription) checkRollback() bool {
+	pending := atomic.SwapUint64(&s.rollback, 0)
+	if pending == 0 {
+		return false
+	}
+	if pending < s.NextHeight() {
+		atomic.StoreUint64(&s.next, pending)
+	}
+	return true
+}
+
+func (s *subscription) setErr(err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.err = err
+}
+
+// start deliver events of committed blocks until deliver return false, stop is called before subscription is done
+func (s *subscription) start(deliver func(blockEvents []*events.Event) bool, stop func()) {
+	go func() {
+		defer close(s.done)
+		defer stop()
+		defer s.notify.Unsubscribe()
+		defer func() {
+			s.ledger.subsLock.Lock()
+			delete(s.ledger.subs, s)
+			s.ledger.subsLock.Unlock()
+		}()
+		for {
+			s.checkRollback()
+			for height := s.NextHeight(); height <= s.ledger.GetCurrentBlockHeight(); height = s.NextHeight() {
+				blockEvents, err := s.ledger.ldgStore.GetBlockEvents(height)
+				if err != nil {
+					// block may be removed by rollback after height check
+					if s.checkRollback() {
+						continue
+					}
+					s.setErr(fmt.Errorf("GetBlockEvents height %d error %s", height, err))
+					return
+				}
+				if !deliver(blockEvents) {
+					return
+				}
+				atomic.StoreUint64(&s.next, height+1)
+				s.checkRollback()
+			}
+			select {
+			case _, ok := <-s.notify.Events():
+				if !ok {
+					s.setErr(s.notify.Err())
+					return
+				}
+			case <-s.wake:
+			case <-s.quit:
+				return
+			}
+		}
+	}()
+}
+
+// BlockSubscription receive committed blocks
+type BlockSubscription struct {
+	*subscription
+	blocks chan *types.Block
+}
+
+// Blocks return channel of blocks, it is closed when subscription is stopped
+func (s *BlockSubscription) Blocks() <-chan *types.Block {
+	return s.blocks
+}
+
+// RequestChange is request state change committed to ledger
+type RequestChange struct {
+	*states.RequestStateEntry
+	DstChainId uint64 // Destination chain id of the request
+}
+
+// RequestSubscription receive committed request state changes
+type RequestSubscription struct {
+	*subscription
+	requests chan *RequestChange
+}
+
+// Requests return channel of request state changes, it is closed when subscription is stopped
+func (s *RequestSubscription) Requests() <-chan *RequestChange {
+	return s.requests
+}
+
+// EpochSubscription receive applied epochs
+type EpochSubscription struct {
+	*subscription
+	epochs chan *states.EpochInfo
+}
+
+// Epochs return channel of applied epochs, it is closed when subscription is stopped
+func (s *EpochSubscription) Epochs() <-chan *states.EpochInfo {
+	return s.epochs
+}
+
+// SubscribeBlocks deliver blocks committed from fromHeight in height order.
+// Block delivered at the height already delivered replaces the rolled back one and the blocks above it.
+// DefaultSubscriptionBufferSize is used if bufferSize is not positive
+func (l *Ledger) SubscribeBlocks(fromHeight uint64, bufferSize int) *BlockSubscription {
+	sub := &BlockSubscription{
+		subscription: l.newSubscription(fromHeight),
+		blocks:       make(chan *types.Block, subscriptionBufferSize(bufferSize)),
+	}
+	sub.start(func(blockEvents []*events.Event) bool {
+		for _, e := range blockEvents {
+			if e.Type != events.EventBlock {
+				continue
+			}
+			select {
+			case sub.blocks <- e.Block:
+			case <-sub.quit:
+				return false
+			}
+		}
+		return true
+	}, func() {
+		close(sub.blocks)
+	})
+	return sub
+}
+
+// SubscribeRequests deliver request state changes committed from fromHeight in height order.
+// Only RequestIds and DstChainIds of the filter are applied, empty filter match all requests
+func (l *Ledger) SubscribeRequests(fromHeight uint64, filter events.Filter, bufferSize int) *RequestSubscription {
+	filter.Types = []events.EventType{events.EventRequestState}
+	sub := &RequestSubscription{
+		subscription: l.newSubscription(fromHeight),
+		requests:     make(chan *RequestChange, subscriptionBufferSize(bufferSize)),
+	}
+	sub.start(func(blockEvents []*events.Event) bool {
+		for _, e := range blockEvents {
+			if !filter.Match(e) {
+				continue
+			}
+			select {
+			case sub.requests <- &RequestChange{RequestStateEntry: e.RequestState, DstChainId: e.DstChainId}:
+			case <-sub.quit:
+				return false
+			}
+		}
+		return true
+	}, func() {
+		close(sub.requests)
+	})
+	return sub
+}
+
+// SubscribeEpochChanges deliver epochs applied from fromHeight in height order
+func (l *Ledger) SubscribeEpochChanges(fromHeight uint64, bufferSize int) *EpochSubscription {
+	sub := &EpochSubscription{
+		subscription: l.newSubscription(fromHeight),
+		epochs:       make(chan *states.EpochInfo, subscriptionBufferSize(bufferSize)),
+	}
+	sub.start(func(blockEvents []*events.Event) bool {
+		for _, e := range blockEvents {
+			if e.Type != events.EventTransaction {
+				continue
+			}
+			epochEvent, ok := e.Tx.(*payload.EpochEvent)
+			if !ok {
+				continue
+			}
+			epoch, err := l.ldgStore.GetEpochByNumber(epochEvent.Number)
+			if err != nil {
+				sub.setErr(fmt.Errorf("GetEpochByNumber %d error %s", epochEvent.Number, err))
+				return false
+			}
+			select {
+			case sub.epochs <- epoch:
+			case <-sub.quit:
+				return false
+			}
+		}
+		return true
+	}, func() {
+		close(sub.epochs)
+	})
+	return sub
+}
+
+func subscriptionBufferSize(bufferSize int) int {
+	if bufferSize <= 0 {
+		return DefaultSubscriptionBufferSize
+	}
+	return bufferSize
+}
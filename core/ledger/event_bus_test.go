@@ -0,0 +1,103 @@
+package ledger
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/eywa-protocol/bls-crypto/bls"
+	"github.com/eywa-protocol/wrappers"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eywa-protocol/chain/common"
+	"github.com/eywa-protocol/chain/core/events"
+	"github.com/eywa-protocol/chain/core/payload"
+	"github.com/eywa-protocol/chain/core/types"
+)
+
+func saveTestRequest(t *testing.T, l *Ledger, reqId [32]byte, chainId int64) *types.Block {
+	event := &payload.BridgeEvent{
+		OriginData: wrappers.BridgeOracleRequest{
+			RequestType: "setRequest",
+			RequestId:   reqId,
+			ChainId:     big.NewInt(chainId),
+		}}
+	block, err := l.CreateBlockFromEvents(types.Transactions{types.ToTransaction(event)}, 10+l.GetCurrentBlockHeight(), common.UINT256_EMPTY)
+	require.NoError(t, err)
+	require.NoError(t, l.ExecAndSaveBlock(block))
+	return block
+}
+
+func receiveBlock(t *testing.T, sub *BlockSubscription) *types.Block {
+	select {
+	case block := <-sub.Blocks():
+		return block
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "block is not delivered")
+		return nil
+	}
+}
+
+func TestEventBus(t *testing.T) {
+	l, err := NewLedger(t.TempDir(), 0)
+	require.NoError(t, err)
+	_, pubKey := bls.GenerateRandomKey()
+	epoch := payload.NewEpochEvent(1, common.UINT256_EMPTY, []bls.PublicKey{pubKey}, []string{"one"})
+	genesisBlock := types.NewBlock(0, common.UINT256_EMPTY, common.UINT256_EMPTY, 10, 0, types.Transactions{types.ToTransaction(epoch)})
+	require.NoError(t, l.Init(genesisBlock))
+
+	reqId1, reqId2 := [32]byte{1}, [32]byte{2}
+	block1 := saveTestRequest(t, l, reqId1, 94)
+	block2 := saveTestRequest(t, l, reqId2, 95)
+
+	// stored blocks are delivered before committed ones through the buffer of one block
+	blocks := l.SubscribeBlocks(1, 1)
+	require.Equal(t, block1.Hash(), receiveBlock(t, blocks).Hash())
+	require.Equal(t, block2.Hash(), receiveBlock(t, blocks).Hash())
+	block3 := saveTestRequest(t, l, [32]byte{3}, 94)
+	require.Equal(t, block3.Hash(), receiveBlock(t, blocks).Hash())
+	require.Eventually(t, func() bool { return blocks.NextHeight() == 4 }, 5*time.Second, 10*time.Millisecond)
+	blocks.Unsubscribe()
+	blocks.Unsubscribe()
+	_, ok := <-blocks.Blocks()
+	require.False(t, ok)
+	require.NoError(t, blocks.Err())
+
+	// resume from the height of the last processed block
+	resumed := l.SubscribeBlocks(3, 0)
+	require.Equal(t, block3.Hash(), receiveBlock(t, resumed).Hash())
+	resumed.Unsubscribe()
+
+	requests := l.SubscribeRequests(0, events.Filter{DstChainIds: []uint64{95}}, 0)
+	change := <-requests.Requests()
+	require.Equal(t, reqId2, change.RequestId)
+	require.Equal(t, payload.ReqStateReceived, change.State)
+	require.Equal(t, uint64(2), change.Height)
+	require.Equal(t, uint64(95), change.DstChainId)
+	requests.Unsubscribe()
+
+	epochs := l.SubscribeEpochChanges(0, 0)
+	epochInfo := <-epochs.Epochs()
+	require.Equal(t, epoch.Number, epochInfo.Number)
+	require.Equal(t, uint64(0), epochInfo.Height)
+	epochs.Unsubscribe()
+
+	// blocks of the new chain are delivered from the block following rollback height
+	rolled := l.SubscribeBlocks(1, 0)
+	for _, block := range []*types.Block{block1, block2, block3} {
+		require.Equal(t, block.Hash(), receiveBlock(t, rolled).Hash())
+	}
+	require.Eventually(t, func() bool { return rolled.NextHeight() == 4 }, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, l.RollbackTo(1))
+	require.Eventually(t, func() bool { return rolled.NextHeight() == 2 }, 5*time.Second, 10*time.Millisecond)
+	newBlock2 := saveTestRequest(t, l, [32]byte{4}, 95)
+	require.NotEqual(t, block2.Hash(), newBlock2.Hash())
+	require.Equal(t, newBlock2.Hash(), receiveBlock(t, rolled).Hash())
+	rolled.Unsubscribe()
+
+	waiting := l.SubscribeBlocks(100, 0)
+	require.NoError(t, l.Close())
+	_, ok = <-waiting.Blocks()
+	require.False(t, ok)
+	require.Equal(t, events.ErrHubClosed, waiting.Err())
+}
@@ -3,8 +3,11 @@ package ledger
 import (
 	"bytes"
 	"fmt"
+	"io"
+	"sync"
 
 	"github.com/eywa-protocol/chain/common"
+	"github.com/eywa-protocol/chain/core/events"
 	"github.com/eywa-protocol/chain/core/payload"
 	"github.com/eywa-protocol/chain/core/states"
 	"github.com/eywa-protocol/chain/core/store"
@@ -18,16 +21,23 @@ import (
 type Ledger struct {
 	ldgStore store.LedgerStore
 	chainId  uint64
+	subs     map[*subscription]struct{} // Active subscriptions, moved back by rollback
+	subsLock sync.Mutex
 }
 
 func NewLedger(dataDir string, chainId uint64) (*Ledger, error) {
-	ldgStore, err := ledgerstore.NewLedgerStore(dataDir)
+	return NewLedgerWithConfig(dataDir, chainId, ledgerstore.StoreConfig{})
+}
+
+func NewLedgerWithConfig(dataDir string, chainId uint64, config ledgerstore.StoreConfig) (*Ledger, error) {
+	ldgStore, err := ledgerstore.NewLedgerStoreWithConfig(dataDir, config)
 	if err != nil {
 		return nil, fmt.Errorf("NewLedgerStore error %s", err)
 	}
 	return &Ledger{
 		ldgStore: ldgStore,
 		chainId:  chainId,
+		subs:     make(map[*subscription]struct{}),
 	}, nil
 }
 
@@ -47,6 +57,35 @@ func (l *Ledger) Init(genesisBlock *types.Block) error {
 	return nil
 }
 
+// InitWithChainParams init the ledger with genesis block and chain parameters persisted with it
+func (l *Ledger) InitWithChainParams(genesisBlock *types.Block, params *states.ChainParams) error {
+	err := l.ldgStore.InitLedgerStoreWithChainParams(genesisBlock, params)
+	if err != nil {
+		return fmt.Errorf("InitLedgerStoreWithChainParams error %s", err)
+	}
+	return nil
+}
+
+func (l *Ledger) Load() error {
+	err := l.ldgStore.LoadLedgerStore()
+	if err != nil {
+		return fmt.Errorf("LoadLedgerStore error %s", err)
+	}
+	return nil
+}
+
+func (l *Ledger) ExportSnapshot(height uint64, w io.Writer) error {
+	return l.ldgStore.ExportSnapshot(height, w)
+}
+
+func (l *Ledger) ImportSnapshot(r io.Reader, trustedHash common.Uint256) error {
+	err := l.ldgStore.ImportSnapshot(r, trustedHash)
+	if err != nil {
+		return fmt.Errorf("ImportSnapshot error %s", err)
+	}
+	return nil
+}
+
 func (l *Ledger) AddHeaders(headers []*types.Header) error {
 	return l.ldgStore.AddHeaders(headers)
 }
@@ -67,6 +106,20 @@ func (l *Ledger) SubmitBlock(b *types.Block, exec store.ExecuteResult) error {
 	return l.ldgStore.SubmitBlock(b, exec)
 }
 
+func (l *Ledger) RollbackTo(height uint64) error {
+	err := l.ldgStore.RollbackTo(height)
+	if err != nil {
+		logrus.Errorf("Ledger RollbackTo height:%d error:%s", height, err)
+		return err
+	}
+	l.subsLock.Lock()
+	for sub := range l.subs {
+		sub.rolledBack(height)
+	}
+	l.subsLock.Unlock()
+	return nil
+}
+
 func (l *Ledger) GetStateMerkleRoot(height uint64) (result common.Uint256, err error) {
 	return l.ldgStore.GetStateMerkleRoot(height)
 }
@@ -109,10 +162,58 @@ func (l *Ledger) GetTransactionByReqId(reqId [32]byte) (payload.Payload, error)
 	return tx, err
 }
 
+func (l *Ledger) GetRequestTxHash(reqId [32]byte) (common.Uint256, error) {
+	return l.ldgStore.GetRequestTxHash(reqId)
+}
+
 func (l *Ledger) GetRequestState(reqId [32]byte) (payload.ReqState, error) {
 	return l.ldgStore.GetRequestState(reqId)
 }
 
+func (l *Ledger) GetRequestHistory(reqId [32]byte) ([]*states.RequestStateEntry, error) {
+	return l.ldgStore.GetRequestHistory(reqId)
+}
+
+func (l *Ledger) GetOpenRequests(olderThanHeight uint64, dstChainId uint64) ([][32]byte, error) {
+	return l.ldgStore.GetOpenRequests(olderThanHeight, dstChainId)
+}
+
+func (l *Ledger) GetRequestsByDstChain(dstChainId uint64, fromHeight, toHeight uint64, offset, limit int) ([][32]byte, error) {
+	return l.ldgStore.GetRequestsByDstChain(dstChainId, fromHeight, toHeight, offset, limit)
+}
+
+func (l *Ledger) GetRequestsBySrcTxHash(srcTxHash []byte) ([][32]byte, error) {
+	return l.ldgStore.GetRequestsBySrcTxHash(srcTxHash)
+}
+
+func (l *Ledger) GetRequestsByBridgeAddress(address []byte, offset, limit int) ([][32]byte, error) {
+	return l.ldgStore.GetRequestsByBridgeAddress(address, offset, limit)
+}
+
+func (l *Ledger) GetRequestExpiry() uint64 {
+	return l.ldgStore.GetRequestExpiry()
+}
+
+func (l *Ledger) SetRequestExpiry(expiry uint64) {
+	l.ldgStore.SetRequestExpiry(expiry)
+}
+
+func (l *Ledger) GetPrunedHeight() uint64 {
+	return l.ldgStore.GetPrunedHeight()
+}
+
+func (l *Ledger) GetDBStats() ([]*store.PrefixStats, error) {
+	return l.ldgStore.GetDBStats()
+}
+
+func (l *Ledger) VerifyChain(from, to uint64) (*store.ChainReport, error) {
+	return l.ldgStore.VerifyChain(from, to)
+}
+
+func (l *Ledger) GetEventHub() *events.Hub {
+	return l.ldgStore.GetEventHub()
+}
+
 func (l *Ledger) GetTransactionWithHeight(txHash common.Uint256) (payload.Payload, uint64, error) {
 	return l.ldgStore.GetTransaction(txHash)
 }
@@ -149,6 +250,58 @@ func (l *Ledger) GetEpochState() (*states.EpochState, error) {
 	return l.ldgStore.GetEpochState()
 }
 
+func (l *Ledger) GetEpochByNumber(number uint32) (*states.EpochInfo, error) {
+	return l.ldgStore.GetEpochByNumber(number)
+}
+
+func (l *Ledger) GetEpochByBlockHash(blockHash common.Uint256) (*states.EpochInfo, error) {
+	return l.ldgStore.GetEpochByBlockHash(blockHash)
+}
+
+func (l *Ledger) GetEpochAtHeight(height uint64) (*states.EpochInfo, error) {
+	return l.ldgStore.GetEpochAtHeight(height)
+}
+
+func (l *Ledger) GetConfirmationDepth(chainId uint64) uint64 {
+	return l.ldgStore.GetConfirmationDepth(chainId)
+}
+
+func (l *Ledger) SetConfirmationDepth(chainId uint64, depth uint64) {
+	l.ldgStore.SetConfirmationDepth(chainId, depth)
+}
+
+func (l *Ledger) GetSourceEvent(txHash common.Uint256) (*states.SourceEventState, error) {
+	return l.ldgStore.GetSourceEvent(txHash)
+}
+
+func (l *Ledger) GetRequestFinality(reqId [32]byte) (states.FinalityStatus, error) {
+	return l.ldgStore.GetRequestFinality(reqId)
+}
+
+func (l *Ledger) GetPendingSourceEvents(chainId uint64) ([]*states.SourceEventState, error) {
+	return l.ldgStore.GetPendingSourceEvents(chainId)
+}
+
+func (l *Ledger) UpdateSourceHead(chainId uint64, head uint64) ([]common.Uint256, error) {
+	txHashes, err := l.ldgStore.UpdateSourceHead(chainId, head)
+	if err != nil {
+		logrus.Errorf("Ledger UpdateSourceHead chain:%d head:%d error:%s", chainId, head, err)
+	}
+	return txHashes, err
+}
+
+func (l *Ledger) ReportSourceBlock(chainId uint64, height uint64, blockHash []byte) ([]common.Uint256, error) {
+	txHashes, err := l.ldgStore.ReportSourceBlock(chainId, height, blockHash)
+	if err != nil {
+		logrus.Errorf("Ledger ReportSourceBlock chain:%d height:%d error:%s", chainId, height, err)
+	}
+	return txHashes, err
+}
+
+func (l *Ledger) MarkSourceEventReorged(txHash common.Uint256) error {
+	return l.ldgStore.MarkSourceEventReorged(txHash)
+}
+
 func (l *Ledger) GetStorageItem(codeHash common.Address, key []byte) ([]byte, error) {
 	storageKey := &states.StorageKey{
 		ContractAddress: codeHash,
@@ -193,6 +346,18 @@ func (l *Ledger) SetProcessedHeight(srcBlockHeight uint64) {
 	l.ldgStore.SetProcessedHeight(srcBlockHeight)
 }
 
+func (l *Ledger) GetChainParams() (*states.ChainParams, error) {
+	return l.ldgStore.GetChainParams()
+}
+
+func (l *Ledger) GetQuorumThreshold() uint64 {
+	return l.ldgStore.GetQuorumThreshold()
+}
+
+func (l *Ledger) SetQuorumThreshold(threshold uint64) {
+	l.ldgStore.SetQuorumThreshold(threshold)
+}
+
 func (l *Ledger) Close() error {
 
 	return l.ldgStore.Close()
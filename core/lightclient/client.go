@@ -0,0 +1,249 @@
+package lightclient
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/eywa-protocol/chain/common"
+	scom "github.com/eywa-protocol/chain/core/store/common"
+	"github.com/eywa-protocol/chain/core/types"
+	"github.com/eywa-protocol/chain/merkle"
+)
+
+// Store key prefixes of light client data
+const (
+	prefixHeader  byte = 0x01 // Header by height
+	prefixEpoch   byte = 0x02 // Epoch by number
+	prefixCurrent byte = 0x03 // Height of the last header and number of the current epoch
+)
+
+var ErrNotInitialized = errors.New("light client is not initialized")
+
+// Client verify bridge chain headers without a full ledger. It keeps headers and epoch transitions only:
+// every header must be signed by quorum of the current epoch, epoch is rotated by inclusion proof
+// of typed epoch leaf against transactions root of a verified header
+type Client struct {
+	store     scom.PersistStore
+	threshold uint64 // Percent of epoch participants required to sign header, zero disables signature verification
+	header    *types.Header
+	epoch     *Epoch
+	lock      sync.RWMutex
+}
+
+// NewClient return light client keeping its data in the store, the client state is loaded if it was initialized before
+func NewClient(store scom.PersistStore, threshold uint64) (*Client, error) {
+	c := &Client{
+		store:     store,
+		threshold: threshold,
+	}
+	value, err := store.Get([]byte{prefixCurrent})
+	if err == scom.ErrNotFound {
+		return c, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if len(value) != 12 {
+		return nil, fmt.Errorf("current state length %d is invalid", len(value))
+	}
+	if c.header, err = c.loadHeader(binary.BigEndian.Uint64(value)); err != nil {
+		return nil, err
+	}
+	if c.epoch, err = c.loadEpoch(binary.BigEndian.Uint32(value[8:])); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Init set trusted header and its epoch proved by Block.EpochProve path of the header block, usually genesis.
+// Blocks saved before typed epoch leaf was added to transactions root can't be used
+func (c *Client) Init(trusted *types.Header, epochProof []byte) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.header != nil {
+		return errors.New("light client is already initialized")
+	}
+	epoch, err := proveEpoch(trusted, epochProof)
+	if err != nil {
+		return err
+	}
+	return c.save(trusted, epoch)
+}
+
+// Height return height of the last verified header
+func (c *Client) Height() (uint64, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if c.header == nil {
+		return 0, ErrNotInitialized
+	}
+	return c.header.Height, nil
+}
+
+// Epoch return the current epoch
+func (c *Client) Epoch() (*Epoch, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if c.epoch == nil {
+		return nil, ErrNotInitialized
+	}
+	return c.epoch, nil
+}
+
+// AddHeader verify the header following the last one and save it
+func (c *Client) AddHeader(header *types.Header) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.header == nil {
+		return ErrNotInitialized
+	}
+	if header.Height != c.header.Height+1 {
+		return fmt.Errorf("header height %d not equal next height %d", header.Height, c.header.Height+1)
+	}
+	if header.ChainID != c.header.ChainID {
+		return fmt.Errorf("header chain id %d not equal %d", header.ChainID, c.header.ChainID)
+	}
+	if prevHash := c.header.Hash(); header.PrevBlockHash != *prevHash {
+		return fmt.Errorf("header prev hash %s not equal %s", header.PrevBlockHash.ToHexString(), prevHash.ToHexString())
+	}
+	if header.SourceHeight <= c.header.SourceHeight {
+		return fmt.Errorf("header source height %d is not above %d", header.SourceHeight, c.header.SourceHeight)
+	}
+	if c.threshold != 0 {
+		if header.EpochBlockHash != c.epoch.BlockHash {
+			return fmt.Errorf("header epoch block hash %s not equal current epoch %d block hash %s",
+				header.EpochBlockHash.ToHexString(), c.epoch.Number, c.epoch.BlockHash.ToHexString())
+		}
+		quorum := types.QuorumSize(c.epoch.Size, c.threshold)
+		if err := header.VerifySignature(c.epoch.PublicKey, c.epoch.Size, quorum); err != nil {
+			return fmt.Errorf("epoch %d signature error %w", c.epoch.Number, err)
+		}
+	}
+	return c.save(header, nil)
+}
+
+// RotateEpoch apply the next epoch event included to block of the last header, epochProof is its Block.EpochProve path
+func (c *Client) RotateEpoch(epochProof []byte) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.header == nil {
+		return ErrNotInitialized
+	}
+	epoch, err := proveEpoch(c.header, epochProof)
+	if err != nil {
+		return err
+	}
+	if epoch.Number != c.epoch.Number+1 {
+		return fmt.Errorf("epoch number %d not equal next epoch %d", epoch.Number, c.epoch.Number+1)
+	}
+	return c.save(nil, epoch)
+}
+
+// VerifyTransaction check transaction inclusion path produced by Block.MerkleProve against transactions root
+// of the verified header and return the transaction data
+func (c *Client) VerifyTransaction(height uint64, proof []byte) ([]byte, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if c.header == nil {
+		return nil, ErrNotInitialized
+	}
+	if height > c.header.Height {
+		return nil, fmt.Errorf("header %d is not verified yet", height)
+	}
+	header, err := c.loadHeader(height)
+	if err != nil {
+		return nil, err
+	}
+	return merkle.MerkleProve(proof, header.TransactionsRoot[:])
+}
+
+// GetHeader return verified header by height
+func (c *Client) GetHeader(height uint64) (*types.Header, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.loadHeader(height)
+}
+
+// proveEpoch return epoch included to block of the header
+func proveEpoch(header *types.Header, proof []byte) (*Epoch, error) {
+	data, err := merkle.MerkleProveTypedLeaf(proof, header.TransactionsRoot[:])
+	if err != nil {
+		return nil, fmt.Errorf("epoch proof error %s", err)
+	}
+	epoch, err := parseEpochLeaf(data)
+	if err != nil {
+		return nil, err
+	}
+	epoch.BlockHash = *header.Hash()
+	epoch.Height = header.Height
+	return epoch, nil
+}
+
+// save persist the header and the epoch if set, then make them current
+func (c *Client) save(header *types.Header, epoch *Epoch) error {
+	current := header
+	if current == nil {
+		current = c.header
+	}
+	currentEpoch := epoch
+	if currentEpoch == nil {
+		currentEpoch = c.epoch
+	}
+	c.store.NewBatch()
+	if header != nil {
+		sink := common.NewZeroCopySink(nil)
+		if err := header.Serialization(sink); err != nil {
+			return err
+		}
+		c.store.BatchPut(headerKey(header.Height), sink.Bytes())
+	}
+	if epoch != nil {
+		sink := common.NewZeroCopySink(nil)
+		epoch.Serialization(sink)
+		c.store.BatchPut(epochKey(epoch.Number), sink.Bytes())
+	}
+	value := make([]byte, 12)
+	binary.BigEndian.PutUint64(value, current.Height)
+	binary.BigEndian.PutUint32(value[8:], currentEpoch.Number)
+	c.store.BatchPut([]byte{prefixCurrent}, value)
+	if err := c.store.BatchCommit(); err != nil {
+		return err
+	}
+	c.header, c.epoch = current, currentEpoch
+	return nil
+}
+
+func (c *Client) loadHeader(height uint64) (*types.Header, error) {
+	value, err := c.store.Get(headerKey(height))
+	if err != nil {
+		return nil, fmt.Errorf("header %d load error %w", height, err)
+	}
+	return types.HeaderFromRawBytes(value)
+}
+
+func (c *Client) loadEpoch(number uint32) (*Epoch, error) {
+	value, err := c.store.Get(epochKey(number))
+	if err != nil {
+		return nil, fmt.Errorf("epoch %d load error %w", number, err)
+	}
+	epoch := new(Epoch)
+	if err := epoch.Deserialization(common.NewZeroCopySource(value)); err != nil {
+		return nil, err
+	}
+	return epoch, nil
+}
+
+func headerKey(height uint64) []byte {
+	key := make([]byte, 9)
+	key[0] = prefixHeader
+	binary.BigEndian.PutUint64(key[1:], height)
+	return key
+}
+
+func epochKey(number uint32) []byte {
+	key := make([]byte, 5)
+	key[0] = prefixEpoch
+	binary.BigEndian.PutUint32(key[1:], number)
+	return key
+}
@@ -0,0 +1,183 @@
+package lightclient
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/eywa-protocol/bls-crypto/bls"
+	"github.com/eywa-protocol/wrappers"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eywa-protocol/chain/account"
+	"github.com/eywa-protocol/chain/common"
+	"github.com/eywa-protocol/chain/core/ledger"
+	"github.com/eywa-protocol/chain/core/payload"
+	"github.com/eywa-protocol/chain/core/signer"
+	"github.com/eywa-protocol/chain/core/store/memstore"
+	"github.com/eywa-protocol/chain/core/txpool"
+	"github.com/eywa-protocol/chain/core/types"
+)
+
+const testThreshold = 67
+
+// testEpoch is epoch event with signers of its participants
+type testEpoch struct {
+	event   *payload.EpochEvent
+	signers []*signer.Signer
+}
+
+func newTestEpoch(t *testing.T, number uint32, size int) *testEpoch {
+	accounts := make([]*account.Account, size)
+	keys := make([]bls.PublicKey, size)
+	hostIds := make([]string, size)
+	for i := range accounts {
+		accounts[i] = account.NewAccount(byte(i))
+		keys[i] = accounts[i].PublicKey
+		hostIds[i] = string(rune('a' + i))
+	}
+	epoch := &testEpoch{event: payload.NewEpochEvent(number, common.UINT256_EMPTY, keys, hostIds)}
+	for i, acc := range accounts {
+		parts := make([]bls.Signature, 0, size)
+		for _, other := range accounts {
+			part, err := signer.MembershipKeyPart(other, keys, i)
+			require.NoError(t, err)
+			parts = append(parts, part)
+		}
+		membershipKey, err := signer.AggregateMembershipKey(parts)
+		require.NoError(t, err)
+		s, err := signer.NewSigner(acc, keys, membershipKey)
+		require.NoError(t, err)
+		epoch.signers = append(epoch.signers, s)
+	}
+	return epoch
+}
+
+// sign header by all epoch participants
+func (e *testEpoch) sign(t *testing.T, header *types.Header) {
+	aggregator, err := signer.NewAggregator(header, e.event.PublicKeys, testThreshold)
+	require.NoError(t, err)
+	for _, s := range e.signers {
+		require.NoError(t, aggregator.Add(s.Sign(header)))
+	}
+	require.NoError(t, aggregator.Finalize())
+}
+
+// saveTestBlock build the next block of pool transactions signed by the epoch and save it
+func saveTestBlock(t *testing.T, l *ledger.Ledger, pool *txpool.TxPool, epoch *testEpoch, txs ...payload.Payload) *types.Block {
+	for _, tx := range txs {
+		require.NoError(t, pool.Add(tx))
+	}
+	block, err := ledger.NewBlockBuilder(l, pool, 0).Build(10 + l.GetCurrentBlockHeight() + 1)
+	require.NoError(t, err)
+	epoch.sign(t, block.Header)
+	require.NoError(t, l.ExecAndSaveBlock(block))
+	pool.RemoveBlock(block)
+	return block
+}
+
+func TestLightClient(t *testing.T) {
+	epoch1, epoch2 := newTestEpoch(t, 1, 4), newTestEpoch(t, 2, 3)
+	l, err := ledger.NewLedger(t.TempDir(), 0)
+	require.NoError(t, err)
+	defer l.Close()
+	genesisBlock := types.NewBlock(0, common.UINT256_EMPTY, common.UINT256_EMPTY, 10, 0, types.Transactions{types.ToTransaction(epoch1.event)})
+	require.NoError(t, l.Init(genesisBlock))
+	l.SetQuorumThreshold(testThreshold)
+	pool := txpool.NewTxPool(l, txpool.Config{})
+	request := &payload.BridgeEvent{
+		OriginData: wrappers.BridgeOracleRequest{
+			RequestType: "setRequest",
+			RequestId:   [32]byte{1},
+			ChainId:     big.NewInt(94),
+		}}
+	block1 := saveTestBlock(t, l, pool, epoch1, request, payload.NewRequestStateEvent([32]byte{1}, payload.ReqStateSigned, 94, nil, "", 0))
+	block2 := saveTestBlock(t, l, pool, epoch1, epoch2.event)
+	block3 := saveTestBlock(t, l, pool, epoch2)
+	require.Equal(t, block2.Hash(), block3.Header.EpochBlockHash)
+
+	// transaction of other type with epoch event data is not proved as epoch
+	epoch4 := newTestEpoch(t, 4, 2)
+	forged := types.NewBlock(0, block3.Hash(), block2.Hash(), 14, 4, types.Transactions{
+		types.ToTransaction(&payload.InvokeCode{Code: epoch4.event.RawData()}),
+	})
+	proof, err := forged.MerkleProve(0)
+	require.NoError(t, err)
+	_, err = proveEpoch(forged.Header, proof)
+	require.Error(t, err)
+	_, err = forged.EpochProve()
+	require.Error(t, err)
+
+	// ledger rejects skipped epoch number, light client refuses to rotate to it as well
+	block4 := types.NewBlock(0, block3.Hash(), block2.Hash(), 14, 4, types.Transactions{types.ToTransaction(epoch4.event)})
+	epoch2.sign(t, block4.Header)
+	require.Error(t, l.ExecAndSaveBlock(block4))
+
+	store := memstore.NewMemStore()
+	client, err := NewClient(store, testThreshold)
+	require.NoError(t, err)
+	require.Equal(t, ErrNotInitialized, client.AddHeader(block1.Header))
+	proof, err = genesisBlock.EpochProve()
+	require.NoError(t, err)
+	require.NoError(t, client.Init(genesisBlock.Header, proof))
+	epoch, err := client.Epoch()
+	require.NoError(t, err)
+	require.Equal(t, uint32(1), epoch.Number)
+	require.Equal(t, 4, epoch.Size)
+	require.Equal(t, genesisBlock.Hash(), epoch.BlockHash)
+
+	// header with changed fields doesn't match the signature
+	sink := common.NewZeroCopySink(nil)
+	require.NoError(t, block1.Header.Serialization(sink))
+	tampered, err := types.HeaderFromRawBytes(sink.Bytes())
+	require.NoError(t, err)
+	tampered.SourceHeight++
+	require.Error(t, client.AddHeader(tampered))
+	require.NoError(t, client.AddHeader(block1.Header))
+	require.Error(t, client.AddHeader(block1.Header))
+
+	// header signed by the next epoch is accepted after epoch is rotated by proof from the previous block
+	require.NoError(t, client.AddHeader(block2.Header))
+	require.Error(t, client.AddHeader(block3.Header))
+	proof, err = block1.MerkleProve(1)
+	require.NoError(t, err)
+	require.Error(t, client.RotateEpoch(proof))
+	proof, err = block2.MerkleProve(0)
+	require.NoError(t, err)
+	require.Error(t, client.RotateEpoch(proof))
+	proof, err = block2.EpochProve()
+	require.NoError(t, err)
+	require.NoError(t, client.RotateEpoch(proof))
+	require.Error(t, client.RotateEpoch(proof))
+	require.NoError(t, client.AddHeader(block3.Header))
+
+	// epoch can't be skipped
+	require.NoError(t, client.AddHeader(block4.Header))
+	proof, err = block4.EpochProve()
+	require.NoError(t, err)
+	require.Error(t, client.RotateEpoch(proof))
+
+	// transaction inclusion
+	proof, err = block1.MerkleProve(0)
+	require.NoError(t, err)
+	data, err := client.VerifyTransaction(1, proof)
+	require.NoError(t, err)
+	require.Equal(t, request.RawData(), data)
+	_, err = client.VerifyTransaction(2, proof)
+	require.Error(t, err)
+	_, err = client.VerifyTransaction(5, proof)
+	require.Error(t, err)
+
+	// state is loaded from store
+	client, err = NewClient(store, testThreshold)
+	require.NoError(t, err)
+	height, err := client.Height()
+	require.NoError(t, err)
+	require.Equal(t, uint64(4), height)
+	epoch, err = client.Epoch()
+	require.NoError(t, err)
+	require.Equal(t, uint32(2), epoch.Number)
+	require.Equal(t, 3, epoch.Size)
+	header, err := client.GetHeader(2)
+	require.NoError(t, err)
+	require.Equal(t, block2.Hash(), *header.Hash())
+}
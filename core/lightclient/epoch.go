@@ -0,0 +1,83 @@
+package lightclient
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/eywa-protocol/bls-crypto/bls"
+
+	"github.com/eywa-protocol/chain/common"
+	"github.com/eywa-protocol/chain/core/payload"
+)
+
+// Epoch is the part of epoch event committed to block transactions root, enough to verify header signatures
+type Epoch struct {
+	Number    uint32
+	BlockHash common.Uint256 // Hash of the block including the epoch event
+	Height    uint64         // Height of the block including the epoch event
+	PublicKey bls.PublicKey  // Aggregated public key of epoch participants
+	Size      int            // Count of epoch participants
+	SourceTx  common.Uint256 // Governance blockchain transaction that caused this epoch change
+}
+
+// parseEpochLeaf decode epoch from typed epoch leaf of transactions merkle tree: EpochType followed by
+// EpochEvent.RawData. Leaves of transactions carry no type, so epoch is accepted from the typed leaf only
+func parseEpochLeaf(data []byte) (*Epoch, error) {
+	if len(data) == 0 || payload.TransactionType(data[0]) != payload.EpochType {
+		return nil, errors.New("epoch leaf has no epoch type")
+	}
+	event, size, err := payload.ParseEpochRawData(data[1:])
+	if err != nil {
+		return nil, fmt.Errorf("epoch leaf error %s", err)
+	}
+	return &Epoch{
+		Number:    event.Number,
+		PublicKey: event.EpochPublicKey,
+		Size:      size,
+		SourceTx:  event.SourceTx,
+	}, nil
+}
+
+func (e *Epoch) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteUint32(e.Number)
+	sink.WriteHash(e.BlockHash)
+	sink.WriteUint64(e.Height)
+	sink.WriteVarBytes(e.PublicKey.Marshal())
+	sink.WriteUint32(uint32(e.Size))
+	sink.WriteHash(e.SourceTx)
+}
+
+func (e *Epoch) Deserialization(source *common.ZeroCopySource) error {
+	var eof bool
+	e.Number, eof = source.NextUint32()
+	if eof {
+		return errors.New("epoch number read eof")
+	}
+	e.BlockHash, eof = source.NextHash()
+	if eof {
+		return errors.New("epoch block hash read eof")
+	}
+	e.Height, eof = source.NextUint64()
+	if eof {
+		return errors.New("epoch height read eof")
+	}
+	key, eof := source.NextVarBytes()
+	if eof {
+		return errors.New("epoch public key read eof")
+	}
+	publicKey, err := bls.UnmarshalPublicKey(key)
+	if err != nil {
+		return fmt.Errorf("epoch public key unmarshal error %s", err)
+	}
+	e.PublicKey = publicKey
+	size, eof := source.NextUint32()
+	if eof {
+		return errors.New("epoch size read eof")
+	}
+	e.Size = int(size)
+	e.SourceTx, eof = source.NextHash()
+	if eof {
+		return errors.New("epoch source tx read eof")
+	}
+	return nil
+}
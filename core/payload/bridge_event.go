@@ -40,6 +40,14 @@ func (e *BridgeEvent) DstChainId() (uint64, bool) {
 	return e.OriginData.ChainId.Uint64(), false
 }
 
+func (e *BridgeEvent) BridgeAddresses() [][]byte {
+	return [][]byte{e.OriginData.Bridge[:], e.OriginData.ReceiveSide[:]}
+}
+
+func (e *BridgeEvent) SrcBlock() (uint64, []byte) {
+	return e.OriginData.Raw.BlockNumber, e.OriginData.Raw.BlockHash[:]
+}
+
 func (e *BridgeEvent) Deserialization(source *common.ZeroCopySource) error {
 	code, eof := source.NextVarBytes()
 	if eof {
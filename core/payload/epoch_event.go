@@ -135,3 +135,39 @@ func (e *EpochEvent) RawData() []byte {
 	sink.WriteBytes(e.SourceTx[:])
 	return sink.Bytes()
 }
+
+// ParseEpochRawData decode epoch number, participants count, epoch public key and source transaction
+// from EpochEvent.RawData, data must be consumed completely
+func ParseEpochRawData(data []byte) (*EpochEvent, int, error) {
+	source := common.NewZeroCopySource(data)
+	e := new(EpochEvent)
+	var eof bool
+	e.Number, eof = source.NextUint32()
+	if eof {
+		return nil, 0, fmt.Errorf("Epoch.Number read eof")
+	}
+	size, eof := source.NextUint8()
+	if eof {
+		return nil, 0, fmt.Errorf("Epoch.len(PublicKeys) read eof")
+	}
+	if size == 0 {
+		return nil, 0, fmt.Errorf("Epoch has no participants")
+	}
+	epochPublicKeyRaw, eof := source.NextVarBytes()
+	if eof {
+		return nil, 0, fmt.Errorf("Epoch.EpochPublicKey read eof")
+	}
+	epochPublicKey, err := bls.UnmarshalPublicKey(epochPublicKeyRaw)
+	if err != nil {
+		return nil, 0, fmt.Errorf("Epoch.EpochPublicKey unmarshal error %v", err)
+	}
+	e.EpochPublicKey = epochPublicKey
+	e.SourceTx, eof = source.NextHash()
+	if eof {
+		return nil, 0, fmt.Errorf("Epoch.SourceTx read eof")
+	}
+	if source.Len() != 0 {
+		return nil, 0, fmt.Errorf("Epoch raw data has %d extra bytes", source.Len())
+	}
+	return e, int(size), nil
+}
@@ -34,3 +34,21 @@ func TestEpochEvent_Serialize(t *testing.T) {
 	assert.Equal(t, uint64(0), uChainId)
 
 }
+
+func TestParseEpochRawData(t *testing.T) {
+	epoch, err := bls.ReadPublicKey("1d65becbb891b6e69951febbc4ac066343670b34d84777a077c06871beb9c07f28be8a6fa825e9d615f56f0dbcd728b46e42b4ae2a611e2ab919a1de923ae7ed0f1c89b508af036f52c2215a04e13a7a5e891d9220d3d8751dc0525b81fca3051dc2e58a167c412941bd1adeb29f5a0beb5d26e748e8ca55e508deadead1ea5e")
+	assert.NoError(t, err)
+	event := NewEpochEvent(123, common.Uint256{1}, []bls.PublicKey{epoch, epoch, epoch}, []string{"one", "two", "three"})
+
+	parsed, size, err := ParseEpochRawData(event.RawData())
+	assert.NoError(t, err)
+	assert.Equal(t, event.Number, parsed.Number)
+	assert.Equal(t, 3, size)
+	assert.Equal(t, event.EpochPublicKey.Marshal(), parsed.EpochPublicKey.Marshal())
+	assert.Equal(t, event.SourceTx, parsed.SourceTx)
+
+	_, _, err = ParseEpochRawData(append(event.RawData(), 0))
+	assert.Error(t, err)
+	_, _, err = ParseEpochRawData(event.RawData()[:10])
+	assert.Error(t, err)
+}
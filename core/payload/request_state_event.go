@@ -0,0 +1,107 @@
+package payload
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/eywa-protocol/chain/common"
+)
+
+// RequestStateEvent reports request progress which is not observed as source or destination chain event,
+// like signing, submitting to destination chain, failures and retries
+type RequestStateEvent struct {
+	ReqId   [32]byte // Request id
+	State   ReqState // New request state
+	ChainId uint64   // Destination chain id
+	TxHash  []byte   // Destination chain transaction hash if any
+	Reason  string   // Failure reason if any
+	Attempt uint32   // Delivery attempt number, distinguishes repeated reports of the same state
+}
+
+func NewRequestStateEvent(reqId [32]byte, state ReqState, chainId uint64, txHash []byte, reason string, attempt uint32) *RequestStateEvent {
+	return &RequestStateEvent{
+		ReqId:   reqId,
+		State:   state,
+		ChainId: chainId,
+		TxHash:  txHash,
+		Reason:  reason,
+		Attempt: attempt,
+	}
+}
+
+func (e *RequestStateEvent) TxType() TransactionType {
+	return RequestStateEventType
+}
+
+func (e *RequestStateEvent) RequestState() ReqState {
+	return e.State
+}
+
+func (e *RequestStateEvent) RequestId() [32]byte {
+	return e.ReqId
+}
+
+func (e *RequestStateEvent) ToJson() (json.RawMessage, error) {
+	return json.Marshal(e)
+}
+
+func (e *RequestStateEvent) SrcTxHash() []byte {
+	return e.TxHash
+}
+
+func (e *RequestStateEvent) DstChainId() (uint64, bool) {
+	return e.ChainId, false
+}
+
+func (e *RequestStateEvent) Deserialization(source *common.ZeroCopySource) error {
+	reqId, eof := source.NextBytes(32)
+	if eof {
+		return fmt.Errorf("RequestStateEvent.ReqId deserialize eof")
+	}
+	copy(e.ReqId[:], reqId)
+
+	state, eof := source.NextUint8()
+	if eof {
+		return fmt.Errorf("RequestStateEvent.State deserialize eof")
+	}
+	e.State = ReqState(state)
+
+	e.ChainId, eof = source.NextUint64()
+	if eof {
+		return fmt.Errorf("RequestStateEvent.ChainId deserialize eof")
+	}
+
+	e.TxHash, eof = source.NextVarBytes()
+	if eof {
+		return fmt.Errorf("RequestStateEvent.TxHash deserialize eof")
+	}
+
+	e.Reason, eof = source.NextString()
+	if eof {
+		return fmt.Errorf("RequestStateEvent.Reason deserialize eof")
+	}
+
+	e.Attempt, eof = source.NextUint32()
+	if eof {
+		return fmt.Errorf("RequestStateEvent.Attempt deserialize eof")
+	}
+	return nil
+}
+
+func (e *RequestStateEvent) Serialization(sink *common.ZeroCopySink) error {
+	sink.WriteBytes(e.ReqId[:])
+	sink.WriteUint8(uint8(e.State))
+	sink.WriteUint64(e.ChainId)
+	sink.WriteVarBytes(e.TxHash)
+	sink.WriteString(e.Reason)
+	sink.WriteUint32(e.Attempt)
+	return nil
+}
+
+func (e *RequestStateEvent) RawData() []byte {
+	sink := common.NewZeroCopySink(nil)
+	if err := e.Serialization(sink); err != nil {
+		return nil
+	}
+	return sink.Bytes()
+}
@@ -0,0 +1,46 @@
+package payload
+
+import (
+	"testing"
+
+	"github.com/eywa-protocol/chain/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestStateEvent_Serialize(t *testing.T) {
+	event := NewRequestStateEvent([32]byte{1, 2, 3}, ReqStateFailed, 94, []byte{4, 5, 6}, "out of gas", 2)
+
+	sink := common.NewZeroCopySink(nil)
+	err := event.Serialization(sink)
+	assert.NoError(t, err)
+	var received RequestStateEvent
+	err = received.Deserialization(common.NewZeroCopySource(sink.Bytes()))
+	assert.NoError(t, err)
+	assert.Equal(t, *event, received)
+	assert.Equal(t, ReqStateFailed, received.RequestState())
+	assert.Equal(t, [32]byte{1, 2, 3}, received.RequestId())
+
+	uChainId, fromHead := received.DstChainId()
+	assert.Equal(t, false, fromHead)
+	assert.Equal(t, uint64(94), uChainId)
+
+	retry := NewRequestStateEvent([32]byte{1, 2, 3}, ReqStateFailed, 94, []byte{4, 5, 6}, "out of gas", 3)
+	assert.NotEqual(t, event.RawData(), retry.RawData())
+}
+
+func TestReqState_CanTransitTo(t *testing.T) {
+	assert.True(t, ReqStateUnknown.CanTransitTo(ReqStateReceived))
+	assert.False(t, ReqStateUnknown.CanTransitTo(ReqStateSent))
+	assert.True(t, ReqStateReceived.CanTransitTo(ReqStateSent))
+	assert.True(t, ReqStateSubmitted.CanTransitTo(ReqStateConfirmed))
+	assert.False(t, ReqStateSent.CanTransitTo(ReqStateReceived))
+	assert.True(t, ReqStateFailed.CanTransitTo(ReqStateRetried))
+	assert.True(t, ReqStateExpired.CanTransitTo(ReqStateSent))
+	assert.True(t, ReqStateExpired.CanTransitTo(ReqStateConfirmed))
+	assert.False(t, ReqStateExpired.CanTransitTo(ReqStateSigned))
+	assert.True(t, ReqStateRetried.CanTransitTo(ReqStateSubmitted))
+	assert.False(t, ReqStateConfirmed.CanTransitTo(ReqStateFailed))
+	assert.True(t, ReqStateConfirmed.IsFinal())
+	assert.False(t, ReqStateSent.IsFinal())
+	assert.Equal(t, "expired", ReqStateExpired.String())
+}
@@ -39,6 +39,14 @@ func (e *SolanaToEVMEvent) DstChainId() (uint64, bool) {
 	return e.OriginData.ChainId, false
 }
 
+func (e *SolanaToEVMEvent) BridgeAddresses() [][]byte {
+	return [][]byte{e.OriginData.BridgePubKey[:], e.OriginData.ReceiveSide[:]}
+}
+
+func (e *SolanaToEVMEvent) SrcBlock() (uint64, []byte) {
+	return uint64(e.OriginData.Slot), nil
+}
+
 func (e *SolanaToEVMEvent) Deserialization(source *common.ZeroCopySource) error {
 	code, eof := source.NextVarBytes()
 	if eof {
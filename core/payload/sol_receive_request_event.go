@@ -38,6 +38,10 @@ func (e *SolReceiveRequestEvent) DstChainId() (uint64, bool) {
 	return 0, true
 }
 
+func (e *SolReceiveRequestEvent) SrcBlock() (uint64, []byte) {
+	return uint64(e.OriginData.Slot), nil
+}
+
 func (e *SolReceiveRequestEvent) Deserialization(source *common.ZeroCopySource) error {
 	code, eof := source.NextVarBytes()
 	if eof {
@@ -38,6 +38,14 @@ func (e *BridgeSolanaEvent) DstChainId() (uint64, bool) {
 	return e.OriginData.ChainId.Uint64(), false
 }
 
+func (e *BridgeSolanaEvent) BridgeAddresses() [][]byte {
+	return [][]byte{e.OriginData.Bridge[:], e.OriginData.OppositeBridge[:]}
+}
+
+func (e *BridgeSolanaEvent) SrcBlock() (uint64, []byte) {
+	return e.OriginData.Raw.BlockNumber, e.OriginData.Raw.BlockHash[:]
+}
+
 func (e *BridgeSolanaEvent) Deserialization(source *common.ZeroCopySource) error {
 	code, eof := source.NextVarBytes()
 	if eof {
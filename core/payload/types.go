@@ -18,16 +18,84 @@ const (
 	SolanaToEVMEventType       TransactionType = 0x21
 	ReceiveRequestEventType    TransactionType = 0x23
 	SolReceiveRequestEventType TransactionType = 0x24
+	RequestStateEventType      TransactionType = 0x25
 )
 
 type ReqState uint8
 
 const (
-	ReqStateUnknown  ReqState = iota // request id not found in ledger
-	ReqStateReceived                 // event received
-	ReqStateSent                     // event sent to destination
+	ReqStateUnknown   ReqState = iota // request id not found in ledger
+	ReqStateReceived                  // event received
+	ReqStateSent                      // event sent to destination
+	ReqStateSigned                    // request signed by epoch participants
+	ReqStateSubmitted                 // request transaction submitted to destination chain
+	ReqStateConfirmed                 // request transaction confirmed on destination chain
+	ReqStateFailed                    // request delivery failed
+	ReqStateExpired                   // request was not delivered in time
+	ReqStateRetried                   // failed or expired request is scheduled for delivery again
 )
 
+// reqStateTransitions lists states allowed to follow the state.
+// Request expired by ledger may still be delivered to destination chain late, so delivery is accepted after expiry
+var reqStateTransitions = map[ReqState][]ReqState{
+	ReqStateUnknown:   {ReqStateReceived},
+	ReqStateReceived:  {ReqStateSigned, ReqStateSubmitted, ReqStateSent, ReqStateFailed, ReqStateExpired},
+	ReqStateSigned:    {ReqStateSubmitted, ReqStateSent, ReqStateFailed, ReqStateExpired},
+	ReqStateSubmitted: {ReqStateSent, ReqStateConfirmed, ReqStateFailed, ReqStateExpired},
+	ReqStateSent:      {ReqStateConfirmed, ReqStateFailed},
+	ReqStateFailed:    {ReqStateRetried, ReqStateExpired},
+	ReqStateExpired:   {ReqStateRetried, ReqStateSent, ReqStateConfirmed},
+	ReqStateRetried:   {ReqStateSigned, ReqStateSubmitted, ReqStateSent, ReqStateFailed, ReqStateExpired},
+}
+
+// CanTransitTo report whether request in this state can move to the next state
+func (rs ReqState) CanTransitTo(next ReqState) bool {
+	for _, state := range reqStateTransitions[rs] {
+		if state == next {
+			return true
+		}
+	}
+	return false
+}
+
+// IsFinal report whether request in this state is delivered and can not change anymore
+func (rs ReqState) IsFinal() bool {
+	return len(reqStateTransitions[rs]) == 0
+}
+
+// IsOpen report whether request in this state still waits for delivery to destination chain
+func (rs ReqState) IsOpen() bool {
+	switch rs {
+	case ReqStateReceived, ReqStateSigned, ReqStateSubmitted, ReqStateFailed, ReqStateRetried:
+		return true
+	default:
+		return false
+	}
+}
+
+func (rs ReqState) String() string {
+	switch rs {
+	case ReqStateReceived:
+		return "received"
+	case ReqStateSent:
+		return "sent"
+	case ReqStateSigned:
+		return "signed"
+	case ReqStateSubmitted:
+		return "submitted"
+	case ReqStateConfirmed:
+		return "confirmed"
+	case ReqStateFailed:
+		return "failed"
+	case ReqStateExpired:
+		return "expired"
+	case ReqStateRetried:
+		return "retried"
+	default:
+		return "unknown"
+	}
+}
+
 func (tt TransactionType) String() string {
 	switch tt {
 	case InvokeType:
@@ -48,6 +116,8 @@ func (tt TransactionType) String() string {
 		return "solana_to_evm_event"
 	case SolReceiveRequestEventType:
 		return "solana_receive_request_event"
+	case RequestStateEventType:
+		return "request_state_event"
 	default:
 		return "unknown"
 	}
@@ -64,3 +134,13 @@ type Payload interface {
 	Deserialization(*common.ZeroCopySource) error
 	RawData() []byte
 }
+
+// SourceEvent is implemented by payloads ingested from source chain logs
+type SourceEvent interface {
+	SrcBlock() (uint64, []byte) // Source block height (slot for solana) and block hash if available
+}
+
+// BridgeRequest is implemented by payloads of requests created by source chain bridge
+type BridgeRequest interface {
+	BridgeAddresses() [][]byte // Source bridge and destination receive side addresses of the request
+}
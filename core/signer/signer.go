@@ -0,0 +1,217 @@
+package signer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+
+	"github.com/eywa-protocol/bls-crypto/bls"
+
+	"github.com/eywa-protocol/chain/account"
+	"github.com/eywa-protocol/chain/core/types"
+)
+
+const MaxEpochSize = math.MaxUint8 // Epoch event encodes participants count and membership keys encode participant index as one byte
+
+var (
+	ErrNotParticipant     = errors.New("account is not epoch participant")
+	ErrDuplicateSignature = errors.New("participant signature already collected")
+	ErrNoQuorum           = errors.New("not enough signatures for quorum")
+)
+
+// PartialSignature is header signature of one epoch participant
+type PartialSignature struct {
+	Index     int           // Index of participant public key in epoch key list
+	Signature bls.Signature // Multisign of header hash with participant membership key
+}
+
+// epochKey return aggregated public key of the epoch participants weighted by anti-rogue coefficients as EpochEvent does
+func epochKey(keys []bls.PublicKey) (key bls.PublicKey, err error) {
+	if len(keys) == 0 {
+		return key, errors.New("epoch has no participants")
+	}
+	if len(keys) > MaxEpochSize {
+		return key, fmt.Errorf("epoch size %d above %d", len(keys), MaxEpochSize)
+	}
+	return bls.AggregatePublicKeys(keys, bls.CalculateAntiRogueCoefficients(keys)), nil
+}
+
+// keyIndex return index of the public key in epoch key list
+func keyIndex(keys []bls.PublicKey, key bls.PublicKey) (int, error) {
+	raw := key.Marshal()
+	for i, item := range keys {
+		if bytes.Equal(item.Marshal(), raw) {
+			return i, nil
+		}
+	}
+	return 0, ErrNotParticipant
+}
+
+// MembershipKeyPart return part of membership key of participant with the index signed by the account.
+// Every epoch participant sends its part to every other one once epoch keys are known
+func MembershipKeyPart(acc *account.Account, keys []bls.PublicKey, index int) (part bls.Signature, err error) {
+	allKey, err := epochKey(keys)
+	if err != nil {
+		return part, err
+	}
+	if index < 0 || index >= len(keys) {
+		return part, fmt.Errorf("participant index %d out of epoch size %d", index, len(keys))
+	}
+	own, err := keyIndex(keys, acc.PublicKey)
+	if err != nil {
+		return part, err
+	}
+	anticoefs := bls.CalculateAntiRogueCoefficients(keys)
+	return acc.PrivateKey.GenerateMembershipKeyPart(byte(index), allKey, anticoefs[own]), nil
+}
+
+// AggregateMembershipKey return membership key from parts received from all epoch participants
+func AggregateMembershipKey(parts []bls.Signature) (key bls.Signature, err error) {
+	if len(parts) == 0 {
+		return key, errors.New("no membership key parts")
+	}
+	key = parts[0]
+	for _, part := range parts[1:] {
+		key = key.Aggregate(part)
+	}
+	return key, nil
+}
+
+// Signer sign headers by epoch participant account
+type Signer struct {
+	account       *account.Account
+	index         int
+	epochKey      bls.PublicKey
+	membershipKey bls.Signature
+}
+
+func NewSigner(acc *account.Account, keys []bls.PublicKey, membershipKey bls.Signature) (*Signer, error) {
+	allKey, err := epochKey(keys)
+	if err != nil {
+		return nil, err
+	}
+	index, err := keyIndex(keys, acc.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	return &Signer{
+		account:       acc,
+		index:         index,
+		epochKey:      allKey,
+		membershipKey: membershipKey,
+	}, nil
+}
+
+// Index return index of the signer public key in epoch key list
+func (s *Signer) Index() int {
+	return s.index
+}
+
+// Sign return partial signature of the header hash
+func (s *Signer) Sign(header *types.Header) *PartialSignature {
+	hash := header.Hash()
+	return &PartialSignature{
+		Index:     s.index,
+		Signature: s.account.PrivateKey.Multisign(hash.ToArray(), s.epochKey, s.membershipKey),
+	}
+}
+
+// Aggregator collect partial signatures of the header until quorum of epoch participants is reached
+type Aggregator struct {
+	header   *types.Header
+	keys     []bls.PublicKey
+	epochKey bls.PublicKey
+	quorum   int
+	parts    map[int]bls.Signature
+}
+
+// NewAggregator return aggregator of the header signatures, threshold is percent of epoch participants required to sign
+func NewAggregator(header *types.Header, keys []bls.PublicKey, threshold uint64) (*Aggregator, error) {
+	allKey, err := epochKey(keys)
+	if err != nil {
+		return nil, err
+	}
+	quorum := types.QuorumSize(len(keys), threshold)
+	if quorum == 0 {
+		quorum = 1
+	}
+	return &Aggregator{
+		header:   header,
+		keys:     keys,
+		epochKey: allKey,
+		quorum:   quorum,
+		parts:    make(map[int]bls.Signature),
+	}, nil
+}
+
+// Add verify partial signature against participant public key and collect it
+func (a *Aggregator) Add(part *PartialSignature) error {
+	if part.Index < 0 || part.Index >= len(a.keys) {
+		return fmt.Errorf("participant index %d out of epoch size %d", part.Index, len(a.keys))
+	}
+	if _, ok := a.parts[part.Index]; ok {
+		return ErrDuplicateSignature
+	}
+	mask := new(big.Int).SetBit(new(big.Int), part.Index, 1)
+	hash := a.header.Hash()
+	if !part.Signature.VerifyMultisig(a.epochKey, a.keys[part.Index], hash.ToArray(), mask) {
+		return fmt.Errorf("participant %d signature verification failed", part.Index)
+	}
+	a.parts[part.Index] = part.Signature
+	return nil
+}
+
+// Count return count of collected signatures
+func (a *Aggregator) Count() int {
+	return len(a.parts)
+}
+
+// Quorum return count of signatures required
+func (a *Aggregator) Quorum() int {
+	return a.quorum
+}
+
+// HasQuorum report whether enough signatures are collected
+func (a *Aggregator) HasQuorum() bool {
+	return len(a.parts) >= a.quorum
+}
+
+// Multisig return aggregation of collected signatures and public keys of signers with signers mask
+func (a *Aggregator) Multisig() (bls.Multisig, error) {
+	if !a.HasQuorum() {
+		return bls.Multisig{}, fmt.Errorf("%w: %d of %d", ErrNoQuorum, len(a.parts), a.quorum)
+	}
+	indexes := make([]int, 0, len(a.parts))
+	for index := range a.parts {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	signature, publicKey := a.parts[indexes[0]], a.keys[indexes[0]]
+	mask := new(big.Int)
+	for i, index := range indexes {
+		if i > 0 {
+			signature = signature.Aggregate(a.parts[index])
+			publicKey = publicKey.Aggregate(a.keys[index])
+		}
+		mask.SetBit(mask, index, 1)
+	}
+	return bls.Multisig{
+		PartSignature: signature,
+		PartPublicKey: publicKey,
+		PartMask:      *mask,
+	}, nil
+}
+
+// Finalize attach aggregated signature to the header and verify it as ledger does
+func (a *Aggregator) Finalize() error {
+	multisig, err := a.Multisig()
+	if err != nil {
+		return err
+	}
+	a.header.Signature = multisig
+	return a.header.VerifySignature(a.epochKey, len(a.keys), a.quorum)
+}
@@ -0,0 +1,103 @@
+package signer
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/eywa-protocol/bls-crypto/bls"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eywa-protocol/chain/account"
+	"github.com/eywa-protocol/chain/common"
+	"github.com/eywa-protocol/chain/core/ledger"
+	"github.com/eywa-protocol/chain/core/payload"
+	"github.com/eywa-protocol/chain/core/txpool"
+	"github.com/eywa-protocol/chain/core/types"
+)
+
+// newTestSigners return signers of epoch participants with membership keys exchanged
+func newTestSigners(t *testing.T, epoch *payload.EpochEvent, accounts []*account.Account) []*Signer {
+	signers := make([]*Signer, 0, len(accounts))
+	for i, acc := range accounts {
+		parts := make([]bls.Signature, 0, len(accounts))
+		for _, other := range accounts {
+			part, err := MembershipKeyPart(other, epoch.PublicKeys, i)
+			require.NoError(t, err)
+			parts = append(parts, part)
+		}
+		membershipKey, err := AggregateMembershipKey(parts)
+		require.NoError(t, err)
+		signer, err := NewSigner(acc, epoch.PublicKeys, membershipKey)
+		require.NoError(t, err)
+		require.Equal(t, i, signer.Index())
+		signers = append(signers, signer)
+	}
+	return signers
+}
+
+func TestHeaderSigning(t *testing.T) {
+	accounts := make([]*account.Account, 4)
+	keys := make([]bls.PublicKey, len(accounts))
+	for i := range accounts {
+		accounts[i] = account.NewAccount(byte(i))
+		keys[i] = accounts[i].PublicKey
+	}
+	epoch := payload.NewEpochEvent(1, common.UINT256_EMPTY, keys, []string{"one", "two", "three", "four"})
+	signers := newTestSigners(t, epoch, accounts)
+	var membershipKey bls.Signature
+	_, err := NewSigner(account.NewAccount(5), epoch.PublicKeys, membershipKey)
+	require.Equal(t, ErrNotParticipant, err)
+
+	l, err := ledger.NewLedger(t.TempDir(), 0)
+	require.NoError(t, err)
+	defer l.Close()
+	genesisBlock := types.NewBlock(0, common.UINT256_EMPTY, common.UINT256_EMPTY, 10, 0, types.Transactions{types.ToTransaction(epoch)})
+	require.NoError(t, l.Init(genesisBlock))
+	block, err := ledger.NewBlockBuilder(l, txpool.NewTxPool(l, txpool.Config{}), 0).Build(11)
+	require.NoError(t, err)
+	header := block.Header
+
+	// 67 percent of 4 participants is 3 signatures
+	aggregator, err := NewAggregator(header, epoch.PublicKeys, 67)
+	require.NoError(t, err)
+	require.Equal(t, 3, aggregator.Quorum())
+	require.NoError(t, aggregator.Add(signers[3].Sign(header)))
+	require.NoError(t, aggregator.Add(signers[1].Sign(header)))
+	require.Equal(t, ErrDuplicateSignature, aggregator.Add(signers[1].Sign(header)))
+	other := types.NewBlock(0, header.PrevBlockHash, header.EpochBlockHash, 12, 1, types.Transactions{})
+	require.Error(t, aggregator.Add(signers[0].Sign(other.Header)))
+	wrongIndex := signers[0].Sign(header)
+	wrongIndex.Index = 2
+	require.Error(t, aggregator.Add(wrongIndex))
+	require.False(t, aggregator.HasQuorum())
+	require.True(t, errors.Is(aggregator.Finalize(), ErrNoQuorum))
+	require.NoError(t, aggregator.Add(signers[0].Sign(header)))
+	require.True(t, aggregator.HasQuorum())
+	require.NoError(t, aggregator.Finalize())
+	require.Equal(t, 0, header.Signature.PartMask.Bit(2))
+	require.Equal(t, 1, header.Signature.PartMask.Bit(3))
+
+	// ledger accepts block signed by quorum of the epoch
+	l.SetQuorumThreshold(67)
+	require.NoError(t, l.ExecAndSaveBlock(block))
+	require.Equal(t, block.Hash(), l.GetCurrentBlockHash())
+}
+
+func TestMaxEpochSize(t *testing.T) {
+	_, pubKey := bls.GenerateRandomKey()
+	keys := make([]bls.PublicKey, MaxEpochSize+1)
+	for i := range keys {
+		keys[i] = pubKey
+	}
+	_, err := epochKey(keys)
+	require.Error(t, err)
+	key, err := epochKey(keys[:MaxEpochSize])
+	require.NoError(t, err)
+
+	// participants count of the largest epoch is kept by epoch event raw data
+	event := payload.NewEpochEvent(1, common.UINT256_EMPTY, keys[:MaxEpochSize], nil)
+	require.Equal(t, key.Marshal(), event.EpochPublicKey.Marshal())
+	_, size, err := payload.ParseEpochRawData(event.RawData())
+	require.NoError(t, err)
+	require.Equal(t, MaxEpochSize, size)
+}
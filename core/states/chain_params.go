@@ -0,0 +1,106 @@
+package states
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/eywa-protocol/chain/common"
+	"github.com/eywa-protocol/chain/common/serialization"
+)
+
+// ChainParams are ledger parameters fixed at genesis, omitted ones keep ledger defaults
+type ChainParams struct {
+	StateBase
+	QuorumThreshold    *uint64           // Percent of epoch participants required to sign block header
+	RequestExpiry      *uint64           // Blocks after which undelivered request is expired
+	ConfirmationDepths map[uint64]uint64 // Source chain id => confirmation depth
+}
+
+func (this *ChainParams) Serialize(w io.Writer) error {
+	err := this.StateBase.Serialize(w)
+	if err != nil {
+		return err
+	}
+	for _, param := range []*uint64{this.QuorumThreshold, this.RequestExpiry} {
+		if err = serialization.WriteBool(w, param != nil); err != nil {
+			return err
+		}
+		if param == nil {
+			continue
+		}
+		if err = serialization.WriteUint64(w, *param); err != nil {
+			return err
+		}
+	}
+	chainIds := make([]uint64, 0, len(this.ConfirmationDepths))
+	for chainId := range this.ConfirmationDepths {
+		chainIds = append(chainIds, chainId)
+	}
+	sort.Slice(chainIds, func(i, j int) bool {
+		return chainIds[i] < chainIds[j]
+	})
+	if err = serialization.WriteUint32(w, uint32(len(chainIds))); err != nil {
+		return err
+	}
+	for _, chainId := range chainIds {
+		if err = serialization.WriteUint64(w, chainId); err != nil {
+			return err
+		}
+		if err = serialization.WriteUint64(w, this.ConfirmationDepths[chainId]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Hash return sha256 of serialized parameters, genesis epoch event carries it as source transaction
+func (this *ChainParams) Hash() common.Uint256 {
+	buf := bytes.NewBuffer(nil)
+	this.Serialize(buf)
+	return sha256.Sum256(buf.Bytes())
+}
+
+func (this *ChainParams) Deserialize(r io.Reader) error {
+	err := this.StateBase.Deserialize(r)
+	if err != nil {
+		return err
+	}
+	for _, param := range []**uint64{&this.QuorumThreshold, &this.RequestExpiry} {
+		set, err := serialization.ReadBool(r)
+		if err != nil {
+			return err
+		}
+		if !set {
+			*param = nil
+			continue
+		}
+		value, err := serialization.ReadUint64(r)
+		if err != nil {
+			return err
+		}
+		*param = &value
+	}
+	count, err := serialization.ReadUint32(r)
+	if err != nil {
+		return fmt.Errorf("[ChainParams] read confirmation depths count error %s", err)
+	}
+	this.ConfirmationDepths = nil
+	for i := uint32(0); i < count; i++ {
+		chainId, err := serialization.ReadUint64(r)
+		if err != nil {
+			return err
+		}
+		depth, err := serialization.ReadUint64(r)
+		if err != nil {
+			return err
+		}
+		if this.ConfirmationDepths == nil {
+			this.ConfirmationDepths = make(map[uint64]uint64)
+		}
+		this.ConfirmationDepths[chainId] = depth
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package states
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChainParams_Deserialize_Serialize(t *testing.T) {
+	expiry := uint64(100)
+	params := ChainParams{
+		StateBase:          StateBase{(byte)(1)},
+		RequestExpiry:      &expiry,
+		ConfirmationDepths: map[uint64]uint64{94: 20, 95: 5},
+	}
+
+	buf := bytes.NewBuffer(nil)
+	err := params.Serialize(buf)
+	assert.NoError(t, err)
+	bs := buf.Bytes()
+
+	var params2 ChainParams
+	err = params2.Deserialize(bytes.NewBuffer(bs))
+	assert.NoError(t, err)
+	assert.Equal(t, params, params2)
+
+	var params3 ChainParams
+	err = params3.Deserialize(bytes.NewBuffer(bs[:len(bs)-1]))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,114 @@
+package states
+
+import (
+	"io"
+
+	"github.com/eywa-protocol/bls-crypto/bls"
+
+	"github.com/eywa-protocol/chain/common"
+	"github.com/eywa-protocol/chain/common/serialization"
+)
+
+// EpochInfo is the validator set applied from a committed epoch event
+type EpochInfo struct {
+	StateBase
+	Number         uint32          // Number of the epoch
+	BlockHash      common.Uint256  // Hash of the block including the epoch event
+	Height         uint64          // Height of the block including the epoch event
+	EpochPublicKey bls.PublicKey   // Aggregated public key of all epoch participants
+	SourceTx       common.Uint256  // Governance blockchain transaction that caused this epoch change
+	PublicKeys     []bls.PublicKey // Public keys of all epoch participants
+	HostIds        []string        // Host IDs of epoch participants
+}
+
+func (this *EpochInfo) Serialize(w io.Writer) error {
+	err := this.StateBase.Serialize(w)
+	if err != nil {
+		return err
+	}
+	if err = serialization.WriteUint32(w, this.Number); err != nil {
+		return err
+	}
+	if err = this.BlockHash.Serialize(w); err != nil {
+		return err
+	}
+	if err = serialization.WriteUint64(w, this.Height); err != nil {
+		return err
+	}
+	if err = serialization.WriteVarBytes(w, this.EpochPublicKey.Marshal()); err != nil {
+		return err
+	}
+	if err = this.SourceTx.Serialize(w); err != nil {
+		return err
+	}
+	if err = serialization.WriteUint32(w, uint32(len(this.PublicKeys))); err != nil {
+		return err
+	}
+	for _, v := range this.PublicKeys {
+		if err = serialization.WriteVarBytes(w, v.Marshal()); err != nil {
+			return err
+		}
+	}
+	if err = serialization.WriteUint32(w, uint32(len(this.HostIds))); err != nil {
+		return err
+	}
+	for _, v := range this.HostIds {
+		if err = serialization.WriteString(w, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (this *EpochInfo) Deserialize(r io.Reader) error {
+	err := this.StateBase.Deserialize(r)
+	if err != nil {
+		return err
+	}
+	if this.Number, err = serialization.ReadUint32(r); err != nil {
+		return err
+	}
+	if err = this.BlockHash.Deserialize(r); err != nil {
+		return err
+	}
+	if this.Height, err = serialization.ReadUint64(r); err != nil {
+		return err
+	}
+	buf, err := serialization.ReadVarBytes(r)
+	if err != nil {
+		return err
+	}
+	if this.EpochPublicKey, err = bls.UnmarshalPublicKey(buf); err != nil {
+		return err
+	}
+	if err = this.SourceTx.Deserialize(r); err != nil {
+		return err
+	}
+	n, err := serialization.ReadUint32(r)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < int(n); i++ {
+		buf, err := serialization.ReadVarBytes(r)
+		if err != nil {
+			return err
+		}
+		pk, err := bls.UnmarshalPublicKey(buf)
+		if err != nil {
+			return err
+		}
+		this.PublicKeys = append(this.PublicKeys, pk)
+	}
+	n, err = serialization.ReadUint32(r)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < int(n); i++ {
+		hostId, err := serialization.ReadString(r)
+		if err != nil {
+			return err
+		}
+		this.HostIds = append(this.HostIds, hostId)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package states
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/eywa-protocol/bls-crypto/bls"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eywa-protocol/chain/common"
+)
+
+func TestEpochInfo_Deserialize_Serialize(t *testing.T) {
+	_, pubKey1 := bls.GenerateRandomKey()
+	_, pubKey2 := bls.GenerateRandomKey()
+	keys := []bls.PublicKey{pubKey1, pubKey2}
+	epochKey := bls.AggregatePublicKeys(keys, bls.CalculateAntiRogueCoefficients(keys))
+
+	info := EpochInfo{
+		StateBase:      StateBase{(byte)(1)},
+		Number:         7,
+		BlockHash:      common.Uint256{1, 2, 3},
+		Height:         100,
+		EpochPublicKey: epochKey,
+		SourceTx:       common.Uint256{4, 5, 6},
+		PublicKeys:     keys,
+		HostIds:        []string{"one", "two"},
+	}
+
+	buf := bytes.NewBuffer(nil)
+	err := info.Serialize(buf)
+	assert.NoError(t, err)
+	bs := buf.Bytes()
+
+	var info2 EpochInfo
+	err = info2.Deserialize(bytes.NewBuffer(bs))
+	assert.NoError(t, err)
+	assert.Equal(t, info.Number, info2.Number)
+	assert.Equal(t, info.BlockHash, info2.BlockHash)
+	assert.Equal(t, info.Height, info2.Height)
+	assert.Equal(t, info.EpochPublicKey.Marshal(), info2.EpochPublicKey.Marshal())
+	assert.Equal(t, info.SourceTx, info2.SourceTx)
+	assert.Equal(t, len(info.PublicKeys), len(info2.PublicKeys))
+	assert.Equal(t, info.HostIds, info2.HostIds)
+
+	var info3 EpochInfo
+	err = info3.Deserialize(bytes.NewBuffer(bs[:len(bs)-1]))
+	assert.NotNil(t, err)
+}
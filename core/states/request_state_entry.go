@@ -0,0 +1,65 @@
+package states
+
+import (
+	"io"
+
+	"github.com/eywa-protocol/chain/common"
+	"github.com/eywa-protocol/chain/common/serialization"
+	"github.com/eywa-protocol/chain/core/payload"
+)
+
+// RequestStateEntry is the record of request state change in request history
+type RequestStateEntry struct {
+	StateBase
+	RequestId [32]byte         // Request id
+	PrevState payload.ReqState // Request state before the change
+	State     payload.ReqState // Request state after the change
+	Height    uint64           // Ledger block height of the transaction caused the change
+	TxHash    common.Uint256   // Ledger transaction hash caused the change
+}
+
+func (this *RequestStateEntry) Serialize(w io.Writer) error {
+	err := this.StateBase.Serialize(w)
+	if err != nil {
+		return err
+	}
+	if err = serialization.WriteBytes(w, this.RequestId[:]); err != nil {
+		return err
+	}
+	if err = serialization.WriteByte(w, byte(this.PrevState)); err != nil {
+		return err
+	}
+	if err = serialization.WriteByte(w, byte(this.State)); err != nil {
+		return err
+	}
+	if err = serialization.WriteUint64(w, this.Height); err != nil {
+		return err
+	}
+	return this.TxHash.Serialize(w)
+}
+
+func (this *RequestStateEntry) Deserialize(r io.Reader) error {
+	err := this.StateBase.Deserialize(r)
+	if err != nil {
+		return err
+	}
+	reqId, err := serialization.ReadBytes(r, 32)
+	if err != nil {
+		return err
+	}
+	copy(this.RequestId[:], reqId)
+	prevState, err := serialization.ReadByte(r)
+	if err != nil {
+		return err
+	}
+	this.PrevState = payload.ReqState(prevState)
+	state, err := serialization.ReadByte(r)
+	if err != nil {
+		return err
+	}
+	this.State = payload.ReqState(state)
+	if this.Height, err = serialization.ReadUint64(r); err != nil {
+		return err
+	}
+	return this.TxHash.Deserialize(r)
+}
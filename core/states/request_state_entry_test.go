@@ -0,0 +1,36 @@
+package states
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eywa-protocol/chain/common"
+	"github.com/eywa-protocol/chain/core/payload"
+)
+
+func TestRequestStateEntry_Deserialize_Serialize(t *testing.T) {
+	entry := RequestStateEntry{
+		StateBase: StateBase{(byte)(1)},
+		RequestId: [32]byte{1, 2, 3},
+		PrevState: payload.ReqStateReceived,
+		State:     payload.ReqStateSubmitted,
+		Height:    12,
+		TxHash:    common.Uint256{4, 5, 6},
+	}
+
+	buf := bytes.NewBuffer(nil)
+	err := entry.Serialize(buf)
+	assert.NoError(t, err)
+	bs := buf.Bytes()
+
+	var entry2 RequestStateEntry
+	err = entry2.Deserialize(bytes.NewBuffer(bs))
+	assert.NoError(t, err)
+	assert.Equal(t, entry, entry2)
+
+	var entry3 RequestStateEntry
+	err = entry3.Deserialize(bytes.NewBuffer(bs[:len(bs)-1]))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,110 @@
+package states
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/eywa-protocol/chain/common"
+	"github.com/eywa-protocol/chain/common/serialization"
+)
+
+// FinalityStatus of an event ingested from source chain
+type FinalityStatus byte
+
+const (
+	FinalityUnknown FinalityStatus = iota // event not tracked
+	FinalityPending                       // source block has not reached confirmation depth
+	FinalityFinal                         // source block reached confirmation depth
+	FinalityReorged                       // source block was reorged, event must not be relayed
+)
+
+func (fs FinalityStatus) String() string {
+	switch fs {
+	case FinalityPending:
+		return "pending"
+	case FinalityFinal:
+		return "final"
+	case FinalityReorged:
+		return "reorged"
+	default:
+		return "unknown"
+	}
+}
+
+// SourceEventState is the source chain position of the event included to ledger
+type SourceEventState struct {
+	StateBase
+	ChainId      uint64         // Source chain id
+	SrcHeight    uint64         // Source block height (slot for solana)
+	SrcBlockHash []byte         // Source block hash, empty if source chain does not provide it
+	SrcTxHash    []byte         // Source transaction hash or signature
+	TxHash       common.Uint256 // Ledger transaction hash
+	RequestId    [32]byte       // Request id of the event
+	Height       uint64         // Ledger block height
+	Status       FinalityStatus // Finality status
+}
+
+func (this *SourceEventState) Serialize(w io.Writer) error {
+	err := this.StateBase.Serialize(w)
+	if err != nil {
+		return err
+	}
+	if err = serialization.WriteUint64(w, this.ChainId); err != nil {
+		return err
+	}
+	if err = serialization.WriteUint64(w, this.SrcHeight); err != nil {
+		return err
+	}
+	if err = serialization.WriteVarBytes(w, this.SrcBlockHash); err != nil {
+		return err
+	}
+	if err = serialization.WriteVarBytes(w, this.SrcTxHash); err != nil {
+		return err
+	}
+	if err = this.TxHash.Serialize(w); err != nil {
+		return err
+	}
+	if err = serialization.WriteBytes(w, this.RequestId[:]); err != nil {
+		return err
+	}
+	if err = serialization.WriteUint64(w, this.Height); err != nil {
+		return err
+	}
+	return serialization.WriteByte(w, byte(this.Status))
+}
+
+func (this *SourceEventState) Deserialize(r io.Reader) error {
+	err := this.StateBase.Deserialize(r)
+	if err != nil {
+		return err
+	}
+	if this.ChainId, err = serialization.ReadUint64(r); err != nil {
+		return err
+	}
+	if this.SrcHeight, err = serialization.ReadUint64(r); err != nil {
+		return err
+	}
+	if this.SrcBlockHash, err = serialization.ReadVarBytes(r); err != nil {
+		return err
+	}
+	if this.SrcTxHash, err = serialization.ReadVarBytes(r); err != nil {
+		return err
+	}
+	if err = this.TxHash.Deserialize(r); err != nil {
+		return err
+	}
+	reqId, err := serialization.ReadBytes(r, 32)
+	if err != nil {
+		return err
+	}
+	copy(this.RequestId[:], reqId)
+	if this.Height, err = serialization.ReadUint64(r); err != nil {
+		return err
+	}
+	status, err := serialization.ReadByte(r)
+	if err != nil {
+		return fmt.Errorf("[SourceEventState] read status error %s", err)
+	}
+	this.Status = FinalityStatus(status)
+	return nil
+}
@@ -0,0 +1,38 @@
+package states
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eywa-protocol/chain/common"
+)
+
+func TestSourceEventState_Deserialize_Serialize(t *testing.T) {
+	state := SourceEventState{
+		StateBase:    StateBase{(byte)(1)},
+		ChainId:      94,
+		SrcHeight:    1000,
+		SrcBlockHash: []byte{1, 2, 3},
+		SrcTxHash:    []byte{4, 5, 6},
+		TxHash:       common.Uint256{7, 8, 9},
+		RequestId:    [32]byte{10, 11},
+		Height:       12,
+		Status:       FinalityPending,
+	}
+
+	buf := bytes.NewBuffer(nil)
+	err := state.Serialize(buf)
+	assert.NoError(t, err)
+	bs := buf.Bytes()
+
+	var state2 SourceEventState
+	err = state2.Deserialize(bytes.NewBuffer(bs))
+	assert.NoError(t, err)
+	assert.Equal(t, state, state2)
+
+	var state3 SourceEventState
+	err = state3.Deserialize(bytes.NewBuffer(bs[:len(bs)-1]))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,64 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+const DefaultBackend = "goleveldb" // Backend used when store backend is not configured
+
+var ErrClosed = errors.New("store closed")
+
+// Backends shipped with the ledger are goleveldb (leveldbstore) and memory (memstore), the latter keeps nothing
+// on disk and is meant for tests. Other engines such as Pebble or BoltDB are not provided, they can be added
+// by a package registering its opener with RegisterBackend and passing storetest.RunConformance.
+
+// BackendOpener open persist store at path
+type BackendOpener func(path string) (PersistStore, error)
+
+var (
+	backendsLock sync.RWMutex
+	backends     = make(map[string]BackendOpener)
+)
+
+// RegisterBackend make persist store backend available by name. Backend packages register themselves in init,
+// registering the same name twice panics
+func RegisterBackend(name string, opener BackendOpener) {
+	backendsLock.Lock()
+	defer backendsLock.Unlock()
+	if opener == nil {
+		panic("store: register backend " + name + " opener is nil")
+	}
+	if _, dup := backends[name]; dup {
+		panic("store: register backend " + name + " twice")
+	}
+	backends[name] = opener
+}
+
+// OpenPersistStore open persist store at path with the backend, DefaultBackend is used if backend is empty
+func OpenPersistStore(backend, path string) (PersistStore, error) {
+	if backend == "" {
+		backend = DefaultBackend
+	}
+	backendsLock.RLock()
+	opener, ok := backends[backend]
+	backendsLock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown store backend %s", backend)
+	}
+	return opener(path)
+}
+
+// Backends return sorted names of registered backends
+func Backends() []string {
+	backendsLock.RLock()
+	defer backendsLock.RUnlock()
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
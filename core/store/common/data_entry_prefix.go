@@ -12,6 +12,8 @@ const (
 	DATA_TRANSACTION                       = 0x02 // Transction hash = > transaction key prefix
 	DATA_REQUEST_ID                        = 0x25 // RequestId key prefix = > req id state + transaction hash
 	DATA_STATE_MERKLE_ROOT                 = 0x21 // block height => write set hash + state merkle root
+	DATA_SOURCE_EVENT                      = 0x29 // Transaction hash => source event state
+	DATA_REQUEST_HISTORY                   = 0x2b // RequestId + sequence number => request state entry
 
 	// Transaction
 	ST_BOOKKEEPER DataEntryPrefix = 0x03 // BookKeeper state key prefix
@@ -19,8 +21,17 @@ const (
 	ST_STORAGE    DataEntryPrefix = 0x05 // Smart contract storage key prefix
 	ST_VALIDATOR  DataEntryPrefix = 0x07 // no use
 	ST_VOTE       DataEntryPrefix = 0x08 // Vote state key prefix
-
-	IX_HEADER_HASH_LIST DataEntryPrefix = 0x09 // Block height => block hash key prefix
+	ST_EPOCH      DataEntryPrefix = 0x26 // Epoch number => epoch info key prefix
+
+	IX_HEADER_HASH_LIST  DataEntryPrefix = 0x09 // Block height => block hash key prefix
+	IX_EPOCH_BLOCK_HASH  DataEntryPrefix = 0x27 // Epoch block hash => epoch number key prefix
+	IX_EPOCH_HEIGHT      DataEntryPrefix = 0x28 // Epoch block height => epoch number key prefix
+	IX_SOURCE_PENDING    DataEntryPrefix = 0x2a // Source chain id + source height + transaction hash => pending finality
+	IX_OPEN_REQUEST      DataEntryPrefix = 0x2c // Open height + request id => destination chain id of open request
+	IX_EXPIRED_REQUEST   DataEntryPrefix = 0x2d // Block height + request id => request expired automatically at height
+	IX_REQUEST_DST_CHAIN DataEntryPrefix = 0x2e // Destination chain id + block height + request id => request received
+	IX_REQUEST_SRC_TX    DataEntryPrefix = 0x2f // Source transaction hash length + hash + request id => request transaction
+	IX_REQUEST_BRIDGE    DataEntryPrefix = 0x30 // Bridge address length + address + block height + request id => request received
 
 	// SYSTEM
 	SYS_CURRENT_BLOCK      DataEntryPrefix = 0x10 // Current block key prefix
@@ -32,6 +43,10 @@ const (
 	SYS_CROSS_STATES_HASH  DataEntryPrefix = 0x23
 
 	SYS_PROCESSED_SRC_HEIGHT DataEntryPrefix = 0x24 // processed source height
+	SYS_PRUNED_HEIGHT        DataEntryPrefix = 0x31 // Height pruning is done up to + height pruning is started up to
+	SYS_ROLLBACK_HEIGHT      DataEntryPrefix = 0x32 // Height of started rollback, removed with block store rollback
+	SYS_CHAIN_PARAMS         DataEntryPrefix = 0x33 // Chain parameters fixed at genesis
+	SYS_REQUEST_INDEX_HEIGHT DataEntryPrefix = 0x34 // Height request indexes start at
 
 	EVENT_NOTIFY DataEntryPrefix = 0x14 // Event notify key prefix
 )
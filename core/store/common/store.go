@@ -10,17 +10,20 @@ import (
 
 var ErrNotFound = errors.New("not found")
 
+// ErrPruned is returned for data removed from store by pruning
+var ErrPruned = errors.New("data pruned")
+
 // StoreIterator iterator for iterate store
 type StoreIterator interface {
-	Next() bool // Next item. If item available return true, otherwise return false
-	// Prev() bool           //previous item. If item available return true, otherwise return false
-	First() bool // First item. If item available return true, otherwise return false
-	// Last() bool           //Last item. If item available return true, otherwise return false
-	// Seek(key []byte) bool //Seek key. If item available return true, otherwise return false
-	Key() []byte   // Return the current item key
-	Value() []byte // Return the current item value
-	Release()      // Close iterator
-	Error() error  // Error returns any accumulated error.
+	Next() bool           // Next item. If item available return true, otherwise return false
+	Prev() bool           // Previous item. If item available return true, otherwise return false
+	First() bool          // First item. If item available return true, otherwise return false
+	Last() bool           // Last item. If item available return true, otherwise return false
+	Seek(key []byte) bool // Seek the first item with key greater or equal to key. If item available return true, otherwise return false
+	Key() []byte          // Return the current item key
+	Value() []byte        // Return the current item value
+	Release()             // Close iterator
+	Error() error         // Error returns any accumulated error.
 }
 
 // PersistStore of ledger
@@ -35,6 +38,8 @@ type PersistStore interface {
 	BatchCommit() error                      // Commit batch to store
 	Close() error                            // Close store
 	NewIterator(prefix []byte) StoreIterator // Return the iterator of store
+	// Return the iterator of keys in range [start, end), nil start or end means the range is not bounded on the side
+	NewRangeIterator(start, end []byte) StoreIterator
 }
 
 // StateStore save result of smart contract execution, before commit to store
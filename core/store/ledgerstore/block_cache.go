@@ -112,3 +112,10 @@ func (c *BlockCache) ContainTransaction(txHash common.Uint256) bool {
 func (c *BlockCache) ContainTransactionWithReqId(reqId [32]byte) bool {
 	return c.transactionCache.Contains(string(reqId[:]))
 }
+
+// Purge remove all blocks, transactions and request ids from cache
+func (c *BlockCache) Purge() {
+	c.blockCache.Purge()
+	c.transactionCache.Purge()
+	c.requestIdCache.Purge()
+}
@@ -5,25 +5,37 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
+	"sync/atomic"
 
 	"github.com/eywa-protocol/chain/common"
 	"github.com/eywa-protocol/chain/common/serialization"
 	"github.com/eywa-protocol/chain/core/payload"
 	scom "github.com/eywa-protocol/chain/core/store/common"
-	"github.com/eywa-protocol/chain/core/store/leveldbstore"
 	"github.com/eywa-protocol/chain/core/types"
 )
 
 // BlockStore Block store save the data of block & transaction
 type BlockStore struct {
-	enableCache bool                       // Is enable lru cache
-	dbDir       string                     // The path of store file
-	cache       *BlockCache                // The cache of block, if have.
-	store       *leveldbstore.LevelDBStore // block store handler
+	enableCache bool                        // Is enable lru cache
+	dbDir       string                      // The path of store file
+	cache       *BlockCache                 // The cache of block, if have.
+	store       scom.PersistStore           // block store handler
+	requests    map[[32]byte]*requestRecord // Request records changed in current batch
+	changes     []*requestChange            // Request state changes saved in current batch
+	pruned      uint64                      // Blocks below the height are pruned, accessed atomically
+}
+
+// NewBlockStore return the block store instance opened with the backend, empty backend means default one
+func NewBlockStore(backend, dbDir string, enableCache bool) (*BlockStore, error) {
+	store, err := scom.OpenPersistStore(backend, dbDir)
+	if err != nil {
+		return nil, err
+	}
+	return newBlockStore(store, dbDir, enableCache)
 }
 
-// NewBlockStore return the block store instance
-func NewBlockStore(dbDir string, enableCache bool) (*BlockStore, error) {
+// newBlockStore return the block store instance over opened store
+func newBlockStore(store scom.PersistStore, dbDir string, enableCache bool) (*BlockStore, error) {
 	var cache *BlockCache
 	var err error
 	if enableCache {
@@ -33,30 +45,30 @@ func NewBlockStore(dbDir string, enableCache bool) (*BlockStore, error) {
 		}
 	}
 
-	store, err := leveldbstore.NewLevelDBStore(dbDir)
-	if err != nil {
-		return nil, err
-	}
 	blockStore := &BlockStore{
 		dbDir:       dbDir,
 		enableCache: enableCache,
 		store:       store,
 		cache:       cache,
+		requests:    make(map[[32]byte]*requestRecord),
+	}
+	_, target, err := blockStore.getPruneState()
+	if err != nil {
+		return nil, fmt.Errorf("getPruneState error %s", err)
 	}
+	blockStore.pruned = target
 	return blockStore, nil
 }
 
 // NewBatch start a commit batch
 func (s *BlockStore) NewBatch() {
 	s.store.NewBatch()
+	s.requests = make(map[[32]byte]*requestRecord)
+	s.changes = nil
 }
 
 // SaveBlock persist block to store
 func (s *BlockStore) SaveBlock(block *types.Block) error {
-	if s.enableCache {
-		s.cache.AddBlock(block)
-	}
-
 	blockHeight := block.Header.Height
 	err := s.SaveHeader(block)
 	if err != nil {
@@ -69,6 +81,9 @@ func (s *BlockStore) SaveBlock(block *types.Block) error {
 			return fmt.Errorf("SaveTransaction block height %d tx %s err %s", blockHeight, txHash.ToHexString(), err)
 		}
 	}
+	if s.enableCache {
+		s.cache.AddBlock(block)
+	}
 	return nil
 }
 
@@ -96,6 +111,9 @@ func (s *BlockStore) GetBlock(blockHash common.Uint256) (*types.Block, error) {
 	if s.enableCache {
 		block = s.cache.GetBlock(blockHash)
 		if block != nil {
+			if s.isPruned(block.Header.Height) {
+				return nil, scom.ErrPruned
+			}
 			return block, nil
 		}
 	}
@@ -103,12 +121,18 @@ func (s *BlockStore) GetBlock(blockHash common.Uint256) (*types.Block, error) {
 	if err != nil {
 		return nil, err
 	}
+	if s.isPruned(header.Height) {
+		return nil, scom.ErrPruned
+	}
 	txList := make(types.Transactions, 0, len(txHashes))
 	for _, txHash := range txHashes {
 
 		tx, _, err := s.GetTransaction(txHash)
+		if err == scom.ErrPruned {
+			return nil, err
+		}
 		if err != nil {
-			return nil, fmt.Errorf("GetTransaction %s error %s", txHash.ToHexString(), err)
+			return nil, fmt.Errorf("GetTransaction %s error %w", txHash.ToHexString(), err)
 		}
 		if tx == nil {
 			return nil, fmt.Errorf("cannot get transaction %s", txHash.ToHexString())
@@ -125,14 +149,19 @@ func (s *BlockStore) loadHeaderWithTx(blockHash common.Uint256) (*types.Header,
 	if err != nil {
 		return nil, nil, err
 	}
+	return decodeHeaderWithTx(value)
+}
+
+// decodeHeaderWithTx decode header with transaction hashes saved by SaveHeader
+func decodeHeaderWithTx(value []byte) (*types.Header, []common.Uint256, error) {
 	source := common.NewZeroCopySource(value)
 	header := new(types.Header)
-	err = header.Deserialization(source)
+	err := header.Deserialization(source)
 	if err != nil {
 		return nil, nil, err
 	}
 	txSize, eof := source.NextUint32()
-	if eof {
+	if eof || uint64(txSize)*common.UINT256_SIZE > source.Len() {
 		return nil, nil, io.ErrUnexpectedEOF
 	}
 	txHashes := make([]common.Uint256, 0, int(txSize))
@@ -297,73 +326,76 @@ func (s *BlockStore) SaveBlockHash(height uint64, blockHash common.Uint256) {
 
 // SaveTransaction persist transaction to store
 func (s *BlockStore) SaveTransaction(tx payload.Payload, height uint64) error {
+	if err := s.putTransaction(tx, height); err != nil {
+		return err
+	}
 	if s.enableCache {
 		s.cache.AddTransaction(tx, height)
 	}
-	return s.putTransaction(tx, height)
+	return nil
 }
 
 func (s *BlockStore) putTransaction(payload payload.Payload, height uint64) error {
+	txHash, err := s.putTransactionData(payload, height)
+	if err != nil {
+		return err
+	}
+
+	// put request id  to batch
+	if payload.RequestState() > 0 {
+		return s.putRequestState(payload, txHash, height)
+	}
+
+	return nil
+}
+
+// putTransactionData put transaction with its height to batch, request state is not changed
+func (s *BlockStore) putTransactionData(payload payload.Payload, height uint64) (common.Uint256, error) {
 	tx := types.ToTransaction(payload)
 	txHash := tx.Hash()
 
 	key, err := s.getTransactionKey(txHash)
 	if err != nil {
-		return err
+		return txHash, err
 	}
 	value := bytes.NewBuffer(nil)
 
 	if err := serialization.WriteUint64(value, height); err != nil {
-		return err
+		return txHash, err
 	}
 
 	if err := serialization.WriteBytes(value, tx.ToArray()); err != nil {
-		return err
+		return txHash, err
 	}
 
 	s.store.BatchPut(key, value.Bytes())
-
-	// put request id  to batch
-	if reqState := payload.RequestState(); reqState > 0 {
-		rKey := s.getRequestIdKey(payload.RequestId())
-		rVal := make([]byte, 1+len(txHash))
-		rVal[0] = byte(reqState)
-		copy(rVal[1:], txHash[:])
-		s.store.BatchPut(rKey, rVal)
-	}
-
-	return nil
+	return txHash, nil
 }
 
-// GetTransaction return transaction by transaction hash
+// GetTransaction return transaction by transaction hash, ErrPruned if the transaction is pruned
 func (s *BlockStore) GetTransaction(txHash common.Uint256) (payload.Payload, uint64, error) {
 	if s.enableCache {
 		tx, height := s.cache.GetTransaction(txHash)
 		if tx != nil {
-			return tx, height, nil
+			return s.checkPruned(tx, height, nil)
 		}
 	}
-	return s.loadTransaction(txHash)
+	return s.checkPruned(s.loadTransaction(txHash))
 }
 
-// GetTransactionByReqId return transaction by request id
+// GetTransactionByReqId return transaction by request id, ErrPruned if the transaction is pruned
 func (s *BlockStore) GetTransactionByReqId(reqId [32]byte) (payload.Payload, uint64, error) {
 	if s.enableCache {
 		tx, height := s.cache.GetTransactionByReqId(reqId)
 		if tx != nil {
-			return tx, height, nil
+			return s.checkPruned(tx, height, nil)
 		}
 	}
-	return s.loadTransactionByReqId(reqId)
+	return s.checkPruned(s.loadTransactionByReqId(reqId))
 }
 
+// GetRequestState return current request state. Request state can change without transaction, so it is not cached
 func (s *BlockStore) GetRequestState(reqId [32]byte) (payload.ReqState, error) {
-	if s.enableCache {
-		tx, _ := s.cache.GetTransactionByReqId(reqId)
-		if tx != nil {
-			return tx.RequestState(), nil
-		}
-	}
 	return s.loadReqIdState(reqId)
 }
 
@@ -387,6 +419,18 @@ func (s *BlockStore) loadTransactionByReqId(reqId [32]byte) (payload.Payload, ui
 	return s.loadTransaction(txHash)
 }
 
+// GetRequestTxHash return hash of the transaction which set the current request state, it is kept when transaction is pruned
+func (s *BlockStore) GetRequestTxHash(reqId [32]byte) (common.Uint256, error) {
+	value, err := s.store.Get(s.getRequestIdKey(reqId))
+	if err != nil {
+		return common.UINT256_EMPTY, err
+	}
+	if len(value) < 1+common.UINT256_SIZE {
+		return common.UINT256_EMPTY, fmt.Errorf("request %x record length %d is invalid", reqId, len(value))
+	}
+	return common.Uint256ParseFromBytes(value[1 : 1+common.UINT256_SIZE])
+}
+
 func (s *BlockStore) loadTransaction(txHash common.Uint256) (payload.Payload, uint64, error) {
 	key, err := s.getTransactionKey(txHash)
 	if err != nil {
@@ -409,6 +453,10 @@ func (s *BlockStore) loadTransaction(txHash common.Uint256) (payload.Payload, ui
 	if eof {
 		return nil, 0, io.ErrUnexpectedEOF
 	}
+	if source.Len() == 0 {
+		// only height is left of pruned transaction
+		return nil, height, scom.ErrPruned
+	}
 	tx, err := types.TransactionDeserialization(source)
 	if err != nil {
 		return nil, 0, fmt.Errorf("transaction deserialize error %s", err)
@@ -465,9 +513,109 @@ func (s *BlockStore) ClearAll() error {
 	if err := iter.Error(); err != nil {
 		return err
 	}
+	atomic.StoreUint64(&s.pruned, 0)
 	return s.CommitTo()
 }
 
+// RollbackTo remove blocks above height from store and set the block specified by height and block hash as current.
+// Blocks must be ordered by height descending. Header index list batches starting from indexCount are removed.
+func (s *BlockStore) RollbackTo(height uint64, blockHash common.Uint256, blocks []*types.Block, indexCount, storedIndexCount uint64) error {
+	s.NewBatch()
+	requests := make(map[[32]byte]struct{})
+	for _, block := range blocks {
+		blockHeight := block.Header.Height
+		if blockHeight <= height {
+			return fmt.Errorf("block height %d not above rollback height %d", blockHeight, height)
+		}
+		for i := len(block.Transactions) - 1; i >= 0; i-- {
+			tx := block.Transactions[i]
+			txHash := tx.Hash()
+			if err := s.rollbackTransaction(tx.Payload, txHash, height, requests); err != nil {
+				return fmt.Errorf("rollbackTransaction %s error %s", txHash.ToHexString(), err)
+			}
+		}
+		if err := s.collectExpiredRequests(blockHeight, requests); err != nil {
+			return fmt.Errorf("collectExpiredRequests height %d error %s", blockHeight, err)
+		}
+		s.store.BatchDelete(s.getHeaderKey(block.Hash()))
+		s.store.BatchDelete(s.getBlockHashKey(blockHeight))
+	}
+	for reqId := range requests {
+		if err := s.rollbackRequestState(reqId, height); err != nil {
+			return fmt.Errorf("rollbackRequestState %x error %s", reqId, err)
+		}
+	}
+	for start := indexCount; start < storedIndexCount; start += HEADER_INDEX_BATCH_SIZE {
+		key, err := s.getHeaderIndexListKey(start)
+		if err != nil {
+			return err
+		}
+		s.store.BatchDelete(key)
+	}
+	if err := s.SaveCurrentBlock(height, blockHash); err != nil {
+		return err
+	}
+	s.store.BatchDelete(s.getRollbackHeightKey())
+	if err := s.CommitTo(); err != nil {
+		return err
+	}
+	if s.enableCache {
+		s.cache.Purge()
+	}
+	return nil
+}
+
+// SaveRollbackHeight persist height of started rollback, it is removed by RollbackTo batch
+func (s *BlockStore) SaveRollbackHeight(height uint64) error {
+	value := make([]byte, 8)
+	binary.BigEndian.PutUint64(value, height)
+	return s.store.Put(s.getRollbackHeightKey(), value)
+}
+
+// GetRollbackHeight return height of the rollback not finished, ErrNotFound if there is no such one
+func (s *BlockStore) GetRollbackHeight() (uint64, error) {
+	value, err := s.store.Get(s.getRollbackHeightKey())
+	if err != nil {
+		return 0, err
+	}
+	if len(value) != 8 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	return binary.BigEndian.Uint64(value), nil
+}
+
+func (s *BlockStore) rollbackTransaction(tx payload.Payload, txHash common.Uint256, height uint64, requests map[[32]byte]struct{}) error {
+	key, err := s.getTransactionKey(txHash)
+	if err != nil {
+		return err
+	}
+	// the same transaction can be saved again at upper height, so keep it if it was first saved below rollback height
+	value, err := s.store.Get(key)
+	if err != nil && err != scom.ErrNotFound {
+		return err
+	}
+	if len(value) >= 8 {
+		txHeight, err := serialization.ReadUint64(bytes.NewReader(value))
+		if err != nil {
+			return err
+		}
+		if txHeight > height {
+			s.store.BatchDelete(key)
+			if err := s.deleteSourceEvent(txHash); err != nil {
+				return err
+			}
+			if tx.RequestState() != payload.ReqStateUnknown {
+				s.deleteRequestIndexes(tx, txHeight)
+			}
+		}
+	}
+
+	if tx.RequestState() != payload.ReqStateUnknown {
+		requests[tx.RequestId()] = struct{}{}
+	}
+	return nil
+}
+
 // CommitTo commit the batch to store
 func (s *BlockStore) CommitTo() error {
 	return s.store.BatchCommit()
@@ -524,6 +672,10 @@ func (s *BlockStore) getVersionKey() []byte {
 	return []byte{byte(scom.SYS_VERSION)}
 }
 
+func (s *BlockStore) getRollbackHeightKey() []byte {
+	return []byte{byte(scom.SYS_ROLLBACK_HEIGHT)}
+}
+
 func (s *BlockStore) getHeaderIndexListKey(startHeight uint64) ([]byte, error) {
 	key := bytes.NewBuffer(nil)
 	if err := key.WriteByte(byte(scom.IX_HEADER_HASH_LIST)); err != nil {
@@ -394,3 +394,69 @@ func TestBlock(t *testing.T) {
 		return
 	}
 }
+
+func TestRequestStateHistory(t *testing.T) {
+	reqId := [32]byte{0xee, 1}
+	received := &payload.BridgeEvent{
+		OriginData: wrappers.BridgeOracleRequest{
+			RequestType: "setRequest",
+			RequestId:   reqId,
+			ChainId:     big.NewInt(94),
+		}}
+	signed := payload.NewRequestStateEvent(reqId, payload.ReqStateSigned, 94, nil, "", 0)
+	submitted := payload.NewRequestStateEvent(reqId, payload.ReqStateSubmitted, 94, []byte{1, 2, 3}, "", 0)
+	confirmed := payload.NewRequestStateEvent(reqId, payload.ReqStateConfirmed, 94, []byte{1, 2, 3}, "", 0)
+
+	// request must be received before any other state
+	require.Error(t, testBlockStore.verifyRequestStates(types.Transactions{types.ToTransaction(signed)}))
+	require.NoError(t, testBlockStore.verifyRequestStates(types.Transactions{types.ToTransaction(received), types.ToTransaction(signed)}))
+	testBlockStore.NewBatch()
+	require.Error(t, testBlockStore.SaveTransaction(signed, 10))
+
+	testBlockStore.NewBatch()
+	require.NoError(t, testBlockStore.SaveTransaction(received, 10))
+	require.NoError(t, testBlockStore.SaveTransaction(signed, 10))
+	require.NoError(t, testBlockStore.CommitTo())
+
+	testBlockStore.NewBatch()
+	require.NoError(t, testBlockStore.SaveTransaction(submitted, 11))
+	// saving the same transaction again doesn't change request state
+	require.NoError(t, testBlockStore.SaveTransaction(submitted, 11))
+	require.Error(t, testBlockStore.SaveTransaction(signed, 11))
+	require.NoError(t, testBlockStore.CommitTo())
+
+	testBlockStore.NewBatch()
+	require.NoError(t, testBlockStore.SaveTransaction(confirmed, 12))
+	require.NoError(t, testBlockStore.CommitTo())
+
+	state, err := testBlockStore.GetRequestState(reqId)
+	require.NoError(t, err)
+	require.Equal(t, payload.ReqStateConfirmed, state)
+
+	history, err := testBlockStore.GetRequestHistory(reqId)
+	require.NoError(t, err)
+	require.Len(t, history, 4)
+	expected := []struct {
+		prev, state payload.ReqState
+		height      uint64
+	}{
+		{payload.ReqStateUnknown, payload.ReqStateReceived, 10},
+		{payload.ReqStateReceived, payload.ReqStateSigned, 10},
+		{payload.ReqStateSigned, payload.ReqStateSubmitted, 11},
+		{payload.ReqStateSubmitted, payload.ReqStateConfirmed, 12},
+	}
+	for i, entry := range history {
+		require.Equal(t, expected[i].prev, entry.PrevState)
+		require.Equal(t, expected[i].state, entry.State)
+		require.Equal(t, expected[i].height, entry.Height)
+		require.Equal(t, reqId, entry.RequestId)
+	}
+	submittedTx := types.ToTransaction(submitted)
+	require.Equal(t, submittedTx.Hash(), history[2].TxHash)
+
+	// confirmed request can not change anymore
+	failed := types.Transactions{types.ToTransaction(payload.NewRequestStateEvent(reqId, payload.ReqStateFailed, 94, nil, "timeout", 0))}
+	require.Error(t, testBlockStore.verifyRequestStates(failed))
+	testBlockStore.NewBatch()
+	require.Error(t, testBlockStore.SaveTransaction(failed[0].Payload, 13))
+}
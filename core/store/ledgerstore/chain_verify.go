@@ -0,0 +1,284 @@
+package ledgerstore
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/eywa-protocol/chain/common"
+	"github.com/eywa-protocol/chain/core/store"
+	scom "github.com/eywa-protocol/chain/core/store/common"
+	"github.com/eywa-protocol/chain/core/types"
+	"github.com/eywa-protocol/chain/merkle"
+)
+
+// chainVerifier collect inconsistencies found by VerifyChain
+type chainVerifier struct {
+	s         *LedgerStoreImp
+	report    *store.ChainReport
+	blockTree *merkle.CompactMerkleTree // Block merkle tree rebuilt from headers
+	stateTree *merkle.CompactMerkleTree // State merkle tree rebuilt from saved write set hashes, nil if history is not complete
+}
+
+// VerifyChain walk saved blocks from height to height inclusive and check headers linkage, source heights,
+// header signatures, transactions roots, block and state merkle trees and request id entries.
+// Every inconsistency found is added to the report, error is returned only for invalid heights
+func (s *LedgerStoreImp) VerifyChain(from, to uint64) (*store.ChainReport, error) {
+	s.getSavingBlockLock()
+	defer s.releaseSavingBlockLock()
+
+	if from > to {
+		return nil, fmt.Errorf("from height %d above to height %d", from, to)
+	}
+	if currHeight := s.GetCurrentBlockHeight(); to > currHeight {
+		return nil, fmt.Errorf("to height %d above current block height %d", to, currHeight)
+	}
+	v := &chainVerifier{
+		s:         s,
+		report:    &store.ChainReport{From: from, To: to, Issues: make([]*store.ChainIssue, 0)},
+		blockTree: merkle.NewTree(0, nil, nil),
+	}
+	v.replay(from)
+	var prevHeader *types.Header
+	if from > 0 {
+		prevHeader, _, _ = s.blockStore.loadHeaderWithTx(s.GetBlockHash(from - 1))
+	}
+	for height := from; height <= to; height++ {
+		prevHeader = v.verifyBlock(height, prevHeader)
+	}
+	if to == s.GetCurrentBlockHeight() {
+		v.verifySavedTrees(to)
+	}
+	v.verifyRequestIndex(from, to)
+	return v.report, nil
+}
+
+func (v *chainVerifier) addIssue(height uint64, check string, format string, args ...interface{}) {
+	v.report.Issues = append(v.report.Issues, &store.ChainIssue{
+		Height: height,
+		Check:  check,
+		Error:  fmt.Sprintf(format, args...),
+	})
+}
+
+// replay rebuild block and state merkle trees of blocks below from height without checks
+func (v *chainVerifier) replay(from uint64) {
+	s := v.s
+	for height := uint64(0); height < from; height++ {
+		prevHash := common.UINT256_EMPTY
+		if height > 0 {
+			prevHash = s.GetBlockHash(height - 1)
+		}
+		v.blockTree.Append(prevHash.ToArray())
+	}
+	v.stateTree = merkle.NewTree(0, nil, nil)
+	for height := s.stateStore.stateHashCheckHeight; height < from; height++ {
+		writeSetHash, _, err := s.stateStore.getStateMerkleRoots(height)
+		if err != nil {
+			// state roots below snapshot height are not saved
+			v.stateTree = nil
+			return
+		}
+		v.stateTree.Append(writeSetHash.ToArray())
+	}
+}
+
+// verifyBlock check the block at height and return its header, nil if header is not found
+func (v *chainVerifier) verifyBlock(height uint64, prevHeader *types.Header) *types.Header {
+	s := v.s
+	blockHash := s.GetBlockHash(height)
+	savedHash, err := s.blockStore.GetBlockHash(height)
+	if err != nil {
+		v.addIssue(height, store.CheckHeader, "block hash error %s", err)
+	} else if savedHash != blockHash {
+		v.addIssue(height, store.CheckHeader, "block hash %s, header index %s", savedHash.ToHexString(), blockHash.ToHexString())
+	}
+	header, txHashes, err := s.blockStore.loadHeaderWithTx(blockHash)
+	if err != nil {
+		v.addIssue(height, store.CheckHeader, "header %s error %s", blockHash.ToHexString(), err)
+		prevHash := common.UINT256_EMPTY
+		if height > 0 {
+			prevHash = s.GetBlockHash(height - 1)
+		}
+		v.verifyBlockTree(height, prevHash)
+		return nil
+	}
+	v.report.Headers++
+	if header.Height != height {
+		v.addIssue(height, store.CheckHeader, "header height %d", header.Height)
+	}
+	if hash := *header.Hash(); hash != blockHash {
+		v.addIssue(height, store.CheckHeader, "header hash %s, saved under %s", hash.ToHexString(), blockHash.ToHexString())
+	}
+	if height > 0 {
+		if prevHash := s.GetBlockHash(height - 1); header.PrevBlockHash != prevHash {
+			v.addIssue(height, store.CheckPrevHash, "previous block hash %s, expected %s",
+				header.PrevBlockHash.ToHexString(), prevHash.ToHexString())
+		}
+		if prevHeader != nil && header.SourceHeight <= prevHeader.SourceHeight {
+			v.addIssue(height, store.CheckSourceHeight, "source height %d, previous block source height %d",
+				header.SourceHeight, prevHeader.SourceHeight)
+		}
+		v.verifySignature(height, header)
+	}
+	block := &types.Block{Header: header}
+	if !s.blockStore.isPruned(height) {
+		block = v.verifyTransactions(height, header, txHashes)
+	}
+	v.verifyBlockTree(height, header.PrevBlockHash)
+	v.verifyStateRoot(height, block)
+	return header
+}
+
+// verifySignature check header signature against the epoch in force at the previous block
+func (v *chainVerifier) verifySignature(height uint64, header *types.Header) {
+	s := v.s
+	if s.GetQuorumThreshold() == 0 {
+		return
+	}
+	if err := s.verifyHeaderSignature(header); err != nil {
+		v.addIssue(height, store.CheckSignature, "%s", err)
+	}
+}
+
+// verifyTransactions load block transactions and check them against header transactions root.
+// Return the block with loaded transactions
+func (v *chainVerifier) verifyTransactions(height uint64, header *types.Header, txHashes []common.Uint256) *types.Block {
+	txs := make(types.Transactions, 0, len(txHashes))
+	for _, txHash := range txHashes {
+		tx, _, err := v.s.blockStore.GetTransaction(txHash)
+		if err != nil {
+			v.addIssue(height, store.CheckTxRoot, "transaction %s error %s", txHash.ToHexString(), err)
+			return &types.Block{Header: header}
+		}
+		transaction := types.ToTransaction(tx)
+		if hash := transaction.Hash(); hash != txHash {
+			v.addIssue(height, store.CheckTxRoot, "transaction %s saved under %s", hash.ToHexString(), txHash.ToHexString())
+			return &types.Block{Header: header}
+		}
+		txs = append(txs, transaction)
+	}
+	// VerifyIntegrity rebuilds transactions root of the header, so saved header is kept untouched
+	headerCopy := *header
+	block := &types.Block{Header: &headerCopy, Transactions: txs}
+	if err := block.VerifyIntegrity(); err != nil {
+		v.addIssue(height, store.CheckTxRoot, "%s", err)
+	}
+	v.report.Blocks++
+	return block
+}
+
+// verifyBlockTree append previous block hash to rebuilt block merkle tree and compare its root
+// with the root of merkle tree file of the same size
+func (v *chainVerifier) verifyBlockTree(height uint64, prevHash common.Uint256) {
+	v.blockTree.Append(prevHash.ToArray())
+	hashes, err := v.s.stateStore.merkleTree.CompactHashes(height + 1)
+	if err != nil {
+		v.addIssue(height, store.CheckBlockMerkle, "merkle tree file error %s", err)
+		return
+	}
+	root, rebuilt := merkle.NewTree(height+1, hashes, nil).Root(), v.blockTree.Root()
+	if root != rebuilt {
+		v.addIssue(height, store.CheckBlockMerkle, "merkle tree file root %s, rebuilt %s", root.ToHexString(), rebuilt.ToHexString())
+	}
+}
+
+// verifyStateRoot recompute block write set and compare it and the rebuilt state merkle root with saved ones
+func (v *chainVerifier) verifyStateRoot(height uint64, block *types.Block) {
+	s := v.s
+	if height < s.stateStore.stateHashCheckHeight {
+		return
+	}
+	writeSetHash, root, err := s.stateStore.getStateMerkleRoots(height)
+	if err == scom.ErrNotFound && s.blockStore.isPruned(height) {
+		// state roots below snapshot height are not saved
+		v.stateTree = nil
+		return
+	}
+	if err != nil {
+		v.addIssue(height, store.CheckStateRoot, "state merkle root error %s", err)
+		v.stateTree = nil
+		return
+	}
+	result, err := s.executeBlock(block)
+	if err != nil {
+		v.addIssue(height, store.CheckStateRoot, "execute block error %s", err)
+	} else if result.Hash != writeSetHash {
+		v.addIssue(height, store.CheckStateRoot, "write set hash %s, recomputed %s",
+			writeSetHash.ToHexString(), result.Hash.ToHexString())
+	}
+	if v.stateTree == nil {
+		return
+	}
+	v.stateTree.Append(writeSetHash.ToArray())
+	if rebuilt := v.stateTree.Root(); rebuilt != root {
+		v.addIssue(height, store.CheckStateRoot, "state merkle root %s, rebuilt %s", root.ToHexString(), rebuilt.ToHexString())
+	}
+}
+
+// verifySavedTrees compare rebuilt block and state merkle trees with compact trees saved at current height
+func (v *chainVerifier) verifySavedTrees(height uint64) {
+	s := v.s
+	treeSize, hashes, err := s.stateStore.GetBlockMerkleTree()
+	if err != nil {
+		v.addIssue(height, store.CheckBlockMerkle, "saved block merkle tree error %s", err)
+	} else {
+		v.compareTrees(height, store.CheckBlockMerkle, "block", merkle.NewTree(treeSize, hashes, nil), v.blockTree)
+	}
+	if v.stateTree == nil || height < s.stateStore.stateHashCheckHeight {
+		return
+	}
+	treeSize, hashes, err = s.stateStore.GetStateMerkleTree()
+	if err != nil {
+		v.addIssue(height, store.CheckStateRoot, "saved state merkle tree error %s", err)
+	} else {
+		v.compareTrees(height, store.CheckStateRoot, "state", merkle.NewTree(treeSize, hashes, nil), v.stateTree)
+	}
+}
+
+func (v *chainVerifier) compareTrees(height uint64, check, name string, saved, rebuilt *merkle.CompactMerkleTree) {
+	savedRoot, rebuiltRoot := saved.Root(), rebuilt.Root()
+	if saved.TreeSize() != rebuilt.TreeSize() || savedRoot != rebuiltRoot {
+		v.addIssue(height, check, "saved %s merkle root %s of size %d, rebuilt %s of size %d",
+			name, savedRoot.ToHexString(), saved.TreeSize(), rebuiltRoot.ToHexString(), rebuilt.TreeSize())
+	}
+}
+
+// verifyRequestIndex check request id entries of requests last changed by transaction from height to height
+// point to saved transaction of the request. Entries pointing to missing transaction are reported at height 0
+func (v *chainVerifier) verifyRequestIndex(from, to uint64) {
+	blockStore := v.s.blockStore
+	iter := blockStore.store.NewIterator([]byte{byte(scom.DATA_REQUEST_ID)})
+	defer iter.Release()
+	for iter.Next() {
+		key, value := iter.Key(), iter.Value()
+		// records saved before open requests index keep state + transaction hash layout
+		if len(key) != 33 || len(value) != 1+common.UINT256_SIZE && len(value) != requestRecordSize {
+			v.addRequestIssue(0, key, "invalid entry size %d", len(value))
+			continue
+		}
+		var reqId [32]byte
+		copy(reqId[:], key[1:])
+		var txHash common.Uint256
+		copy(txHash[:], value[1:1+common.UINT256_SIZE])
+		tx, height, err := blockStore.GetTransaction(txHash)
+		if err != nil && err != scom.ErrPruned {
+			v.addRequestIssue(0, key, "transaction %s error %s", txHash.ToHexString(), err)
+			continue
+		}
+		if height < from || height > to {
+			continue
+		}
+		v.report.Requests++
+		if err == nil && tx.RequestId() != reqId {
+			v.addRequestIssue(height, key, "transaction %s of request %x", txHash.ToHexString(), tx.RequestId())
+		}
+	}
+	if err := iter.Error(); err != nil {
+		v.addIssue(to, store.CheckRequestIndex, "iterator error %s", err)
+	}
+}
+
+func (v *chainVerifier) addRequestIssue(height uint64, key []byte, format string, args ...interface{}) {
+	v.addIssue(height, store.CheckRequestIndex, format, args...)
+	v.report.Issues[len(v.report.Issues)-1].Key = hex.EncodeToString(key)
+}
@@ -0,0 +1,104 @@
+package ledgerstore
+
+import (
+	"testing"
+
+	ethCommon "github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eywa-protocol/chain/common"
+	"github.com/eywa-protocol/chain/core/store"
+	"github.com/eywa-protocol/chain/core/types"
+)
+
+func TestVerifyChain(t *testing.T) {
+	ledgerStore := openTestLedgerStore(t, t.TempDir(), StoreConfig{})
+	defer ledgerStore.Close()
+	bridge := ethCommon.HexToAddress("0x0c760E9A85d2E957Dd1E189516b6658CfEcD3985")
+	block1 := submitTestBlock(t, ledgerStore, 11, newIndexTestRequest([32]byte{1}, 94, bridge, bridge))
+	block2 := submitTestBlock(t, ledgerStore, 12, types.Transactions{})
+	submitTestBlock(t, ledgerStore, 13, newIndexTestRequest([32]byte{2}, 95, bridge, bridge))
+
+	_, err := ledgerStore.VerifyChain(2, 1)
+	require.Error(t, err)
+	_, err = ledgerStore.VerifyChain(0, 4)
+	require.Error(t, err)
+	report, err := ledgerStore.VerifyChain(0, 3)
+	require.NoError(t, err)
+	require.Empty(t, report.Issues)
+	require.Equal(t, uint64(4), report.Headers)
+	require.Equal(t, uint64(4), report.Blocks)
+	require.Equal(t, uint64(2), report.Requests)
+	report, err = ledgerStore.VerifyChain(2, 3)
+	require.NoError(t, err)
+	require.Empty(t, report.Issues)
+	require.Equal(t, uint64(2), report.Headers)
+	require.Equal(t, uint64(1), report.Requests)
+
+	// header of block 2 is replaced with one of lower source height
+	blockStore := ledgerStore.blockStore
+	header, txHashes, err := blockStore.loadHeaderWithTx(block2.Hash())
+	require.NoError(t, err)
+	header.SourceHeight = 5
+	sink := common.NewZeroCopySink(nil)
+	require.NoError(t, header.Serialization(sink))
+	sink.WriteUint32(uint32(len(txHashes)))
+	require.NoError(t, blockStore.store.Put(blockStore.getHeaderKey(block2.Hash()), sink.Bytes()))
+	// request 2 entry points to transaction of request 1
+	txHash := block1.Transactions[0].Hash()
+	value := append([]byte{1}, txHash[:]...)
+	require.NoError(t, blockStore.store.Put(blockStore.getRequestIdKey([32]byte{2}), value))
+	// state merkle root of block 1 is changed
+	stateStore := ledgerStore.stateStore
+	rootKey := stateStore.genStateMerkleRootKey(1)
+	root, err := stateStore.store.Get(rootKey)
+	require.NoError(t, err)
+	root[len(root)-1] ^= 0xff
+	require.NoError(t, stateStore.store.Put(rootKey, root))
+	// blocks are not signed
+	ledgerStore.SetQuorumThreshold(DefaultQuorumThreshold)
+
+	report, err = ledgerStore.VerifyChain(0, 3)
+	require.NoError(t, err)
+	found := make(map[string][]uint64)
+	for _, issue := range report.Issues {
+		found[issue.Check] = append(found[issue.Check], issue.Height)
+	}
+	require.Equal(t, map[string][]uint64{
+		store.CheckHeader:       {2},
+		store.CheckSourceHeight: {2},
+		store.CheckSignature:    {1, 2, 3},
+		store.CheckStateRoot:    {1},
+		store.CheckRequestIndex: {1},
+	}, found)
+}
+
+func TestVerifyRequestRecords(t *testing.T) {
+	ledgerStore := openTestLedgerStore(t, t.TempDir(), StoreConfig{})
+	defer ledgerStore.Close()
+	bridge := ethCommon.HexToAddress("0x0c760E9A85d2E957Dd1E189516b6658CfEcD3985")
+	block := submitTestBlock(t, ledgerStore, 11, newIndexTestRequest([32]byte{1}, 94, bridge, bridge))
+	blockStore := ledgerStore.blockStore
+	value, err := blockStore.store.Get(blockStore.getRequestIdKey([32]byte{1}))
+	require.NoError(t, err)
+	require.Len(t, value, requestRecordSize)
+	report, err := ledgerStore.VerifyChain(0, 1)
+	require.NoError(t, err)
+	require.Empty(t, report.Issues)
+	require.Equal(t, uint64(1), report.Requests)
+
+	// record saved before open requests index
+	txHash := block.Transactions[0].Hash()
+	require.NoError(t, blockStore.store.Put(blockStore.getRequestIdKey([32]byte{1}), append([]byte{1}, txHash[:]...)))
+	report, err = ledgerStore.VerifyChain(0, 1)
+	require.NoError(t, err)
+	require.Empty(t, report.Issues)
+	require.Equal(t, uint64(1), report.Requests)
+
+	require.NoError(t, blockStore.store.Put(blockStore.getRequestIdKey([32]byte{2}), value[:40]))
+	report, err = ledgerStore.VerifyChain(0, 1)
+	require.NoError(t, err)
+	require.Len(t, report.Issues, 1)
+	require.Equal(t, store.CheckRequestIndex, report.Issues[0].Check)
+	require.Equal(t, uint64(0), report.Issues[0].Height)
+}
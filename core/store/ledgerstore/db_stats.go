@@ -0,0 +1,49 @@
+package ledgerstore
+
+import (
+	"fmt"
+
+	"github.com/eywa-protocol/chain/core/store"
+	scom "github.com/eywa-protocol/chain/core/store/common"
+)
+
+// GetDBStats return count and size of block, state and event store entries grouped by key prefix
+func (s *LedgerStoreImp) GetDBStats() ([]*store.PrefixStats, error) {
+	stores := []struct {
+		name  string
+		store scom.PersistStore
+	}{
+		{DBDirBlock, s.blockStore.store},
+		{DBDirState, s.stateStore.store},
+		{DBDirEvent, s.eventStore.store},
+	}
+	var result []*store.PrefixStats
+	for _, st := range stores {
+		stats, err := getPrefixStats(st.name, st.store)
+		if err != nil {
+			return nil, fmt.Errorf("%s store stats error %s", st.name, err)
+		}
+		result = append(result, stats...)
+	}
+	return result, nil
+}
+
+// getPrefixStats iterate all store entries and return stats ordered by prefix
+func getPrefixStats(name string, persistStore scom.PersistStore) ([]*store.PrefixStats, error) {
+	var result []*store.PrefixStats
+	iter := persistStore.NewIterator(nil)
+	defer iter.Release()
+	for iter.Next() {
+		key := iter.Key()
+		if len(key) == 0 {
+			continue
+		}
+		if len(result) == 0 || result[len(result)-1].Prefix != key[0] {
+			result = append(result, &store.PrefixStats{Store: name, Prefix: key[0]})
+		}
+		stats := result[len(result)-1]
+		stats.Keys++
+		stats.Size += uint64(len(key) + len(iter.Value()))
+	}
+	return result, iter.Error()
+}
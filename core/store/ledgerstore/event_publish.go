@@ -0,0 +1,65 @@
+package ledgerstore
+
+import (
+	"fmt"
+
+	"github.com/eywa-protocol/chain/core/events"
+	scom "github.com/eywa-protocol/chain/core/store/common"
+	"github.com/eywa-protocol/chain/core/types"
+)
+
+// GetEventHub return hub publishing events of committed blocks
+func (s *LedgerStoreImp) GetEventHub() *events.Hub {
+	return s.eventHub
+}
+
+// GetBlockEvents return events of committed block in the order they are published
+func (s *LedgerStoreImp) GetBlockEvents(height uint64) ([]*events.Event, error) {
+	block, err := s.GetBlockByHeight(height)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, scom.ErrNotFound
+	}
+	changes, err := s.blockStore.getRequestChanges(block)
+	if err != nil {
+		return nil, fmt.Errorf("getRequestChanges height %d error %s", height, err)
+	}
+	return newBlockEvents(block, changes), nil
+}
+
+// publishBlock publish events of committed block
+func (s *LedgerStoreImp) publishBlock(block *types.Block) {
+	s.eventHub.Publish(newBlockEvents(block, s.blockStore.changes)...)
+}
+
+// newBlockEvents return header, block, transactions and request state changes events of the block
+func newBlockEvents(block *types.Block, changes []*requestChange) []*events.Event {
+	header := block.Header
+	height := header.Height
+	blockEvents := make([]*events.Event, 0, 2+len(block.Transactions)+len(changes))
+	blockEvents = append(blockEvents,
+		&events.Event{Type: events.EventHeader, Height: height, Header: header},
+		&events.Event{Type: events.EventBlock, Height: height, Header: header, Block: block},
+	)
+	for _, tx := range block.Transactions {
+		blockEvents = append(blockEvents, &events.Event{
+			Type:   events.EventTransaction,
+			Height: height,
+			Header: header,
+			Tx:     tx.Payload,
+			TxHash: tx.Hash(),
+		})
+	}
+	for _, change := range changes {
+		blockEvents = append(blockEvents, &events.Event{
+			Type:         events.EventRequestState,
+			Height:       height,
+			Header:       header,
+			RequestState: change.entry,
+			DstChainId:   change.dstChainId,
+		})
+	}
+	return blockEvents
+}
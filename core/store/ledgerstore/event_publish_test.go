@@ -0,0 +1,58 @@
+package ledgerstore
+
+import (
+	"testing"
+
+	ethCommon "github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eywa-protocol/chain/common"
+	"github.com/eywa-protocol/chain/core/events"
+	"github.com/eywa-protocol/chain/core/payload"
+	"github.com/eywa-protocol/chain/core/types"
+)
+
+func TestPublishBlock(t *testing.T) {
+	ledgerStore, err := NewLedgerStore("test/publish")
+	require.NoError(t, err)
+	defer ledgerStore.Close()
+
+	genesisBlock := types.NewBlock(0, common.UINT256_EMPTY, common.UINT256_EMPTY, 10, 0, types.Transactions{})
+	require.NoError(t, ledgerStore.InitLedgerStoreWithGenesisBlock(genesisBlock))
+
+	hub := ledgerStore.GetEventHub()
+	all := hub.Subscribe(events.Filter{}, 0, events.OverflowDisconnect)
+	requests := hub.Subscribe(events.Filter{
+		Types:       []events.EventType{events.EventRequestState},
+		DstChainIds: []uint64{95},
+	}, 0, events.OverflowDisconnect)
+	defer all.Unsubscribe()
+	defer requests.Unsubscribe()
+
+	bridge := ethCommon.HexToAddress("0x0c760E9A85d2E957Dd1E189516b6658CfEcD3985")
+	reqId1, reqId2 := [32]byte{1}, [32]byte{2}
+	block := submitTestBlock(t, ledgerStore, 11, append(newIndexTestRequest(reqId1, 94, bridge, bridge),
+		newIndexTestRequest(reqId2, 95, bridge, bridge)...))
+
+	expected := []events.EventType{
+		events.EventHeader, events.EventBlock,
+		events.EventTransaction, events.EventTransaction,
+		events.EventRequestState, events.EventRequestState,
+	}
+	for i, eventType := range expected {
+		e := <-all.Events()
+		require.Equal(t, eventType, e.Type, "event %d", i)
+		require.Equal(t, uint64(1), e.Height)
+		require.Equal(t, block.Hash(), e.Header.Hash())
+		if eventType == events.EventTransaction {
+			require.Equal(t, block.Transactions[i-2].Hash(), e.TxHash)
+		}
+	}
+
+	e := <-requests.Events()
+	require.Equal(t, reqId2, e.RequestState.RequestId)
+	require.Equal(t, payload.ReqStateUnknown, e.RequestState.PrevState)
+	require.Equal(t, payload.ReqStateReceived, e.RequestState.State)
+	require.Equal(t, uint64(95), e.DstChainId)
+	require.Empty(t, requests.Events())
+}
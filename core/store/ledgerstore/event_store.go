@@ -9,27 +9,32 @@ import (
 	"github.com/eywa-protocol/chain/common"
 	"github.com/eywa-protocol/chain/common/serialization"
 	scom "github.com/eywa-protocol/chain/core/store/common"
-	"github.com/eywa-protocol/chain/core/store/leveldbstore"
+	"github.com/eywa-protocol/chain/core/types"
 	"github.com/eywa-protocol/chain/native/event"
 	"github.com/sirupsen/logrus"
 )
 
 // EventStore saving event notifies gen by smart contract execution
 type EventStore struct {
-	dbDir string                     // Store path
-	store *leveldbstore.LevelDBStore // Store handler
+	dbDir string            // Store path
+	store scom.PersistStore // Store handler
 }
 
-// NewEventStore return event store instance
-func NewEventStore(dbDir string) (*EventStore, error) {
-	store, err := leveldbstore.NewLevelDBStore(dbDir)
+// NewEventStore return event store instance opened with the backend, empty backend means default one
+func NewEventStore(backend, dbDir string) (*EventStore, error) {
+	store, err := scom.OpenPersistStore(backend, dbDir)
 	if err != nil {
 		return nil, err
 	}
+	return newEventStore(store, dbDir), nil
+}
+
+// newEventStore return event store instance over opened store
+func newEventStore(store scom.PersistStore, dbDir string) *EventStore {
 	return &EventStore{
 		dbDir: dbDir,
 		store: store,
-	}, nil
+	}
 }
 
 // NewBatch start event commit batch
@@ -141,6 +146,25 @@ func (s *EventStore) ClearAll() error {
 	return s.CommitTo()
 }
 
+// RollbackTo remove event notifies of blocks above height and set the block specified by height and block hash as current
+func (s *EventStore) RollbackTo(height uint64, blockHash common.Uint256, blocks []*types.Block) error {
+	s.NewBatch()
+	for _, block := range blocks {
+		key, err := s.getEventNotifyByBlockKey(block.Header.Height)
+		if err != nil {
+			return err
+		}
+		s.store.BatchDelete(key)
+		for _, tx := range block.Transactions {
+			s.store.BatchDelete(s.getEventNotifyByTxKey(tx.Hash()))
+		}
+	}
+	if err := s.SaveCurrentBlock(height, blockHash); err != nil {
+		return err
+	}
+	return s.CommitTo()
+}
+
 // SaveCurrentBlock persist current block height and block hash to event store
 func (s *EventStore) SaveCurrentBlock(height uint64, blockHash common.Uint256) error {
 	key := s.getCurrentBlockKey()
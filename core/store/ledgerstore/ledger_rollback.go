@@ -0,0 +1,115 @@
+package ledgerstore
+
+import (
+	"fmt"
+
+	"github.com/eywa-protocol/chain/common"
+	scom "github.com/eywa-protocol/chain/core/store/common"
+	"github.com/eywa-protocol/chain/core/types"
+	"github.com/sirupsen/logrus"
+)
+
+// RollbackTo revert ledger to the block height. Blocks above height are removed with their transactions,
+// request id indexes, event notifies, state merkle roots, cross states and epochs.
+// Block merkle tree is truncated and the current block, header index and processed height are restored.
+// Rollback height is saved before stores are reverted in order event, state, block, and it is removed with
+// the block store batch, so rollback interrupted between store commits is finished by recoverStore on restart.
+// Stores kept in single database are reverted in one batch.
+func (s *LedgerStoreImp) RollbackTo(height uint64) error {
+	s.getSavingBlockLock()
+	defer s.releaseSavingBlockLock()
+
+	currHeight := s.GetCurrentBlockHeight()
+	if height == currHeight {
+		return nil
+	}
+	if height > currHeight {
+		return fmt.Errorf("rollback height %d above current block height %d", height, currHeight)
+	}
+	if prunedHeight := s.blockStore.GetPrunedHeight(); height+1 < prunedHeight {
+		return fmt.Errorf("rollback height %d below pruned height %d: %s", height, prunedHeight, scom.ErrPruned)
+	}
+	// stores kept in single database are reverted in one batch, so there is nothing to finish
+	if s.sharedStore == nil {
+		if err := s.blockStore.SaveRollbackHeight(height); err != nil {
+			return fmt.Errorf("blockStore.SaveRollbackHeight height:%d error %s", height, err)
+		}
+	}
+	return s.rollbackTo(height)
+}
+
+// rollbackTo revert stores from the current block of block store to the height
+func (s *LedgerStoreImp) rollbackTo(height uint64) error {
+	currHeight := s.GetCurrentBlockHeight()
+	blockHash, err := s.blockStore.GetBlockHash(height)
+	if err != nil {
+		return fmt.Errorf("blockStore.GetBlockHash height:%d error:%w", height, err)
+	}
+	header, err := s.blockStore.GetHeader(blockHash)
+	if err != nil {
+		return fmt.Errorf("blockStore.GetHeader height:%d error:%w", height, err)
+	}
+
+	blocks := make([]*types.Block, 0, currHeight-height)
+	for h := currHeight; h > height; h-- {
+		hash, err := s.blockStore.GetBlockHash(h)
+		if err != nil {
+			return fmt.Errorf("blockStore.GetBlockHash height:%d error:%w", h, err)
+		}
+		block, err := s.blockStore.GetBlock(hash)
+		if err != nil {
+			return fmt.Errorf("blockStore.GetBlock height:%d error:%w", h, err)
+		}
+		blocks = append(blocks, block)
+	}
+
+	s.beginAtomicCommit()
+	err = s.eventStore.RollbackTo(height, blockHash, blocks)
+	if err != nil {
+		return s.endAtomicCommit(fmt.Errorf("eventStore.RollbackTo height:%d error %s", height, err))
+	}
+	err = s.stateStore.RollbackTo(height, currHeight, blockHash, header.SourceHeight)
+	if err != nil {
+		return s.endAtomicCommit(fmt.Errorf("stateStore.RollbackTo height:%d error %s", height, err))
+	}
+
+	s.lock.RLock()
+	storedIndexCount := s.storedIndexCount
+	s.lock.RUnlock()
+	indexCount := (height + 1) / HEADER_INDEX_BATCH_SIZE * HEADER_INDEX_BATCH_SIZE
+	if indexCount > storedIndexCount {
+		indexCount = storedIndexCount
+	}
+	err = s.blockStore.RollbackTo(height, blockHash, blocks, indexCount, storedIndexCount)
+	if err != nil {
+		return s.endAtomicCommit(fmt.Errorf("blockStore.RollbackTo height:%d error %s", height, err))
+	}
+	if err = s.endAtomicCommit(nil); err != nil {
+		return fmt.Errorf("commit rollback height:%d error %s", height, err)
+	}
+	if err = s.stateStore.truncateMerkleTrees(height); err != nil {
+		return fmt.Errorf("stateStore.truncateMerkleTrees height:%d error %s", height, err)
+	}
+
+	s.lock.Lock()
+	for h := range s.headerIndex {
+		if h > height {
+			delete(s.headerIndex, h)
+		}
+	}
+	s.headerCache = make(map[common.Uint256]*types.Header)
+	s.storedIndexCount = indexCount
+	s.currBlockHeight = height
+	s.currBlockHash = blockHash
+	s.processedHeight = header.SourceHeight
+	s.lock.Unlock()
+
+	logrus.WithFields(logrus.Fields{
+		"chain_id":         s.chainId,
+		"height":           height,
+		"prev_height":      currHeight,
+		"block_hash":       blockHash.ToHexString(),
+		"processed_height": header.SourceHeight,
+	}).Warnf("Ledger rolled back.")
+	return nil
+}
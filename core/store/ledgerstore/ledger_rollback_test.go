@@ -0,0 +1,127 @@
+package ledgerstore
+
+import (
+	"math/big"
+	"testing"
+
+	ethCommon "github.com/ethereum/go-ethereum/common"
+	"github.com/eywa-protocol/wrappers"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eywa-protocol/chain/common"
+	"github.com/eywa-protocol/chain/core/payload"
+	scom "github.com/eywa-protocol/chain/core/store/common"
+	"github.com/eywa-protocol/chain/core/types"
+)
+
+func submitTestBlock(t *testing.T, ledgerStore *LedgerStoreImp, sourceHeight uint64, txs types.Transactions) *types.Block {
+	block := types.NewBlock(0, ledgerStore.GetCurrentBlockHash(), common.UINT256_EMPTY, sourceHeight, ledgerStore.GetCurrentBlockHeight()+1, txs)
+	result, err := ledgerStore.ExecuteBlock(block)
+	require.NoError(t, err)
+	require.NoError(t, ledgerStore.SubmitBlock(block, result))
+	return block
+}
+
+func TestRollbackTo(t *testing.T) {
+	ledgerStore, err := NewLedgerStore("test/rollback")
+	require.NoError(t, err)
+	defer ledgerStore.Close()
+
+	genesisBlock := types.NewBlock(0, common.UINT256_EMPTY, common.UINT256_EMPTY, 10, 0, types.Transactions{})
+	require.NoError(t, ledgerStore.InitLedgerStoreWithGenesisBlock(genesisBlock))
+
+	reqId := [32]byte{1, 2, 3}
+	received := &payload.BridgeEvent{
+		OriginData: wrappers.BridgeOracleRequest{
+			RequestType: "setRequest",
+			Bridge:      ethCommon.HexToAddress("0x0c760E9A85d2E957Dd1E189516b6658CfEcD3985"),
+			RequestId:   reqId,
+			ChainId:     big.NewInt(94),
+		}}
+	sent := &payload.ReceiveRequestEvent{
+		OriginData: wrappers.BridgeReceiveRequest{
+			ReqId: reqId,
+		}}
+
+	block1 := submitTestBlock(t, ledgerStore, 11, types.Transactions{})
+	block2 := submitTestBlock(t, ledgerStore, 12, types.Transactions{types.ToTransaction(received)})
+	block3 := submitTestBlock(t, ledgerStore, 13, types.Transactions{types.ToTransaction(sent)})
+	ledgerStore.SetProcessedHeight(20)
+
+	state, err := ledgerStore.GetRequestState(reqId)
+	require.NoError(t, err)
+	require.Equal(t, payload.ReqStateSent, state)
+
+	require.Error(t, ledgerStore.RollbackTo(4))
+	require.NoError(t, ledgerStore.RollbackTo(2))
+	require.Equal(t, uint64(2), ledgerStore.GetCurrentBlockHeight())
+	require.Equal(t, block2.Hash(), ledgerStore.GetCurrentBlockHash())
+	require.Equal(t, uint64(2), ledgerStore.GetCurrentHeaderHeight())
+	require.Equal(t, uint64(12), ledgerStore.GetProcessedHeight())
+
+	exist, err := ledgerStore.IsContainBlock(block3.Hash())
+	require.NoError(t, err)
+	require.False(t, exist)
+	sentTx := types.ToTransaction(sent)
+	exist, err = ledgerStore.IsContainTransaction(sentTx.Hash())
+	require.NoError(t, err)
+	require.False(t, exist)
+	state, err = ledgerStore.GetRequestState(reqId)
+	require.NoError(t, err)
+	require.Equal(t, payload.ReqStateReceived, state)
+	history, err := ledgerStore.GetRequestHistory(reqId)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	require.Equal(t, uint64(2), history[0].Height)
+
+	// merkle tree must stay consistent after rollback
+	block3 = submitTestBlock(t, ledgerStore, 14, types.Transactions{})
+	block2Hash := block2.Hash()
+	proof, err := ledgerStore.GetMerkleProof(block2Hash.ToArray(), 3, 3)
+	require.NoError(t, err)
+	require.NotEmpty(t, proof)
+
+	require.NoError(t, ledgerStore.RollbackTo(0))
+	require.Equal(t, genesisBlock.Hash(), ledgerStore.GetCurrentBlockHash())
+	_, err = ledgerStore.GetRequestState(reqId)
+	require.Equal(t, scom.ErrNotFound, err)
+	history, err = ledgerStore.GetRequestHistory(reqId)
+	require.NoError(t, err)
+	require.Empty(t, history)
+	exist, err = ledgerStore.IsContainBlock(block1.Hash())
+	require.NoError(t, err)
+	require.False(t, exist)
+
+	submitTestBlock(t, ledgerStore, 11, types.Transactions{})
+	require.Equal(t, uint64(1), ledgerStore.GetCurrentBlockHeight())
+}
+
+func TestRollbackRecover(t *testing.T) {
+	dataDir := t.TempDir()
+	ledgerStore := openTestLedgerStore(t, dataDir, StoreConfig{})
+	block1 := submitTestBlock(t, ledgerStore, 11, types.Transactions{})
+	block2 := submitTestBlock(t, ledgerStore, 12, types.Transactions{})
+	block3 := submitTestBlock(t, ledgerStore, 13, types.Transactions{})
+
+	// rollback interrupted after event and state stores are reverted
+	require.NoError(t, ledgerStore.blockStore.SaveRollbackHeight(1))
+	require.NoError(t, ledgerStore.eventStore.RollbackTo(1, block1.Hash(), []*types.Block{block3, block2}))
+	require.NoError(t, ledgerStore.stateStore.RollbackTo(1, 3, block1.Hash(), 11))
+	require.NoError(t, ledgerStore.Close())
+
+	ledgerStore = openTestLedgerStore(t, dataDir, StoreConfig{})
+	defer ledgerStore.Close()
+	require.Equal(t, uint64(1), ledgerStore.GetCurrentBlockHeight())
+	require.Equal(t, block1.Hash(), ledgerStore.GetCurrentBlockHash())
+	_, stateHeight, err := ledgerStore.stateStore.GetCurrentBlock()
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), stateHeight)
+	exist, err := ledgerStore.IsContainBlock(block2.Hash())
+	require.NoError(t, err)
+	require.False(t, exist)
+	_, err = ledgerStore.blockStore.GetRollbackHeight()
+	require.Equal(t, scom.ErrNotFound, err)
+
+	block2 = submitTestBlock(t, ledgerStore, 12, types.Transactions{})
+	require.Equal(t, block2.Hash(), ledgerStore.GetCurrentBlockHash())
+}
@@ -7,9 +7,11 @@ import (
 	"sort"
 	"sync"
 
+	"github.com/eywa-protocol/chain/core/events"
 	"github.com/eywa-protocol/chain/core/payload"
 	"github.com/eywa-protocol/chain/core/states"
 	scom "github.com/eywa-protocol/chain/core/store/common"
+	"github.com/eywa-protocol/chain/core/store/sharedstore"
 	"github.com/eywa-protocol/chain/native"
 
 	"github.com/eywa-protocol/chain/common"
@@ -26,6 +28,8 @@ import (
 const (
 	SYSTEM_VERSION          = byte(1)      // Version of ledger store
 	HEADER_INDEX_BATCH_SIZE = uint64(2000) // Bath size of saving header index
+
+	DefaultQuorumThreshold = uint64(67) // Percent of epoch participants nodes require to sign block header unless chain params fix it
 )
 
 var (
@@ -33,6 +37,7 @@ var (
 	DBDirEvent          = "ledgerevent"
 	DBDirBlock          = "block"
 	DBDirState          = "states"
+	DBDirLedger         = "ledger" // Single database of block, state and event stores
 	MerkleTreeStorePath = "merkle_tree.db"
 )
 
@@ -46,72 +51,98 @@ type LedgerStoreImp struct {
 	currBlockHash        common.Uint256                   // Current block hash
 	processedHeight      uint64                           // Processed source block height
 	chainId              uint64                           // Ledger chain id
+	quorumThreshold      uint64                           // Percent of epoch participants required to sign block header, 0 disables verification until chain params or SetQuorumThreshold set it
+	confirmationDepth    map[uint64]uint64                // Source chain id => count of source blocks required to treat event final
+	requestExpiry        uint64                           // Count of blocks after which undelivered request is expired, 0 disables expiry
+	sharedStore          *sharedstore.SharedStore         // Single database of all stores, nil if stores use separate databases
+	eventHub             *events.Hub                      // Hub publishing committed blocks to subscribers
+	pruneKeepBlocks      uint64                           // Count of recent blocks kept unpruned, 0 disables pruning
+	pruner               *pruner                          // Background pruning of old blocks, nil if pruning is disabled
 	headerCache          map[common.Uint256]*types.Header // BlockHash => Header
 	headerIndex          map[uint64]common.Uint256        // Header index, Mapping header height => block hash
 	savingBlockSemaphore chan bool
 	lock                 sync.RWMutex
 }
 
-// NewLedgerStore return LedgerStoreImp instance
+// NewLedgerStore return LedgerStoreImp instance with default store backends
 func NewLedgerStore(dataDir string) (*LedgerStoreImp, error) {
+	return NewLedgerStoreWithConfig(dataDir, StoreConfig{})
+}
+
+// NewLedgerStoreWithConfig return LedgerStoreImp instance with store backends selected by config
+func NewLedgerStoreWithConfig(dataDir string, config StoreConfig) (*LedgerStoreImp, error) {
 	ledgerStore := &LedgerStoreImp{
 		headerIndex:          make(map[uint64]common.Uint256),
 		headerCache:          make(map[common.Uint256]*types.Header, 0),
 		savingBlockSemaphore: make(chan bool, 1),
+		confirmationDepth:    make(map[uint64]uint64),
+		requestExpiry:        DefaultRequestExpiry,
+		eventHub:             events.NewHub(),
+		pruneKeepBlocks:      config.PruneKeepBlocks,
+	}
+
+	var err error
+	if config.SingleDB {
+		err = ledgerStore.openSingleDB(dataDir, config.BlockBackend)
+	} else {
+		err = ledgerStore.openStores(dataDir, config)
+	}
+	if err != nil {
+		return nil, err
 	}
+	return ledgerStore, nil
+}
 
-	blockStore, err := NewBlockStore(fmt.Sprintf("%s%s%s", dataDir, string(os.PathSeparator), DBDirBlock), true)
+// openStores open block, state and event stores in separate databases
+func (s *LedgerStoreImp) openStores(dataDir string, config StoreConfig) error {
+	blockStore, err := NewBlockStore(config.BlockBackend, fmt.Sprintf("%s%s%s", dataDir, string(os.PathSeparator), DBDirBlock), true)
 	if err != nil {
-		return nil, fmt.Errorf("NewBlockStore error %s", err)
+		return fmt.Errorf("NewBlockStore error %s", err)
 	}
-	ledgerStore.blockStore = blockStore
+	s.blockStore = blockStore
 
 	dbPath := fmt.Sprintf("%s%s%s", dataDir, string(os.PathSeparator), DBDirState)
 	merklePath := fmt.Sprintf("%s%s%s", dataDir, string(os.PathSeparator), MerkleTreeStorePath)
-	stateStore, err := NewStateStore(dbPath, merklePath)
+	stateStore, err := NewStateStore(config.StateBackend, dbPath, merklePath)
 	if err != nil {
-		return nil, fmt.Errorf("NewStateStore error %s", err)
+		return fmt.Errorf("NewStateStore error %s", err)
 	}
-	ledgerStore.stateStore = stateStore
+	s.stateStore = stateStore
 
-	eventState, err := NewEventStore(fmt.Sprintf("%s%s%s", dataDir, string(os.PathSeparator), DBDirEvent))
+	eventState, err := NewEventStore(config.EventBackend, fmt.Sprintf("%s%s%s", dataDir, string(os.PathSeparator), DBDirEvent))
 	if err != nil {
-		return nil, fmt.Errorf("NewEventStore error %s", err)
+		return fmt.Errorf("NewEventStore error %s", err)
 	}
-	ledgerStore.eventStore = eventState
+	s.eventStore = eventState
 
-	return ledgerStore, nil
+	return nil
 }
 
 // InitLedgerStoreWithGenesisBlock init the ledger store with genesis block. It's the first operation after NewLedgerStore.
 func (s *LedgerStoreImp) InitLedgerStoreWithGenesisBlock(genesisBlock *types.Block) error {
+	return s.InitLedgerStoreWithChainParams(genesisBlock, nil)
+}
+
+// InitLedgerStoreWithChainParams init the ledger store with genesis block and chain parameters saved with it.
+// Parameters hash must be the source transaction of genesis epoch event if genesis block includes one.
+// Parameters are loaded from store on every start, so all nodes of the chain apply the same ones.
+// Ledger initialized before keeps parameters saved at its genesis
+func (s *LedgerStoreImp) InitLedgerStoreWithChainParams(genesisBlock *types.Block, params *states.ChainParams) error {
 	hasInit, err := s.hasAlreadyInitGenesisBlock()
 	if err != nil {
 		return fmt.Errorf("hasAlreadyInit error %s", err)
 	}
 	if !hasInit {
-		err = s.blockStore.ClearAll()
-		if err != nil {
-			return fmt.Errorf("blockStore.ClearAll error %s", err)
+		if params != nil {
+			if err = checkChainParams(genesisBlock, params); err != nil {
+				return err
+			}
 		}
-		err = s.stateStore.ClearAll()
+		err = s.clearStores()
 		if err != nil {
-			return fmt.Errorf("stateStore.ClearAll error %s", err)
-		}
-		err = s.eventStore.ClearAll()
-		if err != nil {
-			return fmt.Errorf("eventStore.ClearAll error %s", err)
+			return err
 		}
 
-		// bookkeeperState := &states.EpochState{
-		// 	CurrEpoch: defaultEpoch,
-		// 	NextEpoch: defaultEpoch,
-		// }
-		// err = this.stateStore.SaveEpochState(bookkeeperState)
-		// if err != nil {
-		// 	return fmt.Errorf("SaveEpochState error %s", err)
-		// }
-
 		result, err := s.executeBlock(genesisBlock)
 		if err != nil {
 			return err
@@ -120,6 +151,18 @@ func (s *LedgerStoreImp) InitLedgerStoreWithGenesisBlock(genesisBlock *types.Blo
 		if err != nil {
 			return fmt.Errorf("save genesis block error %s", err)
 		}
+		if params != nil {
+			if err = s.stateStore.SaveChainParams(params); err != nil {
+				return fmt.Errorf("save chain params error %s", err)
+			}
+			s.applyChainParams(params)
+			if err = s.checkQuorumEpoch(); err != nil {
+				return err
+			}
+		}
+		if err = s.blockStore.SaveRequestIndexHeight(0); err != nil {
+			return fmt.Errorf("save request index height error %s", err)
+		}
 		err = s.initGenesisBlock()
 		if err != nil {
 			return fmt.Errorf("init error %s", err)
@@ -148,10 +191,61 @@ func (s *LedgerStoreImp) InitLedgerStoreWithGenesisBlock(genesisBlock *types.Blo
 			return fmt.Errorf("init error %s", err)
 		}
 	}
+	s.startPruner()
 
 	return err
 }
 
+// checkChainParams check parameters hash is the source transaction of genesis epoch event
+func checkChainParams(genesisBlock *types.Block, params *states.ChainParams) error {
+	for _, tx := range genesisBlock.Transactions {
+		epoch, ok := tx.Payload.(*payload.EpochEvent)
+		if !ok {
+			continue
+		}
+		if hash := params.Hash(); hash != epoch.SourceTx {
+			return fmt.Errorf("chain params hash %s not equal genesis epoch source tx %s",
+				hash.ToHexString(), epoch.SourceTx.ToHexString())
+		}
+	}
+	return nil
+}
+
+// LoadLedgerStore load the ledger store initialized before with genesis block or snapshot.
+// It's used instead of InitLedgerStoreWithGenesisBlock when genesis block is unknown
+func (s *LedgerStoreImp) LoadLedgerStore() error {
+	hasInit, err := s.hasAlreadyInitGenesisBlock()
+	if err != nil {
+		return fmt.Errorf("hasAlreadyInit error %s", err)
+	}
+	if !hasInit {
+		return errors.New("ledger is not initialized")
+	}
+	err = s.init()
+	if err != nil {
+		return fmt.Errorf("init error %s", err)
+	}
+	s.startPruner()
+	return nil
+}
+
+// clearStores remove all data of block, state and event stores
+func (s *LedgerStoreImp) clearStores() error {
+	err := s.blockStore.ClearAll()
+	if err != nil {
+		return fmt.Errorf("blockStore.ClearAll error %s", err)
+	}
+	err = s.stateStore.ClearAll()
+	if err != nil {
+		return fmt.Errorf("stateStore.ClearAll error %s", err)
+	}
+	err = s.eventStore.ClearAll()
+	if err != nil {
+		return fmt.Errorf("eventStore.ClearAll error %s", err)
+	}
+	return nil
+}
+
 func (s *LedgerStoreImp) hasAlreadyInitGenesisBlock() (bool, error) {
 	version, err := s.blockStore.GetVersion()
 	if err != nil && err != scom.ErrNotFound {
@@ -165,7 +259,15 @@ func (s *LedgerStoreImp) initGenesisBlock() error {
 }
 
 func (s *LedgerStoreImp) init() error {
-	err := s.loadCurrentBlock()
+	err := s.loadChainParams()
+	if err != nil {
+		return fmt.Errorf("loadChainParams error: %w", err)
+	}
+	err = s.blockStore.migrateOpenRequests()
+	if err != nil {
+		return fmt.Errorf("migrateOpenRequests error: %w", err)
+	}
+	err = s.loadCurrentBlock()
 	if err != nil {
 		return fmt.Errorf("loadCurrentBlock error: %w", err)
 	}
@@ -177,11 +279,30 @@ func (s *LedgerStoreImp) init() error {
 	if err != nil {
 		return fmt.Errorf("recoverStore error: %w", err)
 	}
+	err = s.blockStore.indexRequests(s.GetCurrentBlockHeight())
+	if err != nil {
+		return fmt.Errorf("indexRequests error: %w", err)
+	}
 	err = s.loadProcessedHeight()
 	if err != nil {
 		return fmt.Errorf("loadProcessedHeight error: %w", err)
 	}
+	return s.checkQuorumEpoch()
+}
 
+// checkQuorumEpoch fail when header signatures are verified but the ledger has no epoch to verify them against.
+// Ledgers initialized before epoch history or from genesis block without epoch event run with zero quorum threshold
+func (s *LedgerStoreImp) checkQuorumEpoch() error {
+	threshold := s.GetQuorumThreshold()
+	if threshold == 0 {
+		return nil
+	}
+	_, err := s.stateStore.GetEpochAtHeight(s.GetCurrentBlockHeight())
+	if err == scom.ErrNotFound {
+		return fmt.Errorf("quorum threshold %d is set but ledger has no epoch to verify block headers", threshold)
+	} else if err != nil {
+		return fmt.Errorf("GetEpochAtHeight error %s", err)
+	}
 	return nil
 }
 
@@ -254,6 +375,16 @@ func (s *LedgerStoreImp) loadProcessedHeight() error {
 }
 
 func (s *LedgerStoreImp) recoverStore() error {
+	// rollback interrupted between store commits is finished, otherwise the blocks it removes are re-applied
+	rollbackHeight, err := s.blockStore.GetRollbackHeight()
+	if err == nil {
+		logrus.Warnf("finish rollback to height %d", rollbackHeight)
+		if err := s.rollbackTo(rollbackHeight); err != nil {
+			return fmt.Errorf("rollbackTo height:%d error:%w", rollbackHeight, err)
+		}
+	} else if err != scom.ErrNotFound {
+		return fmt.Errorf("blockStore.GetRollbackHeight error:%w", err)
+	}
 	blockHeight := s.GetCurrentBlockHeight()
 
 	_, stateHeight, err := s.stateStore.GetCurrentBlock()
@@ -409,9 +540,85 @@ func (s *LedgerStoreImp) verifyHeader(header *types.Header) error {
 		return fmt.Errorf("block source height [%d] missmatch to prev block source [%d]",
 			header.SourceHeight, prevHeader.SourceHeight)
 	}
+	return s.verifyHeaderSignature(header)
+}
+
+// verifyHeaderSignature check header EpochBlockHash names the epoch in force at the previous block and header
+// multisig against that epoch. Header above the next block height is checked against the latest committed epoch,
+// so the check is repeated when the block is submitted
+func (s *LedgerStoreImp) verifyHeaderSignature(header *types.Header) error {
+	threshold := s.GetQuorumThreshold()
+	if threshold == 0 {
+		return nil
+	}
+	if header.EpochBlockHash == common.UINT256_EMPTY {
+		return errors.New("epoch block hash is empty")
+	}
+	epoch, err := s.stateStore.GetEpochAtHeight(header.Height - 1)
+	if err != nil {
+		return fmt.Errorf("get epoch at height %d error %w", header.Height-1, err)
+	}
+	if epoch.BlockHash != header.EpochBlockHash {
+		return fmt.Errorf("epoch block hash %s not equal block hash %s of epoch %d in force",
+			header.EpochBlockHash.ToHexString(), epoch.BlockHash.ToHexString(), epoch.Number)
+	}
+	epochSize := len(epoch.PublicKeys)
+	if epochSize == 0 {
+		return fmt.Errorf("epoch %d has no participants", epoch.Number)
+	}
+	quorum := types.QuorumSize(epochSize, threshold)
+	if err := header.VerifySignature(epoch.EpochPublicKey, epochSize, quorum); err != nil {
+		return fmt.Errorf("epoch %d signature error %w", epoch.Number, err)
+	}
+	return nil
+}
+
+// GetQuorumThreshold return percent of epoch participants required to sign block header
+func (s *LedgerStoreImp) GetQuorumThreshold() uint64 {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.quorumThreshold
+}
+
+// SetQuorumThreshold set percent of epoch participants required to sign block header.
+// Zero threshold disables header signature verification, it's the default unless chain params set one
+func (s *LedgerStoreImp) SetQuorumThreshold(threshold uint64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.quorumThreshold = threshold
+}
+
+// GetChainParams return chain parameters saved at genesis, ErrNotFound if ledger was initialized without them
+func (s *LedgerStoreImp) GetChainParams() (*states.ChainParams, error) {
+	return s.stateStore.GetChainParams()
+}
+
+// loadChainParams apply chain parameters saved at genesis over the parameters set before
+func (s *LedgerStoreImp) loadChainParams() error {
+	params, err := s.stateStore.GetChainParams()
+	if err == scom.ErrNotFound {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	s.applyChainParams(params)
 	return nil
 }
 
+func (s *LedgerStoreImp) applyChainParams(params *states.ChainParams) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if params.QuorumThreshold != nil {
+		s.quorumThreshold = *params.QuorumThreshold
+	}
+	if params.RequestExpiry != nil {
+		s.requestExpiry = *params.RequestExpiry
+	}
+	for chainId, depth := range params.ConfirmationDepths {
+		s.confirmationDepth[chainId] = depth
+	}
+}
+
 // AddHeader add header to cache, and add the mapping of block height to block hash. Using in block sync
 func (s *LedgerStoreImp) AddHeader(header *types.Header) error {
 	nextHeaderHeight := s.GetCurrentHeaderHeight() + 1
@@ -465,6 +672,10 @@ func (s *LedgerStoreImp) ExecuteBlock(block *types.Block) (result store.ExecuteR
 		err = fmt.Errorf("block height %d not equal next block height %d", blockHeight, nextBlockHeight)
 		return
 	}
+	// block is executed before it's signed, so block that can't be submitted fails here
+	if err = s.verifyBlockContent(block); err != nil {
+		return
+	}
 	result, err = s.executeBlock(block)
 	return
 }
@@ -525,13 +736,16 @@ func (s *LedgerStoreImp) AddBlock(block *types.Block, stateMerkleRoot common.Uin
 }
 
 func (s *LedgerStoreImp) GetCrossStatesProof(height uint64, key []byte) ([]byte, error) {
+	if s.blockStore.isPruned(height) {
+		return nil, scom.ErrPruned
+	}
 	hashes, err := s.stateStore.GetCrossStates(height)
 	if err != nil {
-		return nil, fmt.Errorf("GetCrossStates:%s", err)
+		return nil, fmt.Errorf("GetCrossStates:%w", err)
 	}
 	state, err := s.stateStore.GetStorageValue(key)
 	if err != nil {
-		return nil, fmt.Errorf("GetStorageState key:%x", key)
+		return nil, fmt.Errorf("GetStorageState key:%x error %w", key, err)
 	}
 	path, err := merkle.MerkleLeafPath(state, hashes)
 	if err != nil {
@@ -558,6 +772,14 @@ func (s *LedgerStoreImp) saveBlockToBlockStore(block *types.Block) error {
 	if err != nil {
 		return fmt.Errorf("SaveBlock height %d hash %s error %s", blockHeight, blockHash.ToHexString(), err)
 	}
+	err = s.saveSourceEvents(block)
+	if err != nil {
+		return fmt.Errorf("saveSourceEvents height %d error %s", blockHeight, err)
+	}
+	err = s.expireRequests(blockHeight)
+	if err != nil {
+		return fmt.Errorf("expireRequests height %d error %s", blockHeight, err)
+	}
 	return nil
 }
 
@@ -575,7 +797,12 @@ func (s *LedgerStoreImp) saveBlockToStateStore(block *types.Block, result store.
 	blockHash := block.Hash()
 	blockHeight := block.Header.Height
 
-	err := s.stateStore.AddStateMerkleTreeRoot(blockHeight, result.Hash)
+	err := s.saveEpochsToStateStore(block)
+	if err != nil {
+		return fmt.Errorf("saveEpochsToStateStore error %s", err)
+	}
+
+	err = s.stateStore.AddStateMerkleTreeRoot(blockHeight, result.Hash)
 	if err != nil {
 		return fmt.Errorf("AddBlockMerkleTreeRoot error %s", err)
 	}
@@ -608,6 +835,74 @@ func (s *LedgerStoreImp) saveBlockToStateStore(block *types.Block, result store.
 	return nil
 }
 
+// saveEpochsToStateStore apply epoch event included to block to epoch history
+func (s *LedgerStoreImp) saveEpochsToStateStore(block *types.Block) error {
+	epoch, err := blockEpochEvent(block)
+	if err != nil || epoch == nil {
+		return err
+	}
+	err = s.stateStore.SaveEpoch(&states.EpochInfo{
+		Number:         epoch.Number,
+		BlockHash:      block.Hash(),
+		Height:         block.Header.Height,
+		EpochPublicKey: epoch.EpochPublicKey,
+		SourceTx:       epoch.SourceTx,
+		PublicKeys:     epoch.PublicKeys,
+		HostIds:        epoch.HostIds,
+	})
+	if err != nil {
+		return fmt.Errorf("SaveEpoch number %d error %s", epoch.Number, err)
+	}
+	logrus.Infof("epoch %d applied at height %d", epoch.Number, block.Header.Height)
+	return nil
+}
+
+// blockEpochEvent return epoch event included to block, nil if there is none.
+// Epoch history is indexed by block height, so block can include one epoch event only
+func blockEpochEvent(block *types.Block) (*payload.EpochEvent, error) {
+	var epoch *payload.EpochEvent
+	for _, tx := range block.Transactions {
+		event, ok := tx.Payload.(*payload.EpochEvent)
+		if !ok {
+			continue
+		}
+		if epoch != nil {
+			return nil, fmt.Errorf("block %d includes more than one epoch event", block.Header.Height)
+		}
+		epoch = event
+	}
+	return epoch, nil
+}
+
+// verifyBlockContent check block transactions can be applied to the ledger. It's called before any write
+// of the block, so rejected block leaves no header index, cached block or transactions behind
+func (s *LedgerStoreImp) verifyBlockContent(block *types.Block) error {
+	if err := s.verifyBlockEpoch(block); err != nil {
+		return err
+	}
+	return s.blockStore.verifyRequestStates(block.Transactions)
+}
+
+// verifyBlockEpoch check epoch event of the block follows the epoch in force at the previous block.
+// Epoch history is indexed by number, so reused or skipped numbers are rejected. Genesis block and
+// the first epoch of ledger without epoch history may start at any number
+func (s *LedgerStoreImp) verifyBlockEpoch(block *types.Block) error {
+	epoch, err := blockEpochEvent(block)
+	if err != nil || epoch == nil || block.Header.Height == 0 {
+		return err
+	}
+	last, err := s.stateStore.GetEpochAtHeight(block.Header.Height - 1)
+	if err == scom.ErrNotFound {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("get epoch at height %d error %s", block.Header.Height-1, err)
+	}
+	if epoch.Number != last.Number+1 {
+		return fmt.Errorf("block %d epoch number %d not equal next epoch number %d", block.Header.Height, epoch.Number, last.Number+1)
+	}
+	return nil
+}
+
 func (s *LedgerStoreImp) saveBlockToEventStore(block *types.Block) error {
 	blockHash := block.Hash()
 	blockHeight := block.Header.Height
@@ -651,6 +946,24 @@ func (s *LedgerStoreImp) releaseSavingBlockLock() {
 	}
 }
 
+// commitBlockBatches commit batches of block, event and state stores
+func (s *LedgerStoreImp) commitBlockBatches(blockHeight uint64) error {
+	err := s.blockStore.CommitTo()
+	if err != nil {
+		return fmt.Errorf("blockStore.CommitTo height:%d error %s", blockHeight, err)
+	}
+	// event store is idempotent to re-save when in recovering process, so save first before stateStore
+	err = s.eventStore.CommitTo()
+	if err != nil {
+		return fmt.Errorf("eventStore.CommitTo height:%d error %s", blockHeight, err)
+	}
+	err = s.stateStore.CommitTo()
+	if err != nil {
+		return fmt.Errorf("stateStore.CommitTo height:%d error %s", blockHeight, err)
+	}
+	return nil
+}
+
 // saveBlock do the job of execution samrt contract and commit block to store.
 func (s *LedgerStoreImp) submitBlock(block *types.Block, result store.ExecuteResult) error {
 	blockHash := block.Hash()
@@ -662,10 +975,14 @@ func (s *LedgerStoreImp) submitBlock(block *types.Block, result store.ExecuteRes
 	// 		block.Header.Height, blockRoot.ToHexString(), block.Header.BlockRoot.ToHexString())
 	// }
 
+	err := s.verifyBlockContent(block)
+	if err != nil {
+		return fmt.Errorf("verify block height:%d error:%s", blockHeight, err)
+	}
 	s.blockStore.NewBatch()
 	s.stateStore.NewBatch()
 	s.eventStore.NewBatch()
-	err := s.saveBlockToBlockStore(block)
+	err = s.saveBlockToBlockStore(block)
 	if err != nil {
 		return fmt.Errorf("save to block store height:%d error:%s", blockHeight, err)
 	}
@@ -677,28 +994,15 @@ func (s *LedgerStoreImp) submitBlock(block *types.Block, result store.ExecuteRes
 	if err != nil {
 		return fmt.Errorf("save to event store height:%d error:%s", blockHeight, err)
 	}
-	err = s.blockStore.CommitTo()
-	if err != nil {
-		return fmt.Errorf("blockStore.CommitTo height:%d error %s", blockHeight, err)
-	}
-	// event store is idempotent to re-save when in recovering process, so save first before stateStore
-	err = s.eventStore.CommitTo()
-	if err != nil {
-		return fmt.Errorf("eventStore.CommitTo height:%d error %s", blockHeight, err)
-	}
-	err = s.stateStore.CommitTo()
-	if err != nil {
-		return fmt.Errorf("stateStore.CommitTo height:%d error %s", blockHeight, err)
+	s.beginAtomicCommit()
+	err = s.commitBlockBatches(blockHeight)
+	if err = s.endAtomicCommit(err); err != nil {
+		return err
 	}
 	s.setCurrentBlock(blockHeight, blockHash)
 
-	// if events.DefActorPublisher != nil {
-	//	events.DefActorPublisher.Publish(
-	//		message.TOPIC_SAVE_BLOCK_COMPLETE,
-	//		&message.SaveBlockCompleteMsg{
-	//			Block: block,
-	//		})
-	// }
+	s.publishBlock(block)
+	s.wakePruner()
 	return nil
 }
 
@@ -837,11 +1141,36 @@ func (s *LedgerStoreImp) GetTransactionByReqId(reqId [32]byte) (payload.Payload,
 	return s.blockStore.GetTransactionByReqId(reqId)
 }
 
+// GetRequestTxHash return hash of the last transaction of the request. Wrap function of BlockStore.GetRequestTxHash
+func (s *LedgerStoreImp) GetRequestTxHash(reqId [32]byte) (common.Uint256, error) {
+	return s.blockStore.GetRequestTxHash(reqId)
+}
+
 // GetRequestState return request state by request id. Wrap function of BlockStore.GetRequestState
 func (s *LedgerStoreImp) GetRequestState(reqId [32]byte) (payload.ReqState, error) {
 	return s.blockStore.GetRequestState(reqId)
 }
 
+// GetRequestsByDstChain return ids of the requests to destination chain received in height range. Wrap function of BlockStore.GetRequestsByDstChain
+func (s *LedgerStoreImp) GetRequestsByDstChain(dstChainId uint64, fromHeight, toHeight uint64, offset, limit int) ([][32]byte, error) {
+	return s.blockStore.GetRequestsByDstChain(dstChainId, fromHeight, toHeight, offset, limit)
+}
+
+// GetRequestsBySrcTxHash return ids of the requests with chain transaction hash. Wrap function of BlockStore.GetRequestsBySrcTxHash
+func (s *LedgerStoreImp) GetRequestsBySrcTxHash(srcTxHash []byte) ([][32]byte, error) {
+	return s.blockStore.GetRequestsBySrcTxHash(srcTxHash)
+}
+
+// GetRequestsByBridgeAddress return ids of the requests of bridge address. Wrap function of BlockStore.GetRequestsByBridgeAddress
+func (s *LedgerStoreImp) GetRequestsByBridgeAddress(address []byte, offset, limit int) ([][32]byte, error) {
+	return s.blockStore.GetRequestsByBridgeAddress(address, offset, limit)
+}
+
+// GetRequestHistory return all state changes of the request. Wrap function of BlockStore.GetRequestHistory
+func (s *LedgerStoreImp) GetRequestHistory(reqId [32]byte) ([]*states.RequestStateEntry, error) {
+	return s.blockStore.GetRequestHistory(reqId)
+}
+
 // GetBlockByHash return block by block hash. Wrap function of BlockStore.GetBlockByHash
 func (s *LedgerStoreImp) GetBlockByHash(blockHash common.Uint256) (*types.Block, error) {
 	return s.blockStore.GetBlock(blockHash)
@@ -862,6 +1191,21 @@ func (s *LedgerStoreImp) GetEpochState() (*states.EpochState, error) {
 	return s.stateStore.GetEpochState()
 }
 
+// GetEpochByNumber return the epoch info by epoch number. Wrap function of StateStore.GetEpochByNumber
+func (s *LedgerStoreImp) GetEpochByNumber(number uint32) (*states.EpochInfo, error) {
+	return s.stateStore.GetEpochByNumber(number)
+}
+
+// GetEpochByBlockHash return the epoch info by hash of block including epoch event. Wrap function of StateStore.GetEpochByBlockHash
+func (s *LedgerStoreImp) GetEpochByBlockHash(blockHash common.Uint256) (*states.EpochInfo, error) {
+	return s.stateStore.GetEpochByBlockHash(blockHash)
+}
+
+// GetEpochAtHeight return the epoch info active at block height. Wrap function of StateStore.GetEpochAtHeight
+func (s *LedgerStoreImp) GetEpochAtHeight(height uint64) (*states.EpochInfo, error) {
+	return s.stateStore.GetEpochAtHeight(height)
+}
+
 // GetMerkleProof return the block merkle proof. Wrap function of StateStore.GetMerkleProof
 func (s *LedgerStoreImp) GetMerkleProof(raw []byte, proofHeight, rootHeight uint64) ([]byte, error) {
 	return s.stateStore.GetMerkleProof(raw, proofHeight, rootHeight)
@@ -874,11 +1218,20 @@ func (s *LedgerStoreImp) GetStorageItem(key *states.StorageKey) (*states.Storage
 
 // GetEventNotifyByTx return the events notify gen by executing of smart contract.  Wrap function of EventStore.GetEventNotifyByTx
 func (s *LedgerStoreImp) GetEventNotifyByTx(tx common.Uint256) (*event.ExecuteNotify, error) {
-	return s.eventStore.GetEventNotifyByTx(tx)
+	notify, err := s.eventStore.GetEventNotifyByTx(tx)
+	if err == scom.ErrNotFound {
+		if _, _, txErr := s.blockStore.GetTransaction(tx); txErr == scom.ErrPruned {
+			return nil, txErr
+		}
+	}
+	return notify, err
 }
 
 // GetEventNotifyByBlock return the transaction hash which have event notice after execution of smart contract. Wrap function of EventStore.GetEventNotifyByBlock
 func (s *LedgerStoreImp) GetEventNotifyByBlock(height uint64) ([]*event.ExecuteNotify, error) {
+	if s.blockStore.isPruned(height) {
+		return nil, scom.ErrPruned
+	}
 	return s.eventStore.GetEventNotifyByBlock(height)
 }
 
@@ -918,6 +1271,9 @@ func (s *LedgerStoreImp) Close() error {
 		logrus.Error(err)
 	}
 
+	s.eventHub.Close()
+	s.stopPruner()
+
 	s.lock.RLock()
 	logrus.Infof("gracefull shutdown ledger.  processed height: %d", s.processedHeight)
 	s.lock.RUnlock()
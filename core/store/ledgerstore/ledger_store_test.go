@@ -5,9 +5,16 @@ import (
 	"os"
 	"testing"
 
+	ethCommon "github.com/ethereum/go-ethereum/common"
+	"github.com/eywa-protocol/bls-crypto/bls"
 	"github.com/stretchr/testify/require"
 
+	"github.com/eywa-protocol/chain/common"
 	"github.com/eywa-protocol/chain/core/genesis"
+	"github.com/eywa-protocol/chain/core/payload"
+	"github.com/eywa-protocol/chain/core/store"
+	"github.com/eywa-protocol/chain/core/store/memstore"
+	"github.com/eywa-protocol/chain/core/types"
 )
 
 // TODO: fix unhandled errors
@@ -26,14 +33,14 @@ func TestMain(m *testing.M) {
 	}
 
 	testBlockDir := "test/block"
-	testBlockStore, err = NewBlockStore(testBlockDir, false)
+	testBlockStore, err = NewBlockStore("", testBlockDir, false)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "NewBlockStore error %s\n", err)
 		return
 	}
 	testStateDir := "test/state"
 	merklePath := "test/" + MerkleTreeStorePath
-	testStateStore, err = NewStateStore(testStateDir, merklePath)
+	testStateStore, err = NewStateStore("", testStateDir, merklePath)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "NewStateStore error %s\n", err)
 		return
@@ -118,3 +125,138 @@ func TestInitLedgerStoreWithGenesisBlock(t *testing.T) {
 		return
 	}
 }
+
+func TestSubmitBlockVerifySignature(t *testing.T) {
+	ledgerStore, err := NewLedgerStore("test/verify")
+	require.NoError(t, err)
+	defer ledgerStore.Close()
+
+	_, pubKey1 := bls.GenerateRandomKey()
+	_, pubKey2 := bls.GenerateRandomKey()
+	epoch := payload.NewEpochEvent(1, common.UINT256_EMPTY, []bls.PublicKey{pubKey1, pubKey2}, []string{"one", "two"})
+	genesisBlock := types.NewBlock(0, common.UINT256_EMPTY, common.UINT256_EMPTY, 0, 0, types.Transactions{types.ToTransaction(epoch)})
+	err = ledgerStore.InitLedgerStoreWithGenesisBlock(genesisBlock)
+	require.NoError(t, err)
+	ledgerStore.SetQuorumThreshold(DefaultQuorumThreshold)
+
+	epochInfo, err := ledgerStore.GetEpochByBlockHash(genesisBlock.Hash())
+	require.NoError(t, err)
+	require.Equal(t, epoch.Number, epochInfo.Number)
+	require.Equal(t, epoch.HostIds, epochInfo.HostIds)
+
+	unsigned := types.NewBlock(0, genesisBlock.Hash(), genesisBlock.Hash(), 1, 1, types.Transactions{})
+	result, err := ledgerStore.ExecuteBlock(unsigned)
+	require.NoError(t, err)
+	require.Error(t, ledgerStore.SubmitBlock(unsigned, result))
+
+	withoutEpoch := types.NewBlock(0, genesisBlock.Hash(), common.UINT256_EMPTY, 1, 1, types.Transactions{})
+	result, err = ledgerStore.ExecuteBlock(withoutEpoch)
+	require.NoError(t, err)
+	require.Error(t, ledgerStore.SubmitBlock(withoutEpoch, result))
+	require.Equal(t, uint64(0), ledgerStore.GetCurrentBlockHeight())
+
+	ledgerStore.SetQuorumThreshold(0)
+	require.NoError(t, ledgerStore.SubmitBlock(unsigned, result))
+	require.Equal(t, uint64(1), ledgerStore.GetCurrentBlockHeight())
+
+	// header naming epoch rotated out before the previous block is rejected
+	_, pubKey3 := bls.GenerateRandomKey()
+	next := payload.NewEpochEvent(2, common.UINT256_EMPTY, []bls.PublicKey{pubKey3}, []string{"three"})
+	rotation := submitTestBlock(t, ledgerStore, 2, types.Transactions{types.ToTransaction(next)})
+	ledgerStore.SetQuorumThreshold(DefaultQuorumThreshold)
+	stale := types.NewBlock(0, rotation.Hash(), genesisBlock.Hash(), 3, 3, types.Transactions{})
+	result, err = ledgerStore.ExecuteBlock(stale)
+	require.NoError(t, err)
+	err = ledgerStore.SubmitBlock(stale, result)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "in force")
+}
+
+func TestLedgerStoreMemoryBackend(t *testing.T) {
+	ledgerStore, err := NewLedgerStoreWithConfig(t.TempDir(), StoreConfig{
+		BlockBackend: memstore.BackendName,
+		StateBackend: memstore.BackendName,
+		EventBackend: memstore.BackendName,
+	})
+	require.NoError(t, err)
+	defer ledgerStore.Close()
+	require.IsType(t, &memstore.MemStore{}, ledgerStore.blockStore.store)
+	require.IsType(t, &memstore.MemStore{}, ledgerStore.stateStore.store)
+	require.IsType(t, &memstore.MemStore{}, ledgerStore.eventStore.store)
+
+	genesisBlock := types.NewBlock(0, common.UINT256_EMPTY, common.UINT256_EMPTY, 10, 0, types.Transactions{})
+	require.NoError(t, ledgerStore.InitLedgerStoreWithGenesisBlock(genesisBlock))
+	block := submitTestBlock(t, ledgerStore, 11, types.Transactions{})
+	require.Equal(t, uint64(1), ledgerStore.GetCurrentBlockHeight())
+	stored, err := ledgerStore.GetBlockByHeight(1)
+	require.NoError(t, err)
+	require.Equal(t, block.Hash(), stored.Hash())
+
+	_, err = NewLedgerStoreWithConfig(t.TempDir(), StoreConfig{StateBackend: "unknown"})
+	require.Error(t, err)
+}
+
+func TestSubmitBlockEpochEvents(t *testing.T) {
+	ledgerStore := openTestLedgerStore(t, t.TempDir(), StoreConfig{})
+	defer ledgerStore.Close()
+	_, pubKey1 := bls.GenerateRandomKey()
+	_, pubKey2 := bls.GenerateRandomKey()
+	epoch1 := payload.NewEpochEvent(1, common.UINT256_EMPTY, []bls.PublicKey{pubKey1}, []string{"one"})
+	epoch2 := payload.NewEpochEvent(2, common.UINT256_EMPTY, []bls.PublicKey{pubKey2}, []string{"two"})
+
+	// the second epoch of the block would overwrite the first one in epoch height index
+	block := types.NewBlock(0, ledgerStore.GetCurrentBlockHash(), common.UINT256_EMPTY, 11, 1,
+		types.Transactions{types.ToTransaction(epoch1), types.ToTransaction(epoch2)})
+	_, err := ledgerStore.ExecuteBlock(block)
+	require.Error(t, err)
+	require.Error(t, ledgerStore.SubmitBlock(block, store.ExecuteResult{}))
+	require.Equal(t, uint64(0), ledgerStore.GetCurrentBlockHeight())
+	require.Equal(t, uint64(0), ledgerStore.GetCurrentHeaderHeight())
+	epoch2Tx := types.ToTransaction(epoch2)
+	contains, err := ledgerStore.IsContainTransaction(epoch2Tx.Hash())
+	require.NoError(t, err)
+	require.False(t, contains)
+
+	submitTestBlock(t, ledgerStore, 11, types.Transactions{types.ToTransaction(epoch1)})
+	epoch, err := ledgerStore.GetEpochAtHeight(1)
+	require.NoError(t, err)
+	require.Equal(t, uint32(1), epoch.Number)
+
+	// epoch number must follow the epoch in force
+	_, pubKey3 := bls.GenerateRandomKey()
+	for _, number := range []uint32{1, 3} {
+		event := payload.NewEpochEvent(number, common.UINT256_EMPTY, []bls.PublicKey{pubKey3}, []string{"three"})
+		block = types.NewBlock(0, ledgerStore.GetCurrentBlockHash(), common.UINT256_EMPTY, 12, 2, types.Transactions{types.ToTransaction(event)})
+		_, err = ledgerStore.ExecuteBlock(block)
+		require.Error(t, err, number)
+		require.Error(t, ledgerStore.SubmitBlock(block, store.ExecuteResult{}), number)
+		require.Equal(t, uint64(1), ledgerStore.GetCurrentHeaderHeight())
+	}
+	submitTestBlock(t, ledgerStore, 12, types.Transactions{types.ToTransaction(epoch2)})
+	epoch, err = ledgerStore.GetEpochAtHeight(2)
+	require.NoError(t, err)
+	require.Equal(t, uint32(2), epoch.Number)
+}
+
+func TestSubmitBlockRequestStates(t *testing.T) {
+	ledgerStore := openTestLedgerStore(t, t.TempDir(), StoreConfig{})
+	defer ledgerStore.Close()
+	reqId := [32]byte{1}
+	received := newIndexTestRequest(reqId, 94, ethCommon.Address{}, ethCommon.Address{})[0]
+	signed := types.ToTransaction(payload.NewRequestStateEvent(reqId, payload.ReqStateSigned, 94, nil, "", 0))
+
+	// request must be received before it's signed, block of illegal transition is rejected before it's written
+	block := types.NewBlock(0, ledgerStore.GetCurrentBlockHash(), common.UINT256_EMPTY, 11, 1, types.Transactions{signed, received})
+	_, err := ledgerStore.ExecuteBlock(block)
+	require.Error(t, err)
+	require.Error(t, ledgerStore.SubmitBlock(block, store.ExecuteResult{}))
+	require.Equal(t, uint64(0), ledgerStore.GetCurrentHeaderHeight())
+	contains, err := ledgerStore.IsContainTransaction(received.Hash())
+	require.NoError(t, err)
+	require.False(t, contains)
+
+	submitTestBlock(t, ledgerStore, 11, types.Transactions{received, signed})
+	state, err := ledgerStore.GetRequestState(reqId)
+	require.NoError(t, err)
+	require.Equal(t, payload.ReqStateSigned, state)
+}
@@ -0,0 +1,323 @@
+package ledgerstore
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sync/atomic"
+
+	"github.com/eywa-protocol/chain/common"
+	"github.com/eywa-protocol/chain/core/payload"
+	scom "github.com/eywa-protocol/chain/core/store/common"
+	"github.com/eywa-protocol/chain/core/store/sharedstore"
+	"github.com/sirupsen/logrus"
+)
+
+const PruneBatchSize = uint64(1000) // Count of blocks pruned between saves of pruning progress
+
+// pruner remove transactions, event notifies and cross states of old blocks in background.
+// Headers, request states, request history, request indexes and transactions of epoch blocks are kept forever
+type pruner struct {
+	keepBlocks uint64        // Count of recent blocks kept unpruned
+	wake       chan struct{} // Signaled after block commit
+	quit       chan struct{}
+	done       chan struct{}
+}
+
+// pruneBatch collect removals of pruned block. Stores kept in single database write them in a batch of its own,
+// apart from block commit batches, stores kept in separate databases write them directly
+type pruneBatch struct {
+	shared *sharedstore.Batch // Batch of single database, nil if stores are kept in separate databases
+	ops    []pruneOp
+}
+
+// pruneOp is put or delete operation of pruned block
+type pruneOp struct {
+	store     scom.PersistStore
+	namespace byte // Namespace of the store in single database
+	key       []byte
+	value     []byte
+	delete    bool
+}
+
+func (s *LedgerStoreImp) newPruneBatch() *pruneBatch {
+	batch := new(pruneBatch)
+	if s.sharedStore != nil {
+		batch.shared = s.sharedStore.NewBatch()
+	}
+	return batch
+}
+
+func (b *pruneBatch) put(store scom.PersistStore, namespace byte, key, value []byte) {
+	b.ops = append(b.ops, pruneOp{store: store, namespace: namespace, key: key, value: value})
+}
+
+func (b *pruneBatch) delete(store scom.PersistStore, namespace byte, key []byte) {
+	b.ops = append(b.ops, pruneOp{store: store, namespace: namespace, key: key, delete: true})
+}
+
+// commit write collected operations in their order
+func (b *pruneBatch) commit() error {
+	if b.shared != nil {
+		for _, op := range b.ops {
+			if op.delete {
+				b.shared.Delete(op.namespace, op.key)
+			} else {
+				b.shared.Put(op.namespace, op.key, op.value)
+			}
+		}
+		return b.shared.Commit()
+	}
+	for _, op := range b.ops {
+		var err error
+		if op.delete {
+			err = op.store.Delete(op.key)
+		} else {
+			err = op.store.Put(op.key, op.value)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetPrunedHeight return height below which blocks are pruned, 0 if nothing is pruned
+func (s *LedgerStoreImp) GetPrunedHeight() uint64 {
+	return s.blockStore.GetPrunedHeight()
+}
+
+// startPruner start background pruning if it is enabled by store config
+func (s *LedgerStoreImp) startPruner() {
+	if s.pruneKeepBlocks == 0 || s.pruner != nil {
+		return
+	}
+	s.pruner = &pruner{
+		keepBlocks: s.pruneKeepBlocks,
+		wake:       make(chan struct{}, 1),
+		quit:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+	go s.runPruner(s.pruner)
+}
+
+// wakePruner signal pruner about committed block without waiting for it
+func (s *LedgerStoreImp) wakePruner() {
+	if s.pruner == nil {
+		return
+	}
+	select {
+	case s.pruner.wake <- struct{}{}:
+	default:
+	}
+}
+
+// stopPruner stop background pruning and wait until it is done
+func (s *LedgerStoreImp) stopPruner() {
+	if s.pruner == nil {
+		return
+	}
+	close(s.pruner.quit)
+	<-s.pruner.done
+	s.pruner = nil
+}
+
+func (s *LedgerStoreImp) runPruner(p *pruner) {
+	defer close(p.done)
+	for {
+		if err := s.prune(p); err != nil {
+			logrus.Errorf("prune blocks error %s", err)
+		}
+		select {
+		case <-p.wake:
+		case <-p.quit:
+			return
+		}
+	}
+}
+
+// prune blocks which are not kept. Blocks are marked pruned before their data is removed
+// and interrupted pruning is finished on the next run
+func (s *LedgerStoreImp) prune(p *pruner) error {
+	done, target, err := s.blockStore.getPruneState()
+	if err != nil {
+		return fmt.Errorf("getPruneState error %s", err)
+	}
+	for {
+		if done >= target {
+			target, err = s.advancePruneTarget(done, p.keepBlocks)
+			if err != nil {
+				return err
+			}
+			if done >= target {
+				return nil
+			}
+		}
+		for height := done; height < target; height++ {
+			select {
+			case <-p.quit:
+				return nil
+			default:
+			}
+			if err := s.pruneBlock(height); err != nil {
+				return fmt.Errorf("pruneBlock height %d error %s", height, err)
+			}
+		}
+		done = target
+		if err := s.blockStore.savePruneState(done, target); err != nil {
+			return fmt.Errorf("savePruneState error %s", err)
+		}
+		logrus.Debugf("blocks below height %d are pruned", done)
+	}
+}
+
+// advancePruneTarget mark up to PruneBatchSize blocks above done as pruned if they are not kept, return new target.
+// Saving block lock is held so the target is never above the blocks required by concurrent rollback
+func (s *LedgerStoreImp) advancePruneTarget(done, keepBlocks uint64) (uint64, error) {
+	s.getSavingBlockLock()
+	defer s.releaseSavingBlockLock()
+	currHeight := s.GetCurrentBlockHeight()
+	if currHeight+1 <= done+keepBlocks {
+		return done, nil
+	}
+	target := currHeight + 1 - keepBlocks
+	if target > done+PruneBatchSize {
+		target = done + PruneBatchSize
+	}
+	if err := s.blockStore.savePruneState(done, target); err != nil {
+		return done, fmt.Errorf("savePruneState error %s", err)
+	}
+	return target, nil
+}
+
+// pruneBlock remove transactions, event notifies and cross states of the block at height in one batch.
+// Saving block lock is held so transaction saved again by concurrent block is not overwritten with the pruned one
+func (s *LedgerStoreImp) pruneBlock(height uint64) error {
+	s.getSavingBlockLock()
+	defer s.releaseSavingBlockLock()
+	blockHash, err := s.blockStore.GetBlockHash(height)
+	if err != nil {
+		return fmt.Errorf("GetBlockHash error %s", err)
+	}
+	_, txHashes, err := s.blockStore.loadHeaderWithTx(blockHash)
+	if err != nil {
+		return fmt.Errorf("loadHeaderWithTx error %s", err)
+	}
+	// transactions of epoch block are kept to prove the epoch in snapshots
+	if _, err := s.stateStore.GetEpochByBlockHash(blockHash); err == nil {
+		txHashes = nil
+	} else if err != scom.ErrNotFound {
+		return fmt.Errorf("GetEpochByBlockHash error %s", err)
+	}
+	batch := s.newPruneBatch()
+	pruned, err := s.blockStore.pruneTransactions(batch, height, txHashes)
+	if err != nil {
+		return fmt.Errorf("pruneTransactions error %s", err)
+	}
+	if err := s.eventStore.pruneBlock(batch, height, pruned); err != nil {
+		return fmt.Errorf("eventStore.pruneBlock error %s", err)
+	}
+	s.stateStore.pruneCrossStates(batch, height)
+	if err := batch.commit(); err != nil {
+		return fmt.Errorf("commit pruned block error %s", err)
+	}
+	return nil
+}
+
+// GetPrunedHeight return height below which blocks are pruned
+func (s *BlockStore) GetPrunedHeight() uint64 {
+	return atomic.LoadUint64(&s.pruned)
+}
+
+func (s *BlockStore) isPruned(height uint64) bool {
+	return height < s.GetPrunedHeight()
+}
+
+// checkPruned replace transaction of pruned block with ErrPruned
+func (s *BlockStore) checkPruned(tx payload.Payload, height uint64, err error) (payload.Payload, uint64, error) {
+	if err == nil && s.isPruned(height) {
+		return nil, height, scom.ErrPruned
+	}
+	return tx, height, err
+}
+
+// getPruneState return height blocks are pruned up to and height blocks are marked pruned up to
+func (s *BlockStore) getPruneState() (done, target uint64, err error) {
+	value, err := s.store.Get(s.getPruneStateKey())
+	if err == scom.ErrNotFound {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(value) != 16 {
+		return 0, 0, io.ErrUnexpectedEOF
+	}
+	return binary.BigEndian.Uint64(value), binary.BigEndian.Uint64(value[8:]), nil
+}
+
+// savePruneState persist pruning progress, blocks below target are reported pruned from now
+func (s *BlockStore) savePruneState(done, target uint64) error {
+	value := make([]byte, 16)
+	binary.BigEndian.PutUint64(value, done)
+	binary.BigEndian.PutUint64(value[8:], target)
+	if err := s.store.Put(s.getPruneStateKey(), value); err != nil {
+		return err
+	}
+	atomic.StoreUint64(&s.pruned, target)
+	return nil
+}
+
+// pruneTransactions put transactions saved at height replaced with the height only to batch.
+// Return hashes of the transactions saved at height, transactions included again later are kept
+func (s *BlockStore) pruneTransactions(batch *pruneBatch, height uint64, txHashes []common.Uint256) ([]common.Uint256, error) {
+	pruned := make([]common.Uint256, 0, len(txHashes))
+	for _, txHash := range txHashes {
+		key, err := s.getTransactionKey(txHash)
+		if err != nil {
+			return nil, err
+		}
+		value, err := s.store.Get(key)
+		if err == scom.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		source := common.NewZeroCopySource(value)
+		txHeight, eof := source.NextUint64()
+		if eof {
+			return nil, io.ErrUnexpectedEOF
+		}
+		if txHeight != height {
+			continue
+		}
+		if source.Len() > 0 {
+			batch.put(s.store, blockNamespace, key, value[:8])
+		}
+		pruned = append(pruned, txHash)
+	}
+	return pruned, nil
+}
+
+func (s *BlockStore) getPruneStateKey() []byte {
+	return []byte{byte(scom.SYS_PRUNED_HEIGHT)}
+}
+
+// pruneBlock put removal of event notifies of the block and its transactions to batch
+func (s *EventStore) pruneBlock(batch *pruneBatch, height uint64, txHashes []common.Uint256) error {
+	key, err := s.getEventNotifyByBlockKey(height)
+	if err != nil {
+		return err
+	}
+	batch.delete(s.store, eventNamespace, key)
+	for _, txHash := range txHashes {
+		batch.delete(s.store, eventNamespace, s.getEventNotifyByTxKey(txHash))
+	}
+	return nil
+}
+
+// pruneCrossStates put removal of cross states of the block at height to batch, cross states root is kept
+func (s *StateStore) pruneCrossStates(batch *pruneBatch, height uint64) {
+	batch.delete(s.store, stateNamespace, genCrossStatesKey(height))
+}
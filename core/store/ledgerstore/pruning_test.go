@@ -0,0 +1,85 @@
+package ledgerstore
+
+import (
+	"testing"
+	"time"
+
+	ethCommon "github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eywa-protocol/chain/common"
+	"github.com/eywa-protocol/chain/core/payload"
+	scom "github.com/eywa-protocol/chain/core/store/common"
+	"github.com/eywa-protocol/chain/core/types"
+)
+
+func TestPruning(t *testing.T) {
+	t.Run("SeparateDB", func(t *testing.T) {
+		testPruning(t, StoreConfig{PruneKeepBlocks: 2})
+	})
+	t.Run("SingleDB", func(t *testing.T) {
+		testPruning(t, StoreConfig{PruneKeepBlocks: 2, SingleDB: true})
+	})
+}
+
+func testPruning(t *testing.T, config StoreConfig) {
+	dataDir := t.TempDir()
+	ledgerStore := openTestLedgerStore(t, dataDir, config)
+	bridge := ethCommon.HexToAddress("0x0c760E9A85d2E957Dd1E189516b6658CfEcD3985")
+	reqId := [32]byte{1}
+	txs := newIndexTestRequest(reqId, 94, bridge, bridge)
+	txHash := txs[0].Hash()
+	block1 := submitTestBlock(t, ledgerStore, 11, txs)
+	for i := uint64(0); i < 3; i++ {
+		submitTestBlock(t, ledgerStore, 12+i, types.Transactions{})
+	}
+
+	// blocks below current height 4 minus 2 kept blocks are pruned
+	require.Eventually(t, func() bool {
+		done, target, err := ledgerStore.blockStore.getPruneState()
+		return err == nil && done == 3 && target == 3
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, uint64(3), ledgerStore.GetPrunedHeight())
+
+	_, err := ledgerStore.GetBlockByHeight(1)
+	require.Equal(t, scom.ErrPruned, err)
+	_, err = ledgerStore.GetBlockByHash(block1.Hash())
+	require.Equal(t, scom.ErrPruned, err)
+	_, _, err = ledgerStore.GetTransaction(txHash)
+	require.Equal(t, scom.ErrPruned, err)
+	_, _, err = ledgerStore.GetTransactionByReqId(reqId)
+	require.Equal(t, scom.ErrPruned, err)
+	_, err = ledgerStore.GetEventNotifyByBlock(1)
+	require.Equal(t, scom.ErrPruned, err)
+	_, err = ledgerStore.GetCrossStatesProof(1, nil)
+	require.Equal(t, scom.ErrPruned, err)
+	_, _, err = ledgerStore.GetTransaction(common.Uint256{0xff})
+	require.Equal(t, scom.ErrNotFound, err)
+
+	// headers and request states are kept
+	header, err := ledgerStore.GetHeaderByHeight(1)
+	require.NoError(t, err)
+	require.Equal(t, block1.Hash(), *header.Hash())
+	has, err := ledgerStore.IsContainTransaction(txHash)
+	require.NoError(t, err)
+	require.True(t, has)
+	state, err := ledgerStore.GetRequestState(reqId)
+	require.NoError(t, err)
+	require.Equal(t, payload.ReqStateReceived, state)
+	history, err := ledgerStore.GetRequestHistory(reqId)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	block, err := ledgerStore.GetBlockByHeight(3)
+	require.NoError(t, err)
+	require.NotNil(t, block)
+
+	require.Error(t, ledgerStore.RollbackTo(1))
+	require.NoError(t, ledgerStore.RollbackTo(2))
+	require.NoError(t, ledgerStore.Close())
+
+	ledgerStore = openTestLedgerStore(t, dataDir, config)
+	defer ledgerStore.Close()
+	require.Equal(t, uint64(3), ledgerStore.GetPrunedHeight())
+	_, _, err = ledgerStore.GetTransaction(txHash)
+	require.Equal(t, scom.ErrPruned, err)
+}
@@ -0,0 +1,228 @@
+package ledgerstore
+
+import (
+	"encoding/binary"
+
+	"github.com/eywa-protocol/chain/common"
+	"github.com/eywa-protocol/chain/core/payload"
+	scom "github.com/eywa-protocol/chain/core/store/common"
+	"github.com/sirupsen/logrus"
+)
+
+const DefaultRequestExpiry = uint64(10000) // Blocks after which undelivered request is expired, 0 disables expiry
+
+// GetOpenRequests return ids of the requests to destination chain which wait for delivery since height below olderThanHeight.
+// Requests are ordered by the height they were received or retried at
+func (s *BlockStore) GetOpenRequests(olderThanHeight uint64, dstChainId uint64) ([][32]byte, error) {
+	var reqIds [][32]byte
+	err := s.iterateOpenRequests(olderThanHeight, func(reqId [32]byte, openHeight, chainId uint64) {
+		if chainId == dstChainId {
+			reqIds = append(reqIds, reqId)
+		}
+	})
+	return reqIds, err
+}
+
+// ExpireRequests move requests open for expiry blocks at height to expired state in store batch.
+// Open requests index is ordered by open height, so only requests opened at height-expiry or below are read.
+// Return ids of expired requests
+func (s *BlockStore) ExpireRequests(height uint64, expiry uint64) ([][32]byte, error) {
+	if expiry == 0 || height < expiry {
+		return nil, nil
+	}
+	var candidates [][32]byte
+	err := s.iterateOpenRequests(height-expiry+1, func(reqId [32]byte, openHeight, chainId uint64) {
+		candidates = append(candidates, reqId)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var expired [][32]byte
+	for _, reqId := range candidates {
+		record, err := s.getRequestRecord(reqId)
+		if err != nil {
+			return nil, err
+		}
+		// request may be delivered or retried in the current batch
+		if !record.indexed || !record.state.IsOpen() || record.openHeight+expiry > height {
+			continue
+		}
+		if err := s.changeRequestState(reqId, record, payload.ReqStateExpired, height, common.UINT256_EMPTY); err != nil {
+			return nil, err
+		}
+		s.store.BatchPut(s.getExpiredRequestKey(height, reqId), nil)
+		expired = append(expired, reqId)
+	}
+	return expired, nil
+}
+
+// iterateOpenRequests call fn for committed open requests opened at height below toHeight in open height order
+func (s *BlockStore) iterateOpenRequests(toHeight uint64, fn func(reqId [32]byte, openHeight, dstChainId uint64)) error {
+	end := make([]byte, 9)
+	end[0] = byte(scom.IX_OPEN_REQUEST)
+	binary.BigEndian.PutUint64(end[1:], toHeight)
+	iter := s.store.NewRangeIterator([]byte{byte(scom.IX_OPEN_REQUEST)}, end)
+	defer iter.Release()
+	for iter.Next() {
+		openHeight, reqId, ok := parseOpenRequestKey(iter.Key())
+		if ok && len(iter.Value()) == 8 {
+			fn(reqId, openHeight, binary.BigEndian.Uint64(iter.Value()))
+		}
+	}
+	return iter.Error()
+}
+
+// migrateOpenRequests move open requests index entries of destination chain first layout to open height first one
+func (s *BlockStore) migrateOpenRequests() error {
+	iter := s.store.NewIterator([]byte{byte(scom.IX_OPEN_REQUEST)})
+	type legacyEntry struct {
+		key        []byte
+		dstChainId uint64
+		openHeight uint64
+		reqId      [32]byte
+	}
+	var legacy []*legacyEntry
+	for iter.Next() {
+		key := iter.Key()
+		if len(key) != 17+32 {
+			continue
+		}
+		entry := &legacyEntry{
+			key:        append([]byte{}, key...),
+			dstChainId: binary.BigEndian.Uint64(key[1:]),
+			openHeight: binary.BigEndian.Uint64(key[9:]),
+		}
+		copy(entry.reqId[:], key[17:])
+		legacy = append(legacy, entry)
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		return err
+	}
+	if len(legacy) == 0 {
+		return nil
+	}
+	s.store.NewBatch()
+	for _, entry := range legacy {
+		s.store.BatchDelete(entry.key)
+		s.store.BatchPut(s.getOpenRequestKey(entry.openHeight, entry.reqId), openRequestValue(entry.dstChainId))
+	}
+	if err := s.store.BatchCommit(); err != nil {
+		return err
+	}
+	logrus.Infof("Open requests index migrated, %d requests", len(legacy))
+	return nil
+}
+
+// collectExpiredRequests add requests expired automatically at height to requests and remove them from store batch
+func (s *BlockStore) collectExpiredRequests(height uint64, requests map[[32]byte]struct{}) error {
+	expired, err := s.getExpiredRequests(height)
+	if err != nil {
+		return err
+	}
+	for _, reqId := range expired {
+		requests[reqId] = struct{}{}
+		s.store.BatchDelete(s.getExpiredRequestKey(height, reqId))
+	}
+	return nil
+}
+
+// getExpiredRequests return ids of requests expired at height
+func (s *BlockStore) getExpiredRequests(height uint64) ([][32]byte, error) {
+	prefix := make([]byte, 9)
+	prefix[0] = byte(scom.IX_EXPIRED_REQUEST)
+	binary.BigEndian.PutUint64(prefix[1:], height)
+	iter := s.store.NewIterator(prefix)
+	defer iter.Release()
+	var expired [][32]byte
+	for iter.Next() {
+		key := iter.Key()
+		if len(key) != 9+32 {
+			continue
+		}
+		var reqId [32]byte
+		copy(reqId[:], key[9:])
+		expired = append(expired, reqId)
+	}
+	return expired, iter.Error()
+}
+
+func (s *BlockStore) putOpenRequest(reqId [32]byte, record *requestRecord) {
+	if record.indexed && record.state.IsOpen() {
+		s.store.BatchPut(s.getOpenRequestKey(record.openHeight, reqId), openRequestValue(record.dstChainId))
+	}
+}
+
+func (s *BlockStore) deleteOpenRequest(reqId [32]byte, record *requestRecord) {
+	if record.indexed && record.state.IsOpen() {
+		s.store.BatchDelete(s.getOpenRequestKey(record.openHeight, reqId))
+	}
+}
+
+// getOpenRequestKey use big endian height to keep open requests ordered by open height in iteration
+func (s *BlockStore) getOpenRequestKey(openHeight uint64, reqId [32]byte) []byte {
+	key := make([]byte, 9+len(reqId))
+	key[0] = byte(scom.IX_OPEN_REQUEST)
+	binary.BigEndian.PutUint64(key[1:], openHeight)
+	copy(key[9:], reqId[:])
+	return key
+}
+
+func parseOpenRequestKey(key []byte) (openHeight uint64, reqId [32]byte, ok bool) {
+	if len(key) != 9+len(reqId) {
+		return 0, reqId, false
+	}
+	openHeight = binary.BigEndian.Uint64(key[1:])
+	copy(reqId[:], key[9:])
+	return openHeight, reqId, true
+}
+
+// openRequestValue return open requests index value: destination chain id of the request
+func openRequestValue(dstChainId uint64) []byte {
+	value := make([]byte, 8)
+	binary.BigEndian.PutUint64(value, dstChainId)
+	return value
+}
+
+func (s *BlockStore) getExpiredRequestKey(height uint64, reqId [32]byte) []byte {
+	key := make([]byte, 9+len(reqId))
+	key[0] = byte(scom.IX_EXPIRED_REQUEST)
+	binary.BigEndian.PutUint64(key[1:], height)
+	copy(key[9:], reqId[:])
+	return key
+}
+
+// expireRequests move requests undelivered for configured count of blocks to expired state
+func (s *LedgerStoreImp) expireRequests(height uint64) error {
+	expired, err := s.blockStore.ExpireRequests(height, s.GetRequestExpiry())
+	if err != nil {
+		return err
+	}
+	if len(expired) > 0 {
+		logrus.WithFields(logrus.Fields{
+			"height": height,
+			"count":  len(expired),
+		}).Warn("Undelivered requests expired")
+	}
+	return nil
+}
+
+// GetRequestExpiry return count of blocks after which undelivered request is expired
+func (s *LedgerStoreImp) GetRequestExpiry() uint64 {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.requestExpiry
+}
+
+// SetRequestExpiry set count of blocks after which undelivered request is expired, 0 disables expiry
+func (s *LedgerStoreImp) SetRequestExpiry(expiry uint64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.requestExpiry = expiry
+}
+
+// GetOpenRequests return ids of undelivered requests. Wrap function of BlockStore.GetOpenRequests
+func (s *LedgerStoreImp) GetOpenRequests(olderThanHeight uint64, dstChainId uint64) ([][32]byte, error) {
+	return s.blockStore.GetOpenRequests(olderThanHeight, dstChainId)
+}
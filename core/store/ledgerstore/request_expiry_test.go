@@ -0,0 +1,154 @@
+package ledgerstore
+
+import (
+	"encoding/binary"
+	"math/big"
+	"testing"
+
+	"github.com/eywa-protocol/wrappers"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eywa-protocol/chain/common"
+	"github.com/eywa-protocol/chain/core/payload"
+	"github.com/eywa-protocol/chain/core/states"
+	scom "github.com/eywa-protocol/chain/core/store/common"
+	"github.com/eywa-protocol/chain/core/types"
+)
+
+func TestRequestExpiry(t *testing.T) {
+	ledgerStore, err := NewLedgerStore("test/expiry")
+	require.NoError(t, err)
+	defer ledgerStore.Close()
+
+	expiry := uint64(3)
+	genesisBlock := types.NewBlock(0, common.UINT256_EMPTY, common.UINT256_EMPTY, 10, 0, types.Transactions{})
+	require.NoError(t, ledgerStore.InitLedgerStoreWithChainParams(genesisBlock, &states.ChainParams{RequestExpiry: &expiry}))
+	require.Equal(t, expiry, ledgerStore.GetRequestExpiry())
+
+	reqId := [32]byte{0xe, 1}
+	received := &payload.BridgeEvent{
+		OriginData: wrappers.BridgeOracleRequest{
+			RequestType: "setRequest",
+			RequestId:   reqId,
+			ChainId:     big.NewInt(94),
+		}}
+	sent := &payload.ReceiveRequestEvent{
+		OriginData: wrappers.BridgeReceiveRequest{
+			ReqId: reqId,
+		}}
+
+	submitTestBlock(t, ledgerStore, 11, types.Transactions{types.ToTransaction(received)})
+	open, err := ledgerStore.GetOpenRequests(100, 94)
+	require.NoError(t, err)
+	require.Equal(t, [][32]byte{reqId}, open)
+	open, err = ledgerStore.GetOpenRequests(1, 94)
+	require.NoError(t, err)
+	require.Empty(t, open)
+	open, err = ledgerStore.GetOpenRequests(100, 95)
+	require.NoError(t, err)
+	require.Empty(t, open)
+
+	submitTestBlock(t, ledgerStore, 12, types.Transactions{})
+	submitTestBlock(t, ledgerStore, 13, types.Transactions{})
+	state, err := ledgerStore.GetRequestState(reqId)
+	require.NoError(t, err)
+	require.Equal(t, payload.ReqStateReceived, state)
+
+	submitTestBlock(t, ledgerStore, 14, types.Transactions{})
+	state, err = ledgerStore.GetRequestState(reqId)
+	require.NoError(t, err)
+	require.Equal(t, payload.ReqStateExpired, state)
+	open, err = ledgerStore.GetOpenRequests(100, 94)
+	require.NoError(t, err)
+	require.Empty(t, open)
+	history, err := ledgerStore.GetRequestHistory(reqId)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	require.Equal(t, uint64(4), history[1].Height)
+	require.Equal(t, common.UINT256_EMPTY, history[1].TxHash)
+
+	// retried request is open again since retry height
+	retried := payload.NewRequestStateEvent(reqId, payload.ReqStateRetried, 94, nil, "", 1)
+	submitTestBlock(t, ledgerStore, 15, types.Transactions{types.ToTransaction(retried)})
+	open, err = ledgerStore.GetOpenRequests(6, 94)
+	require.NoError(t, err)
+	require.Equal(t, [][32]byte{reqId}, open)
+	open, err = ledgerStore.GetOpenRequests(5, 94)
+	require.NoError(t, err)
+	require.Empty(t, open)
+
+	// rollback reverts automatic expiry
+	require.NoError(t, ledgerStore.RollbackTo(3))
+	state, err = ledgerStore.GetRequestState(reqId)
+	require.NoError(t, err)
+	require.Equal(t, payload.ReqStateReceived, state)
+	open, err = ledgerStore.GetOpenRequests(2, 94)
+	require.NoError(t, err)
+	require.Equal(t, [][32]byte{reqId}, open)
+
+	// request delivered in the block it would expire at
+	submitTestBlock(t, ledgerStore, 14, types.Transactions{types.ToTransaction(sent)})
+	state, err = ledgerStore.GetRequestState(reqId)
+	require.NoError(t, err)
+	require.Equal(t, payload.ReqStateSent, state)
+	open, err = ledgerStore.GetOpenRequests(100, 94)
+	require.NoError(t, err)
+	require.Empty(t, open)
+
+	// expired request is still delivered late
+	reqId2 := [32]byte{0xe, 2}
+	received2 := &payload.BridgeEvent{
+		OriginData: wrappers.BridgeOracleRequest{
+			RequestType: "setRequest",
+			RequestId:   reqId2,
+			ChainId:     big.NewInt(94),
+		}}
+	sent2 := &payload.ReceiveRequestEvent{
+		OriginData: wrappers.BridgeReceiveRequest{
+			ReqId: reqId2,
+		}}
+	submitTestBlock(t, ledgerStore, 15, types.Transactions{types.ToTransaction(received2)})
+	for srcHeight := uint64(16); srcHeight <= 18; srcHeight++ {
+		submitTestBlock(t, ledgerStore, srcHeight, types.Transactions{})
+	}
+	state, err = ledgerStore.GetRequestState(reqId2)
+	require.NoError(t, err)
+	require.Equal(t, payload.ReqStateExpired, state)
+	submitTestBlock(t, ledgerStore, 19, types.Transactions{types.ToTransaction(sent2)})
+	state, err = ledgerStore.GetRequestState(reqId2)
+	require.NoError(t, err)
+	require.Equal(t, payload.ReqStateSent, state)
+
+	// expiry saved at genesis overrides the node setting on load
+	require.NoError(t, ledgerStore.Close())
+	ledgerStore, err = NewLedgerStore("test/expiry")
+	require.NoError(t, err)
+	defer ledgerStore.Close()
+	ledgerStore.SetRequestExpiry(100)
+	require.NoError(t, ledgerStore.LoadLedgerStore())
+	require.Equal(t, expiry, ledgerStore.GetRequestExpiry())
+}
+
+func TestMigrateOpenRequests(t *testing.T) {
+	store, err := NewBlockStore("", "test/migrate_open_requests", false)
+	require.NoError(t, err)
+	defer store.Close()
+
+	reqId := [32]byte{0xe, 3}
+	legacyKey := make([]byte, 17+len(reqId))
+	legacyKey[0] = byte(scom.IX_OPEN_REQUEST)
+	binary.BigEndian.PutUint64(legacyKey[1:], 94)
+	binary.BigEndian.PutUint64(legacyKey[9:], 5)
+	copy(legacyKey[17:], reqId[:])
+	require.NoError(t, store.store.Put(legacyKey, nil))
+
+	require.NoError(t, store.migrateOpenRequests())
+	_, err = store.store.Get(legacyKey)
+	require.Equal(t, scom.ErrNotFound, err)
+	open, err := store.GetOpenRequests(6, 94)
+	require.NoError(t, err)
+	require.Equal(t, [][32]byte{reqId}, open)
+	open, err = store.GetOpenRequests(5, 94)
+	require.NoError(t, err)
+	require.Empty(t, open)
+}
@@ -0,0 +1,336 @@
+package ledgerstore
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/eywa-protocol/chain/common"
+	"github.com/eywa-protocol/chain/common/serialization"
+	"github.com/eywa-protocol/chain/core/payload"
+	"github.com/eywa-protocol/chain/core/states"
+	scom "github.com/eywa-protocol/chain/core/store/common"
+	"github.com/eywa-protocol/chain/core/types"
+)
+
+const requestRecordSize = 1 + common.UINT256_SIZE + 8 + 8
+
+// requestRecord is the current state of the request, changed records are kept until batch commit
+type requestRecord struct {
+	state      payload.ReqState
+	txHash     common.Uint256
+	dstChainId uint64 // Destination chain id of the request
+	openHeight uint64 // Ledger height the request was received or retried at
+	indexed    bool   // Record tracks open requests index, false for records saved before the index was introduced
+	seq        uint32 // Count of request history entries
+}
+
+// requestChange is request state change saved in current batch
+type requestChange struct {
+	entry      *states.RequestStateEntry
+	dstChainId uint64
+}
+
+// putRequestState validate request state transition caused by transaction and save the change to request history
+func (s *BlockStore) putRequestState(tx payload.Payload, txHash common.Uint256, height uint64) error {
+	reqId := tx.RequestId()
+	record, err := s.getRequestRecord(reqId)
+	if err != nil {
+		return err
+	}
+	// the same transaction saved again doesn't change request state
+	if record.txHash == txHash {
+		return nil
+	}
+	if record.state == payload.ReqStateUnknown {
+		record.dstChainId, _ = tx.DstChainId()
+		record.indexed = true
+	}
+	if err := s.changeRequestState(reqId, record, tx.RequestState(), height, txHash); err != nil {
+		return err
+	}
+	s.putRequestIndexes(tx, height)
+	return nil
+}
+
+// verifyRequestStates check request state transitions of the transactions in their order against committed
+// request states. It's called before the block is written, so block of illegal transition is rejected as a whole
+func (s *BlockStore) verifyRequestStates(txs types.Transactions) error {
+	pending := make(map[[32]byte]*requestRecord)
+	for _, tx := range txs {
+		next := tx.Payload.RequestState()
+		if next == payload.ReqStateUnknown {
+			continue
+		}
+		reqId := tx.Payload.RequestId()
+		record, ok := pending[reqId]
+		if !ok {
+			record = new(requestRecord)
+			value, err := s.store.Get(s.getRequestIdKey(reqId))
+			if err != nil && err != scom.ErrNotFound {
+				return err
+			}
+			if err == nil {
+				if len(value) < 1+common.UINT256_SIZE {
+					return fmt.Errorf("request %x record length %d is invalid", reqId, len(value))
+				}
+				record.state = payload.ReqState(value[0])
+				copy(record.txHash[:], value[1:1+common.UINT256_SIZE])
+			}
+			pending[reqId] = record
+		}
+		txHash := tx.Hash()
+		// the same transaction saved again doesn't change request state
+		if record.txHash == txHash {
+			continue
+		}
+		if !record.state.CanTransitTo(next) {
+			return fmt.Errorf("request %x transaction %s illegal state transition %s => %s",
+				reqId, txHash.ToHexString(), record.state, next)
+		}
+		record.state, record.txHash = next, txHash
+	}
+	return nil
+}
+
+// changeRequestState save request state change to request history and keep open requests index in sync
+func (s *BlockStore) changeRequestState(reqId [32]byte, record *requestRecord, next payload.ReqState, height uint64, txHash common.Uint256) error {
+	if !record.state.CanTransitTo(next) {
+		return fmt.Errorf("request %x illegal state transition %s => %s", reqId, record.state, next)
+	}
+	entry := &states.RequestStateEntry{
+		RequestId: reqId,
+		PrevState: record.state,
+		State:     next,
+		Height:    height,
+		TxHash:    txHash,
+	}
+	value := bytes.NewBuffer(nil)
+	if err := entry.Serialize(value); err != nil {
+		return err
+	}
+	s.store.BatchPut(s.getRequestHistoryKey(reqId, record.seq), value.Bytes())
+
+	s.deleteOpenRequest(reqId, record)
+	record.state = next
+	// state changed without transaction keeps the last transaction of the request
+	if txHash != common.UINT256_EMPTY {
+		record.txHash = txHash
+	}
+	record.seq++
+	if next == payload.ReqStateReceived || next == payload.ReqStateRetried {
+		record.openHeight = height
+	}
+	s.putOpenRequest(reqId, record)
+
+	s.requests[reqId] = record
+	s.store.BatchPut(s.getRequestIdKey(reqId), record.value())
+	s.changes = append(s.changes, &requestChange{entry: entry, dstChainId: record.dstChainId})
+	return nil
+}
+
+// getRequestRecord return current request record including changes of the batch
+func (s *BlockStore) getRequestRecord(reqId [32]byte) (*requestRecord, error) {
+	if record, ok := s.requests[reqId]; ok {
+		return record, nil
+	}
+	return s.loadRequestRecord(reqId)
+}
+
+// loadRequestRecord return committed request record
+func (s *BlockStore) loadRequestRecord(reqId [32]byte) (*requestRecord, error) {
+	record := new(requestRecord)
+	value, err := s.store.Get(s.getRequestIdKey(reqId))
+	if err == scom.ErrNotFound {
+		return record, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if len(value) < 1+common.UINT256_SIZE {
+		return nil, fmt.Errorf("request %x record length %d is invalid", reqId, len(value))
+	}
+	record.state = payload.ReqState(value[0])
+	copy(record.txHash[:], value[1:1+common.UINT256_SIZE])
+	if len(value) >= requestRecordSize {
+		record.dstChainId = binary.BigEndian.Uint64(value[1+common.UINT256_SIZE:])
+		record.openHeight = binary.BigEndian.Uint64(value[1+common.UINT256_SIZE+8:])
+		record.indexed = true
+	}
+	history, err := s.GetRequestHistory(reqId)
+	if err != nil {
+		return nil, err
+	}
+	record.seq = uint32(len(history))
+	return record, nil
+}
+
+// getRequestChanges return request state changes committed with the block:
+// changes caused by block transactions in their order followed by requests expired at block height
+func (s *BlockStore) getRequestChanges(block *types.Block) ([]*requestChange, error) {
+	height := block.Header.Height
+	var changes []*requestChange
+	for _, tx := range block.Transactions {
+		if tx.Payload.RequestState() == payload.ReqStateUnknown {
+			continue
+		}
+		txHash := tx.Hash()
+		found, err := s.findRequestChanges(tx.Payload.RequestId(), func(entry *states.RequestStateEntry) bool {
+			return entry.Height == height && entry.TxHash == txHash
+		})
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, found...)
+	}
+	expired, err := s.getExpiredRequests(height)
+	if err != nil {
+		return nil, err
+	}
+	for _, reqId := range expired {
+		found, err := s.findRequestChanges(reqId, func(entry *states.RequestStateEntry) bool {
+			return entry.Height == height && entry.State == payload.ReqStateExpired && entry.TxHash == common.UINT256_EMPTY
+		})
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, found...)
+	}
+	return changes, nil
+}
+
+// findRequestChanges return committed request history entries matching the filter
+func (s *BlockStore) findRequestChanges(reqId [32]byte, match func(entry *states.RequestStateEntry) bool) ([]*requestChange, error) {
+	history, err := s.GetRequestHistory(reqId)
+	if err != nil {
+		return nil, err
+	}
+	var changes []*requestChange
+	var record *requestRecord
+	for _, entry := range history {
+		if !match(entry) {
+			continue
+		}
+		if record == nil {
+			if record, err = s.loadRequestRecord(reqId); err != nil {
+				return nil, err
+			}
+		}
+		changes = append(changes, &requestChange{entry: entry, dstChainId: record.dstChainId})
+	}
+	return changes, nil
+}
+
+// GetRequestHistory return all state changes of the request in the order they happened
+func (s *BlockStore) GetRequestHistory(reqId [32]byte) ([]*states.RequestStateEntry, error) {
+	prefix := make([]byte, 1+len(reqId))
+	prefix[0] = byte(scom.DATA_REQUEST_HISTORY)
+	copy(prefix[1:], reqId[:])
+	iter := s.store.NewIterator(prefix)
+	defer iter.Release()
+	var history []*states.RequestStateEntry
+	for iter.Next() {
+		entry := new(states.RequestStateEntry)
+		if err := entry.Deserialize(bytes.NewReader(iter.Value())); err != nil {
+			return nil, fmt.Errorf("request %x history entry deserialize error %s", reqId, err)
+		}
+		history = append(history, entry)
+	}
+	return history, iter.Error()
+}
+
+// rollbackRequestState remove request history entries above height and restore request state from the last entry left
+func (s *BlockStore) rollbackRequestState(reqId [32]byte, height uint64) error {
+	history, err := s.GetRequestHistory(reqId)
+	if err != nil {
+		return err
+	}
+	if len(history) == 0 {
+		return s.rollbackLegacyRequestState(reqId, height)
+	}
+	record, err := s.getRequestRecord(reqId)
+	if err != nil {
+		return err
+	}
+	s.deleteOpenRequest(reqId, record)
+	record.seq = 0
+	for seq, entry := range history {
+		if entry.Height > height {
+			s.store.BatchDelete(s.getRequestHistoryKey(reqId, uint32(seq)))
+			continue
+		}
+		record.state = entry.State
+		if entry.TxHash != common.UINT256_EMPTY {
+			record.txHash = entry.TxHash
+		}
+		record.seq = uint32(seq) + 1
+		if entry.State == payload.ReqStateReceived || entry.State == payload.ReqStateRetried {
+			record.openHeight = entry.Height
+		}
+	}
+	if record.seq == 0 {
+		delete(s.requests, reqId)
+		s.store.BatchDelete(s.getRequestIdKey(reqId))
+		return nil
+	}
+	s.putOpenRequest(reqId, record)
+	s.requests[reqId] = record
+	s.store.BatchPut(s.getRequestIdKey(reqId), record.value())
+	return nil
+}
+
+// rollbackLegacyRequestState remove request record saved without history if its transaction is above height
+func (s *BlockStore) rollbackLegacyRequestState(reqId [32]byte, height uint64) error {
+	value, err := s.store.Get(s.getRequestIdKey(reqId))
+	if err == scom.ErrNotFound {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	var txHash common.Uint256
+	copy(txHash[:], value[1:])
+	key, err := s.getTransactionKey(txHash)
+	if err != nil {
+		return err
+	}
+	txValue, err := s.store.Get(key)
+	if err == scom.ErrNotFound {
+		s.store.BatchDelete(s.getRequestIdKey(reqId))
+		return nil
+	} else if err != nil {
+		return err
+	}
+	txHeight, err := serialization.ReadUint64(bytes.NewReader(txValue))
+	if err != nil {
+		return err
+	}
+	if txHeight > height {
+		s.store.BatchDelete(s.getRequestIdKey(reqId))
+	}
+	return nil
+}
+
+// value return request id record value: state + transaction hash + destination chain id + open height.
+// Records saved before open requests index keep state + transaction hash layout
+func (r *requestRecord) value() []byte {
+	if !r.indexed {
+		value := make([]byte, 1+common.UINT256_SIZE)
+		value[0] = byte(r.state)
+		copy(value[1:], r.txHash[:])
+		return value
+	}
+	value := make([]byte, requestRecordSize)
+	value[0] = byte(r.state)
+	copy(value[1:], r.txHash[:])
+	binary.BigEndian.PutUint64(value[1+common.UINT256_SIZE:], r.dstChainId)
+	binary.BigEndian.PutUint64(value[1+common.UINT256_SIZE+8:], r.openHeight)
+	return value
+}
+
+// getRequestHistoryKey use big endian sequence number to keep history ordered in iteration
+func (s *BlockStore) getRequestHistoryKey(reqId [32]byte, seq uint32) []byte {
+	key := make([]byte, 1+len(reqId)+4)
+	key[0] = byte(scom.DATA_REQUEST_HISTORY)
+	copy(key[1:], reqId[:])
+	binary.BigEndian.PutUint32(key[1+len(reqId):], seq)
+	return key
+}
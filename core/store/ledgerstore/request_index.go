@@ -0,0 +1,217 @@
+package ledgerstore
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/eywa-protocol/chain/core/payload"
+	scom "github.com/eywa-protocol/chain/core/store/common"
+	"github.com/sirupsen/logrus"
+)
+
+const requestIndexBatchBlocks = 1000 // Count of blocks indexed in one batch by request indexes backfill
+
+// putRequestIndexes index request transaction by source transaction hash,
+// and the received request by destination chain and bridge addresses
+func (s *BlockStore) putRequestIndexes(tx payload.Payload, height uint64) {
+	reqId := tx.RequestId()
+	if srcTxHash := tx.SrcTxHash(); len(srcTxHash) > 0 {
+		s.store.BatchPut(s.getRequestSrcTxKey(srcTxHash, reqId), nil)
+	}
+	if tx.RequestState() != payload.ReqStateReceived {
+		return
+	}
+	dstChainId, _ := tx.DstChainId()
+	s.store.BatchPut(s.getRequestDstChainKey(dstChainId, height, reqId), nil)
+	for _, address := range bridgeAddresses(tx) {
+		s.store.BatchPut(s.getRequestBridgeKey(address, height, reqId), nil)
+	}
+}
+
+// deleteRequestIndexes remove indexes of the request transaction saved at height from store batch
+func (s *BlockStore) deleteRequestIndexes(tx payload.Payload, height uint64) {
+	reqId := tx.RequestId()
+	if srcTxHash := tx.SrcTxHash(); len(srcTxHash) > 0 {
+		s.store.BatchDelete(s.getRequestSrcTxKey(srcTxHash, reqId))
+	}
+	if tx.RequestState() != payload.ReqStateReceived {
+		return
+	}
+	dstChainId, _ := tx.DstChainId()
+	s.store.BatchDelete(s.getRequestDstChainKey(dstChainId, height, reqId))
+	for _, address := range bridgeAddresses(tx) {
+		s.store.BatchDelete(s.getRequestBridgeKey(address, height, reqId))
+	}
+}
+
+// GetRequestsByDstChain return ids of the requests to destination chain received in [fromHeight, toHeight].
+// Skip offset requests and return up to limit requests, 0 limit means no limit.
+// Requests of blocks pruned before the index was introduced are not indexed, see GetRequestIndexHeight
+func (s *BlockStore) GetRequestsByDstChain(dstChainId uint64, fromHeight, toHeight uint64, offset, limit int) ([][32]byte, error) {
+	if fromHeight > toHeight {
+		return nil, nil
+	}
+	prefix := make([]byte, 9)
+	prefix[0] = byte(scom.IX_REQUEST_DST_CHAIN)
+	binary.BigEndian.PutUint64(prefix[1:], dstChainId)
+	start := make([]byte, 17)
+	copy(start, prefix)
+	binary.BigEndian.PutUint64(start[9:], fromHeight)
+	// end key is above all keys of toHeight: its request id part is longer than request id and all bits set
+	end := make([]byte, 17+32+1)
+	copy(end, start)
+	binary.BigEndian.PutUint64(end[9:], toHeight)
+	for i := 17; i < len(end); i++ {
+		end[i] = 0xff
+	}
+	return s.iterateRequestIndex(s.store.NewRangeIterator(start, end), len(prefix), offset, limit)
+}
+
+// GetRequestsBySrcTxHash return ids of the requests with transaction hash (signature for solana) on source or destination chain
+func (s *BlockStore) GetRequestsBySrcTxHash(srcTxHash []byte) ([][32]byte, error) {
+	prefix := make([]byte, 0, 2+len(srcTxHash))
+	prefix = append(prefix, byte(scom.IX_REQUEST_SRC_TX), byte(len(srcTxHash)))
+	prefix = append(prefix, srcTxHash...)
+	return s.iterateRequestIndex(s.store.NewIterator(prefix), len(prefix), 0, 0)
+}
+
+// GetRequestsByBridgeAddress return ids of the requests of source bridge or destination receive side address
+// in the order they were received. Skip offset requests and return up to limit requests, 0 limit means no limit
+func (s *BlockStore) GetRequestsByBridgeAddress(address []byte, offset, limit int) ([][32]byte, error) {
+	prefix := make([]byte, 0, 2+len(address))
+	prefix = append(prefix, byte(scom.IX_REQUEST_BRIDGE), byte(len(address)))
+	prefix = append(prefix, address...)
+	return s.iterateRequestIndex(s.store.NewIterator(prefix), len(prefix), offset, limit)
+}
+
+// iterateRequestIndex collect request ids ending index keys of the iterator, keys shorter than prefix
+// length and request id are skipped
+func (s *BlockStore) iterateRequestIndex(iter scom.StoreIterator, prefixLen int, offset, limit int) ([][32]byte, error) {
+	defer iter.Release()
+	var reqIds [][32]byte
+	for iter.Next() {
+		key := iter.Key()
+		if len(key) < prefixLen+32 {
+			continue
+		}
+		if offset > 0 {
+			offset--
+			continue
+		}
+		var reqId [32]byte
+		copy(reqId[:], key[len(key)-32:])
+		reqIds = append(reqIds, reqId)
+		if limit > 0 && len(reqIds) >= limit {
+			break
+		}
+	}
+	return reqIds, iter.Error()
+}
+
+// indexRequests backfill request indexes of the blocks saved before the indexes were introduced and save the height
+// indexes start at, so backfill runs once. Bodies of pruned blocks are not available, indexes start at pruned height then
+func (s *BlockStore) indexRequests(currHeight uint64) error {
+	_, err := s.GetRequestIndexHeight()
+	if err == nil {
+		return nil
+	} else if err != scom.ErrNotFound {
+		return err
+	}
+	from := s.GetPrunedHeight()
+	s.store.NewBatch()
+	for height := from; height <= currHeight; height++ {
+		blockHash, err := s.GetBlockHash(height)
+		if err != nil {
+			return fmt.Errorf("GetBlockHash %d error %s", height, err)
+		}
+		block, err := s.GetBlock(blockHash)
+		if err != nil {
+			return fmt.Errorf("GetBlock %d error %s", height, err)
+		}
+		for _, tx := range block.Transactions {
+			if tx.Payload.RequestState() != payload.ReqStateUnknown {
+				s.putRequestIndexes(tx.Payload, height)
+			}
+		}
+		if (height-from+1)%requestIndexBatchBlocks == 0 {
+			if err := s.store.BatchCommit(); err != nil {
+				return err
+			}
+			s.store.NewBatch()
+		}
+	}
+	if err := s.store.BatchCommit(); err != nil {
+		return err
+	}
+	if err := s.SaveRequestIndexHeight(from); err != nil {
+		return err
+	}
+	logrus.Infof("Request indexes built from height %d to %d", from, currHeight)
+	return nil
+}
+
+// SaveRequestIndexHeight persist height request indexes start at
+func (s *BlockStore) SaveRequestIndexHeight(height uint64) error {
+	value := make([]byte, 8)
+	binary.BigEndian.PutUint64(value, height)
+	return s.store.Put(s.getRequestIndexHeightKey(), value)
+}
+
+// GetRequestIndexHeight return height request indexes start at, requests received below it are not indexed
+func (s *BlockStore) GetRequestIndexHeight() (uint64, error) {
+	value, err := s.store.Get(s.getRequestIndexHeightKey())
+	if err != nil {
+		return 0, err
+	}
+	if len(value) != 8 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	return binary.BigEndian.Uint64(value), nil
+}
+
+// bridgeAddresses return distinct non empty bridge addresses of the request
+func bridgeAddresses(tx payload.Payload) [][]byte {
+	request, ok := tx.(payload.BridgeRequest)
+	if !ok {
+		return nil
+	}
+	var addresses [][]byte
+	for _, address := range request.BridgeAddresses() {
+		if len(address) == 0 || len(addresses) > 0 && bytes.Equal(addresses[0], address) {
+			continue
+		}
+		addresses = append(addresses, address)
+	}
+	return addresses
+}
+
+func (s *BlockStore) getRequestIndexHeightKey() []byte {
+	return []byte{byte(scom.SYS_REQUEST_INDEX_HEIGHT)}
+}
+
+func (s *BlockStore) getRequestDstChainKey(dstChainId, height uint64, reqId [32]byte) []byte {
+	key := make([]byte, 17+len(reqId))
+	key[0] = byte(scom.IX_REQUEST_DST_CHAIN)
+	binary.BigEndian.PutUint64(key[1:], dstChainId)
+	binary.BigEndian.PutUint64(key[9:], height)
+	copy(key[17:], reqId[:])
+	return key
+}
+
+func (s *BlockStore) getRequestSrcTxKey(srcTxHash []byte, reqId [32]byte) []byte {
+	key := make([]byte, 0, 2+len(srcTxHash)+len(reqId))
+	key = append(key, byte(scom.IX_REQUEST_SRC_TX), byte(len(srcTxHash)))
+	key = append(key, srcTxHash...)
+	return append(key, reqId[:]...)
+}
+
+func (s *BlockStore) getRequestBridgeKey(address []byte, height uint64, reqId [32]byte) []byte {
+	key := make([]byte, 0, 2+len(address)+8+len(reqId))
+	key = append(key, byte(scom.IX_REQUEST_BRIDGE), byte(len(address)))
+	key = append(key, address...)
+	key = append(key, make([]byte, 8)...)
+	binary.BigEndian.PutUint64(key[len(key)-8:], height)
+	return append(key, reqId[:]...)
+}
@@ -0,0 +1,112 @@
+package ledgerstore
+
+import (
+	"math/big"
+	"testing"
+
+	ethCommon "github.com/ethereum/go-ethereum/common"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/eywa-protocol/wrappers"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eywa-protocol/chain/common"
+	"github.com/eywa-protocol/chain/core/payload"
+	"github.com/eywa-protocol/chain/core/types"
+)
+
+func newIndexTestRequest(reqId [32]byte, chainId int64, bridge, receiveSide ethCommon.Address) types.Transactions {
+	return types.Transactions{types.ToTransaction(&payload.BridgeEvent{
+		OriginData: wrappers.BridgeOracleRequest{
+			RequestType: "setRequest",
+			Bridge:      bridge,
+			ReceiveSide: receiveSide,
+			RequestId:   reqId,
+			ChainId:     big.NewInt(chainId),
+			Raw:         ethTypes.Log{TxHash: ethCommon.Hash{reqId[0], 0xff}},
+		}})}
+}
+
+func TestRequestIndexes(t *testing.T) {
+	ledgerStore, err := NewLedgerStore("test/index")
+	require.NoError(t, err)
+	defer ledgerStore.Close()
+
+	genesisBlock := types.NewBlock(0, common.UINT256_EMPTY, common.UINT256_EMPTY, 10, 0, types.Transactions{})
+	require.NoError(t, ledgerStore.InitLedgerStoreWithGenesisBlock(genesisBlock))
+
+	bridge := ethCommon.HexToAddress("0x0c760E9A85d2E957Dd1E189516b6658CfEcD3985")
+	receiveSide1 := ethCommon.HexToAddress("0x1111111111111111111111111111111111111111")
+	receiveSide2 := ethCommon.HexToAddress("0x2222222222222222222222222222222222222222")
+	reqId1, reqId2, reqId3, reqId4 := [32]byte{1}, [32]byte{2}, [32]byte{3}, [32]byte{4}
+
+	submitTestBlock(t, ledgerStore, 11, newIndexTestRequest(reqId1, 94, bridge, receiveSide1))
+	submitTestBlock(t, ledgerStore, 12, append(newIndexTestRequest(reqId2, 94, bridge, receiveSide2),
+		newIndexTestRequest(reqId3, 95, bridge, receiveSide1)...))
+	submitTestBlock(t, ledgerStore, 13, newIndexTestRequest(reqId4, 94, bridge, receiveSide2))
+	sent := &payload.ReceiveRequestEvent{
+		OriginData: wrappers.BridgeReceiveRequest{
+			ReqId: reqId1,
+			Raw:   ethTypes.Log{TxHash: ethCommon.Hash{0xde, 0xad}},
+		}}
+	submitTestBlock(t, ledgerStore, 14, types.Transactions{types.ToTransaction(sent)})
+
+	reqIds, err := ledgerStore.GetRequestsByDstChain(94, 0, 100, 0, 0)
+	require.NoError(t, err)
+	require.Equal(t, [][32]byte{reqId1, reqId2, reqId4}, reqIds)
+	reqIds, err = ledgerStore.GetRequestsByDstChain(94, 2, 3, 0, 0)
+	require.NoError(t, err)
+	require.Equal(t, [][32]byte{reqId2, reqId4}, reqIds)
+	reqIds, err = ledgerStore.GetRequestsByDstChain(94, 0, 100, 1, 1)
+	require.NoError(t, err)
+	require.Equal(t, [][32]byte{reqId2}, reqIds)
+	reqIds, err = ledgerStore.GetRequestsByDstChain(95, 0, 1, 0, 0)
+	require.NoError(t, err)
+	require.Empty(t, reqIds)
+
+	reqIds, err = ledgerStore.GetRequestsBySrcTxHash(ethCommon.Hash{3, 0xff}.Bytes())
+	require.NoError(t, err)
+	require.Equal(t, [][32]byte{reqId3}, reqIds)
+	reqIds, err = ledgerStore.GetRequestsBySrcTxHash(ethCommon.Hash{0xde, 0xad}.Bytes())
+	require.NoError(t, err)
+	require.Equal(t, [][32]byte{reqId1}, reqIds)
+
+	reqIds, err = ledgerStore.GetRequestsByBridgeAddress(bridge.Bytes(), 0, 0)
+	require.NoError(t, err)
+	require.Len(t, reqIds, 4)
+	reqIds, err = ledgerStore.GetRequestsByBridgeAddress(receiveSide1.Bytes(), 0, 0)
+	require.NoError(t, err)
+	require.Equal(t, [][32]byte{reqId1, reqId3}, reqIds)
+	reqIds, err = ledgerStore.GetRequestsByBridgeAddress(receiveSide2.Bytes(), 1, 10)
+	require.NoError(t, err)
+	require.Equal(t, [][32]byte{reqId4}, reqIds)
+
+	require.NoError(t, ledgerStore.RollbackTo(2))
+	reqIds, err = ledgerStore.GetRequestsByDstChain(94, 0, 100, 0, 0)
+	require.NoError(t, err)
+	require.Equal(t, [][32]byte{reqId1, reqId2}, reqIds)
+	reqIds, err = ledgerStore.GetRequestsBySrcTxHash(ethCommon.Hash{0xde, 0xad}.Bytes())
+	require.NoError(t, err)
+	require.Empty(t, reqIds)
+	reqIds, err = ledgerStore.GetRequestsByBridgeAddress(receiveSide2.Bytes(), 0, 0)
+	require.NoError(t, err)
+	require.Equal(t, [][32]byte{reqId2}, reqIds)
+
+	// indexes of ledger saved before request indexes are built once on load
+	height, err := ledgerStore.blockStore.GetRequestIndexHeight()
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), height)
+	blockStore := ledgerStore.blockStore
+	blockStore.NewBatch()
+	blockStore.store.BatchDelete(blockStore.getRequestIndexHeightKey())
+	blockStore.store.BatchDelete(blockStore.getRequestDstChainKey(94, 1, reqId1))
+	require.NoError(t, blockStore.CommitTo())
+	reqIds, err = ledgerStore.GetRequestsByDstChain(94, 0, 100, 0, 0)
+	require.NoError(t, err)
+	require.Equal(t, [][32]byte{reqId2}, reqIds)
+	require.NoError(t, blockStore.indexRequests(ledgerStore.GetCurrentBlockHeight()))
+	reqIds, err = ledgerStore.GetRequestsByDstChain(94, 0, 100, 0, 0)
+	require.NoError(t, err)
+	require.Equal(t, [][32]byte{reqId1, reqId2}, reqIds)
+	_, err = blockStore.GetRequestIndexHeight()
+	require.NoError(t, err)
+}
@@ -0,0 +1,149 @@
+package ledgerstore
+
+import (
+	"fmt"
+	"os"
+
+	scom "github.com/eywa-protocol/chain/core/store/common"
+	"github.com/eywa-protocol/chain/core/store/sharedstore"
+	"github.com/sirupsen/logrus"
+)
+
+// Key space prefixes of stores kept in single database
+const (
+	blockNamespace byte = 0x01
+	stateNamespace byte = 0x02
+	eventNamespace byte = 0x03
+)
+
+const migrateBatchSize = 10000 // Count of keys copied in one batch by MigrateToSingleDB
+
+// openSingleDB open block, state and event stores as key spaces of one database
+func (s *LedgerStoreImp) openSingleDB(dataDir string, backend string) error {
+	dbPath := fmt.Sprintf("%s%s%s", dataDir, string(os.PathSeparator), DBDirLedger)
+	store, err := scom.OpenPersistStore(backend, dbPath)
+	if err != nil {
+		return fmt.Errorf("OpenPersistStore error %s", err)
+	}
+	s.sharedStore = sharedstore.NewSharedStore(store)
+
+	blockStore, err := newBlockStore(s.sharedStore.Namespace(blockNamespace), dbPath, true)
+	if err != nil {
+		return fmt.Errorf("NewBlockStore error %s", err)
+	}
+	s.blockStore = blockStore
+
+	merklePath := fmt.Sprintf("%s%s%s", dataDir, string(os.PathSeparator), MerkleTreeStorePath)
+	stateStore, err := newStateStore(s.sharedStore.Namespace(stateNamespace), dbPath, merklePath)
+	if err != nil {
+		return fmt.Errorf("NewStateStore error %s", err)
+	}
+	s.stateStore = stateStore
+
+	s.eventStore = newEventStore(s.sharedStore.Namespace(eventNamespace), dbPath)
+	return nil
+}
+
+// beginAtomicCommit defer commit of store batches until endAtomicCommit if stores are kept in single database
+func (s *LedgerStoreImp) beginAtomicCommit() {
+	if s.sharedStore != nil {
+		s.sharedStore.Begin()
+	}
+}
+
+// endAtomicCommit write store batches deferred by beginAtomicCommit in one batch, they are dropped if err is not nil
+func (s *LedgerStoreImp) endAtomicCommit(err error) error {
+	if s.sharedStore == nil {
+		return err
+	}
+	if err != nil {
+		s.sharedStore.Discard()
+		return err
+	}
+	return s.sharedStore.Commit()
+}
+
+// MigrateToSingleDB copy block, state and event stores of dataDir opened with config backends
+// to single database opened with config.BlockBackend. Source databases are left untouched
+// and may be removed after the ledger is opened with SingleDB config
+func MigrateToSingleDB(dataDir string, config StoreConfig) error {
+	target := fmt.Sprintf("%s%s%s", dataDir, string(os.PathSeparator), DBDirLedger)
+	if _, err := os.Stat(target); err == nil {
+		return fmt.Errorf("single database %s already exists", target)
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	sources := []struct {
+		dir       string
+		backend   string
+		namespace byte
+	}{
+		{DBDirBlock, config.BlockBackend, blockNamespace},
+		{DBDirState, config.StateBackend, stateNamespace},
+		{DBDirEvent, config.EventBackend, eventNamespace},
+	}
+	for _, source := range sources {
+		path := fmt.Sprintf("%s%s%s", dataDir, string(os.PathSeparator), source.dir)
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("source database %s error %s", path, err)
+		}
+	}
+
+	store, err := scom.OpenPersistStore(config.BlockBackend, target)
+	if err != nil {
+		return fmt.Errorf("OpenPersistStore error %s", err)
+	}
+	shared := sharedstore.NewSharedStore(store)
+	namespaces := make([]scom.PersistStore, 0, len(sources))
+	for _, source := range sources {
+		namespaces = append(namespaces, shared.Namespace(source.namespace))
+	}
+	for i, source := range sources {
+		path := fmt.Sprintf("%s%s%s", dataDir, string(os.PathSeparator), source.dir)
+		count, copyErr := copyStore(source.backend, path, namespaces[i])
+		if copyErr != nil {
+			err = fmt.Errorf("migrate %s error %s", path, copyErr)
+			break
+		}
+		logrus.Infof("migrated %d keys of %s", count, path)
+	}
+	for _, namespace := range namespaces {
+		if closeErr := namespace.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		// target did not exist before migration
+		_ = os.RemoveAll(target)
+		return err
+	}
+	return nil
+}
+
+// copyStore copy all keys of the store at path to dst, return count of copied keys
+func copyStore(backend, path string, dst scom.PersistStore) (int, error) {
+	src, err := scom.OpenPersistStore(backend, path)
+	if err != nil {
+		return 0, err
+	}
+	defer src.Close()
+	iter := src.NewIterator(nil)
+	defer iter.Release()
+
+	count := 0
+	dst.NewBatch()
+	for iter.Next() {
+		dst.BatchPut(iter.Key(), iter.Value())
+		count++
+		if count%migrateBatchSize == 0 {
+			if err := dst.BatchCommit(); err != nil {
+				return count, err
+			}
+			dst.NewBatch()
+		}
+	}
+	if err := iter.Error(); err != nil {
+		return count, err
+	}
+	return count, dst.BatchCommit()
+}
@@ -0,0 +1,75 @@
+package ledgerstore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	ethCommon "github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eywa-protocol/chain/common"
+	"github.com/eywa-protocol/chain/core/payload"
+	"github.com/eywa-protocol/chain/core/types"
+)
+
+func openTestLedgerStore(t *testing.T, dataDir string, config StoreConfig) *LedgerStoreImp {
+	ledgerStore, err := NewLedgerStoreWithConfig(dataDir, config)
+	require.NoError(t, err)
+	genesisBlock := types.NewBlock(0, common.UINT256_EMPTY, common.UINT256_EMPTY, 10, 0, types.Transactions{})
+	require.NoError(t, ledgerStore.InitLedgerStoreWithGenesisBlock(genesisBlock))
+	return ledgerStore
+}
+
+func TestSingleDB(t *testing.T) {
+	dataDir := t.TempDir()
+	ledgerStore := openTestLedgerStore(t, dataDir, StoreConfig{SingleDB: true})
+	bridge := ethCommon.HexToAddress("0x0c760E9A85d2E957Dd1E189516b6658CfEcD3985")
+	reqId := [32]byte{1}
+	submitTestBlock(t, ledgerStore, 11, newIndexTestRequest(reqId, 94, bridge, bridge))
+	block := submitTestBlock(t, ledgerStore, 12, types.Transactions{})
+	require.NoError(t, ledgerStore.RollbackTo(1))
+	block = submitTestBlock(t, ledgerStore, 13, types.Transactions{})
+	require.NoError(t, ledgerStore.Close())
+
+	_, err := os.Stat(filepath.Join(dataDir, DBDirBlock))
+	require.True(t, os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(dataDir, DBDirLedger))
+	require.NoError(t, err)
+
+	ledgerStore = openTestLedgerStore(t, dataDir, StoreConfig{SingleDB: true})
+	defer ledgerStore.Close()
+	require.Equal(t, uint64(2), ledgerStore.GetCurrentBlockHeight())
+	require.Equal(t, block.Hash(), ledgerStore.GetCurrentBlockHash())
+	_, stateHeight, err := ledgerStore.stateStore.GetCurrentBlock()
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), stateHeight)
+	state, err := ledgerStore.GetRequestState(reqId)
+	require.NoError(t, err)
+	require.Equal(t, payload.ReqStateReceived, state)
+}
+
+func TestMigrateToSingleDB(t *testing.T) {
+	dataDir := t.TempDir()
+	ledgerStore := openTestLedgerStore(t, dataDir, StoreConfig{})
+	bridge := ethCommon.HexToAddress("0x0c760E9A85d2E957Dd1E189516b6658CfEcD3985")
+	reqId := [32]byte{1}
+	submitTestBlock(t, ledgerStore, 11, newIndexTestRequest(reqId, 94, bridge, bridge))
+	block := submitTestBlock(t, ledgerStore, 12, types.Transactions{})
+	require.NoError(t, ledgerStore.Close())
+
+	require.Error(t, MigrateToSingleDB(t.TempDir(), StoreConfig{}))
+	require.NoError(t, MigrateToSingleDB(dataDir, StoreConfig{}))
+	require.Error(t, MigrateToSingleDB(dataDir, StoreConfig{}))
+
+	ledgerStore = openTestLedgerStore(t, dataDir, StoreConfig{SingleDB: true})
+	defer ledgerStore.Close()
+	require.Equal(t, uint64(2), ledgerStore.GetCurrentBlockHeight())
+	require.Equal(t, block.Hash(), ledgerStore.GetCurrentBlockHash())
+	state, err := ledgerStore.GetRequestState(reqId)
+	require.NoError(t, err)
+	require.Equal(t, payload.ReqStateReceived, state)
+	reqIds, err := ledgerStore.GetRequestsByDstChain(94, 0, 10, 0, 0)
+	require.NoError(t, err)
+	require.Equal(t, [][32]byte{reqId}, reqIds)
+}
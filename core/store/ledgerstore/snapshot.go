@@ -0,0 +1,603 @@
+package ledgerstore
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/eywa-protocol/chain/common"
+	"github.com/eywa-protocol/chain/common/serialization"
+	scom "github.com/eywa-protocol/chain/core/store/common"
+	"github.com/eywa-protocol/chain/core/types"
+	"github.com/eywa-protocol/chain/merkle"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	SNAPSHOT_VERSION   = byte(2)       // Version of ledger snapshot format
+	snapshotCommitSize = uint64(10000) // Count of imported records committed in one batch
+)
+
+// Snapshot record kinds
+const (
+	snapshotEnd             = byte(0x00) // End of records, sha256 digest of the snapshot follows
+	snapshotHeader          = byte(0x01) // Header with transaction hashes as saved by SaveHeader
+	snapshotBlock           = byte(0x02) // Block at snapshot height
+	snapshotBlockMerkleTree = byte(0x03) // Compact block merkle tree at snapshot height
+	snapshotBlockEntry      = byte(0x04) // Block store key and value
+	snapshotStateEntry      = byte(0x05) // State store key and value
+	snapshotEpochBlock      = byte(0x06) // Block including epoch event in place of its header
+)
+
+var snapshotMagic = []byte("EYWASNAP")
+
+// Request states, request history, source events and request indexes are kept in snapshot
+var snapshotBlockPrefixes = []scom.DataEntryPrefix{
+	scom.DATA_REQUEST_ID,
+	scom.DATA_REQUEST_HISTORY,
+	scom.DATA_SOURCE_EVENT,
+	scom.IX_SOURCE_PENDING,
+	scom.IX_OPEN_REQUEST,
+	scom.IX_EXPIRED_REQUEST,
+	scom.IX_REQUEST_DST_CHAIN,
+	scom.IX_REQUEST_SRC_TX,
+	scom.IX_REQUEST_BRIDGE,
+}
+
+// Contract states, state merkle tree, processed height and chain parameters are kept in snapshot.
+// Epochs are not, they are applied from epoch blocks of the snapshot
+var snapshotStatePrefixes = []scom.DataEntryPrefix{
+	scom.ST_CONTRACT,
+	scom.ST_STORAGE,
+	scom.ST_VOTE,
+	scom.SYS_STATE_MERKLE_TREE,
+	scom.SYS_PROCESSED_SRC_HEIGHT,
+	scom.SYS_CHAIN_PARAMS,
+}
+
+// State merkle root and cross states are kept in snapshot for snapshot height only
+var snapshotHeightPrefixes = []scom.DataEntryPrefix{
+	scom.DATA_STATE_MERKLE_ROOT,
+	scom.SYS_CROSS_STATES,
+	scom.SYS_CROSS_STATES_HASH,
+}
+
+// ExportSnapshot write snapshot of the ledger at height to w. Snapshot contains headers with transaction hashes,
+// blocks including epoch events, the block at height, block merkle tree, request states and indexes, contract states
+// and processed height. State is kept for the current block only, so height must be the current block height.
+// Block saving waits until export is done
+func (s *LedgerStoreImp) ExportSnapshot(height uint64, w io.Writer) error {
+	s.getSavingBlockLock()
+	defer s.releaseSavingBlockLock()
+
+	currHeight, blockHash := s.GetCurrentBlock()
+	if height != currHeight {
+		return fmt.Errorf("snapshot height %d is not current block height %d", height, currHeight)
+	}
+	block, err := s.blockStore.GetBlock(blockHash)
+	if err != nil {
+		return fmt.Errorf("GetBlock height %d error %s", height, err)
+	}
+	blockData, err := block.ToArray()
+	if err != nil {
+		return err
+	}
+	treeData, err := s.stateStore.store.Get(s.stateStore.genBlockMerkleTreeKey())
+	if err != nil {
+		return fmt.Errorf("get block merkle tree error %s", err)
+	}
+	epochHeights, err := s.stateStore.getEpochHeights()
+	if err != nil {
+		return fmt.Errorf("getEpochHeights error %s", err)
+	}
+
+	hasher := sha256.New()
+	buf := bufio.NewWriter(w)
+	sw := &snapshotWriter{w: io.MultiWriter(buf, hasher)}
+	sw.write(snapshotMagic)
+	sw.write([]byte{SNAPSHOT_VERSION})
+	heightData := make([]byte, 8)
+	binary.LittleEndian.PutUint64(heightData, height)
+	sw.write(heightData)
+	sw.write(blockHash.ToArray())
+
+	for h := uint64(0); h < height && sw.err == nil; h++ {
+		hash, err := s.blockStore.GetBlockHash(h)
+		if err != nil {
+			return fmt.Errorf("GetBlockHash height %d error %s", h, err)
+		}
+		if _, ok := epochHeights[h]; ok {
+			epochBlock, err := s.blockStore.loadEpochBlock(hash)
+			if err != nil {
+				return fmt.Errorf("loadEpochBlock height %d error %s", h, err)
+			}
+			value, err := epochBlock.ToArray()
+			if err != nil {
+				return err
+			}
+			sw.record(snapshotEpochBlock, value)
+			continue
+		}
+		value, err := s.blockStore.store.Get(s.blockStore.getHeaderKey(hash))
+		if err != nil {
+			return fmt.Errorf("get header height %d error %s", h, err)
+		}
+		sw.record(snapshotHeader, value)
+	}
+	sw.record(snapshotBlock, blockData)
+	sw.record(snapshotBlockMerkleTree, treeData)
+	for _, prefix := range snapshotBlockPrefixes {
+		sw.entries(snapshotBlockEntry, s.blockStore.store, []byte{byte(prefix)})
+	}
+	for _, prefix := range snapshotStatePrefixes {
+		sw.entries(snapshotStateEntry, s.stateStore.store, []byte{byte(prefix)})
+	}
+	for _, key := range [][]byte{s.stateStore.genStateMerkleRootKey(height), genCrossStatesKey(height), genCrossStatesRootKey(height)} {
+		value, err := s.stateStore.store.Get(key)
+		if err == scom.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		sw.record(snapshotStateEntry, key, value)
+	}
+	sw.record(snapshotEnd)
+	if sw.err != nil {
+		return fmt.Errorf("write snapshot error %s", sw.err)
+	}
+	if _, err := buf.Write(hasher.Sum(nil)); err != nil {
+		return fmt.Errorf("write snapshot error %s", err)
+	}
+	return buf.Flush()
+}
+
+// ImportSnapshot restore empty ledger from snapshot written by ExportSnapshot. Headers must be chained up to
+// the snapshot block which hash must be trustedHash, block merkle tree rebuilt from headers must match the snapshot one.
+// Epochs are applied from epoch blocks matching transactions root of their headers, and epoch block hash
+// of each header must name the last epoch applied. Request states, request indexes and contract states
+// are not committed to by headers and are taken from the snapshot as is, so it must come from a trusted source.
+// Blocks below snapshot height are reported pruned. Ledger is ready to save the next block after import
+// and store is cleared if import fails
+func (s *LedgerStoreImp) ImportSnapshot(r io.Reader, trustedHash common.Uint256) error {
+	s.getSavingBlockLock()
+	defer s.releaseSavingBlockLock()
+
+	hasInit, err := s.hasAlreadyInitGenesisBlock()
+	if err != nil {
+		return fmt.Errorf("hasAlreadyInit error %s", err)
+	}
+	if hasInit {
+		return errors.New("ledger is already initialized")
+	}
+	if err := s.clearStores(); err != nil {
+		return err
+	}
+	height, err := s.importSnapshot(r, trustedHash)
+	if err != nil {
+		if err := s.clearStores(); err != nil {
+			logrus.Errorf("clear stores after snapshot import error %s", err)
+		}
+		if err := s.stateStore.merkleTree.Truncate(0); err != nil {
+			logrus.Errorf("truncate block merkle tree after snapshot import error %s", err)
+		}
+		return err
+	}
+	if err := s.init(); err != nil {
+		return fmt.Errorf("init error %s", err)
+	}
+	s.startPruner()
+	logrus.WithFields(logrus.Fields{
+		"chain_id":         s.chainId,
+		"height":           height,
+		"processed_height": s.processedHeight,
+		"block_hash":       trustedHash.ToHexString(),
+	}).Infof("Ledger initialized from snapshot.")
+	return nil
+}
+
+// importSnapshot save snapshot records to cleared stores and return snapshot height.
+// Store version is saved last, so interrupted import is discarded by the next init
+func (s *LedgerStoreImp) importSnapshot(r io.Reader, trustedHash common.Uint256) (uint64, error) {
+	if err := s.stateStore.merkleTree.Truncate(0); err != nil {
+		return 0, fmt.Errorf("block merkle tree Truncate error %s", err)
+	}
+	hasher := sha256.New()
+	buf := bufio.NewReader(r)
+	source := io.TeeReader(buf, hasher)
+
+	magic, err := serialization.ReadBytes(source, uint64(len(snapshotMagic)+1))
+	if err != nil {
+		return 0, fmt.Errorf("read snapshot error %s", err)
+	}
+	if !bytes.Equal(magic[:len(snapshotMagic)], snapshotMagic) {
+		return 0, errors.New("not a ledger snapshot")
+	}
+	if version := magic[len(snapshotMagic)]; version != SNAPSHOT_VERSION {
+		return 0, fmt.Errorf("unsupported snapshot version %d", version)
+	}
+	height, err := serialization.ReadUint64(source)
+	if err != nil {
+		return 0, fmt.Errorf("read snapshot error %s", err)
+	}
+	blockHash, err := serialization.ReadHash(source)
+	if err != nil {
+		return 0, fmt.Errorf("read snapshot error %s", err)
+	}
+	if blockHash != trustedHash {
+		return 0, fmt.Errorf("snapshot block hash %s is not trusted hash %s", blockHash.ToHexString(), trustedHash.ToHexString())
+	}
+
+	s.blockStore.NewBatch()
+	s.stateStore.NewBatch()
+	s.eventStore.NewBatch()
+	records := uint64(0)
+	commit := func() error {
+		if records++; records%snapshotCommitSize != 0 {
+			return nil
+		}
+		return s.commitSnapshotBatches(height)
+	}
+
+	statePrefixes := append(append([]scom.DataEntryPrefix{}, snapshotStatePrefixes...), snapshotHeightPrefixes...)
+	var prevHash, epochHash common.Uint256
+	var epochNumber uint32
+	indexList := make([]common.Uint256, 0, HEADER_INDEX_BATCH_SIZE)
+	for h := uint64(0); h < height; h++ {
+		kind, err := serialization.ReadByte(source)
+		if err != nil {
+			return 0, fmt.Errorf("read snapshot error %s", err)
+		}
+		value, err := serialization.ReadVarBytes(source)
+		if err != nil {
+			return 0, fmt.Errorf("read snapshot error %s", err)
+		}
+		var header *types.Header
+		switch kind {
+		case snapshotHeader:
+			var txHashes []common.Uint256
+			header, txHashes, err = decodeHeaderWithTx(value)
+			if err != nil {
+				return 0, fmt.Errorf("decode header height %d error %s", h, err)
+			}
+			if err := checkSnapshotHeader(header, h, prevHash, epochHash); err != nil {
+				return 0, err
+			}
+			s.blockStore.store.BatchPut(s.blockStore.getHeaderKey(*header.Hash()), value)
+			for _, txHash := range txHashes {
+				if err := s.blockStore.putPrunedTransaction(txHash, h); err != nil {
+					return 0, err
+				}
+			}
+		case snapshotEpochBlock:
+			block, err := types.BlockFromRawBytes(value)
+			if err != nil {
+				return 0, fmt.Errorf("decode epoch block height %d error %s", h, err)
+			}
+			header = block.Header
+			if err := checkSnapshotHeader(header, h, prevHash, epochHash); err != nil {
+				return 0, err
+			}
+			epoch, err := blockEpochEvent(block)
+			if err != nil {
+				return 0, err
+			}
+			if epoch == nil {
+				return 0, fmt.Errorf("epoch block height %d has no epoch event", h)
+			}
+			if epochHash != common.UINT256_EMPTY && epoch.Number != epochNumber+1 {
+				return 0, fmt.Errorf("epoch block height %d epoch number %d not equal next epoch number %d", h, epoch.Number, epochNumber+1)
+			}
+			if err := s.importSnapshotBlock(block); err != nil {
+				return 0, fmt.Errorf("import epoch block height %d error %s", h, err)
+			}
+			epochHash, epochNumber = block.Hash(), epoch.Number
+		default:
+			return 0, fmt.Errorf("unexpected snapshot record %d, expected header", kind)
+		}
+		hash := *header.Hash()
+		s.blockStore.SaveBlockHash(h, hash)
+		if err := s.stateStore.AddBlockMerkleTreeRoot(header.PrevBlockHash); err != nil {
+			return 0, err
+		}
+		indexList = append(indexList, hash)
+		if uint64(len(indexList)) == HEADER_INDEX_BATCH_SIZE {
+			if err := s.blockStore.SaveHeaderIndexList(h+1-HEADER_INDEX_BATCH_SIZE, indexList); err != nil {
+				return 0, err
+			}
+			indexList = indexList[:0]
+		}
+		prevHash = hash
+		if err := commit(); err != nil {
+			return 0, err
+		}
+	}
+
+	value, err := readSnapshotRecord(source, snapshotBlock)
+	if err != nil {
+		return 0, err
+	}
+	block, err := types.BlockFromRawBytes(value)
+	if err != nil {
+		return 0, fmt.Errorf("decode block error %s", err)
+	}
+	if block.Header.Height != height || block.Hash() != trustedHash {
+		return 0, fmt.Errorf("snapshot block is not trusted block %s at height %d", trustedHash.ToHexString(), height)
+	}
+	if err := checkSnapshotHeader(block.Header, height, prevHash, epochHash); err != nil {
+		return 0, err
+	}
+	if err := s.importSnapshotBlock(block); err != nil {
+		return 0, fmt.Errorf("import snapshot block error %s", err)
+	}
+	s.blockStore.SaveBlockHash(height, trustedHash)
+	if err := s.stateStore.AddBlockMerkleTreeRoot(block.Header.PrevBlockHash); err != nil {
+		return 0, err
+	}
+
+	value, err = readSnapshotRecord(source, snapshotBlockMerkleTree)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.stateStore.checkBlockMerkleTree(value); err != nil {
+		return 0, err
+	}
+
+	for {
+		kind, err := serialization.ReadByte(source)
+		if err != nil {
+			return 0, fmt.Errorf("read snapshot error %s", err)
+		}
+		if kind == snapshotEnd {
+			break
+		}
+		var store scom.PersistStore
+		var prefixes []scom.DataEntryPrefix
+		switch kind {
+		case snapshotBlockEntry:
+			store, prefixes = s.blockStore.store, snapshotBlockPrefixes
+		case snapshotStateEntry:
+			store, prefixes = s.stateStore.store, statePrefixes
+		default:
+			return 0, fmt.Errorf("unexpected snapshot record %d", kind)
+		}
+		key, err := serialization.ReadVarBytes(source)
+		if err != nil {
+			return 0, fmt.Errorf("read snapshot error %s", err)
+		}
+		value, err := serialization.ReadVarBytes(source)
+		if err != nil {
+			return 0, fmt.Errorf("read snapshot error %s", err)
+		}
+		if !hasSnapshotPrefix(key, prefixes) {
+			return 0, fmt.Errorf("unexpected snapshot key %x", key)
+		}
+		store.BatchPut(key, value)
+		if err := commit(); err != nil {
+			return 0, err
+		}
+	}
+
+	digest := hasher.Sum(nil)
+	expected, err := serialization.ReadBytes(buf, sha256.Size)
+	if err != nil {
+		return 0, fmt.Errorf("read snapshot digest error %s", err)
+	}
+	if !bytes.Equal(digest, expected) {
+		return 0, errors.New("snapshot digest mismatch")
+	}
+
+	if err := s.blockStore.SaveCurrentBlock(height, trustedHash); err != nil {
+		return 0, err
+	}
+	if err := s.stateStore.SaveCurrentBlock(height, trustedHash); err != nil {
+		return 0, err
+	}
+	if err := s.eventStore.SaveCurrentBlock(height, trustedHash); err != nil {
+		return 0, err
+	}
+	if err := s.commitSnapshotBatches(height); err != nil {
+		return 0, err
+	}
+	if err := s.stateStore.loadStateMerkleTree(); err != nil {
+		return 0, fmt.Errorf("loadStateMerkleTree error %s", err)
+	}
+	if err := s.blockStore.savePruneState(height, height); err != nil {
+		return 0, fmt.Errorf("savePruneState error %s", err)
+	}
+	if err := s.blockStore.SaveRequestIndexHeight(height); err != nil {
+		return 0, fmt.Errorf("SaveRequestIndexHeight error %s", err)
+	}
+	if err := s.initGenesisBlock(); err != nil {
+		return 0, fmt.Errorf("save version error %s", err)
+	}
+	return height, nil
+}
+
+// checkSnapshotHeader check header is at height, chained to the previous one and names the last epoch
+// applied from the snapshot if it names any
+func checkSnapshotHeader(header *types.Header, height uint64, prevHash, epochHash common.Uint256) error {
+	if header.Height != height || (height > 0 && header.PrevBlockHash != prevHash) {
+		return fmt.Errorf("header height %d is not chained to previous one", height)
+	}
+	if height > 0 && header.EpochBlockHash != common.UINT256_EMPTY && header.EpochBlockHash != epochHash {
+		return fmt.Errorf("header height %d epoch block %s is not in snapshot", height, header.EpochBlockHash.ToHexString())
+	}
+	return nil
+}
+
+// importSnapshotBlock save block with its transactions and apply its epoch event. Transactions must match
+// transactions root of the block header, so epoch is proven by the header chained to trusted block
+func (s *LedgerStoreImp) importSnapshotBlock(block *types.Block) error {
+	if err := block.VerifyIntegrity(); err != nil {
+		return fmt.Errorf("block VerifyIntegrity error %s", err)
+	}
+	if err := s.blockStore.SaveHeader(block); err != nil {
+		return err
+	}
+	for _, tx := range block.Transactions {
+		if _, err := s.blockStore.putTransactionData(tx.Payload, block.Header.Height); err != nil {
+			return err
+		}
+	}
+	return s.saveEpochsToStateStore(block)
+}
+
+// loadEpochBlock return block including epoch event, its transactions are kept when block is pruned
+func (s *BlockStore) loadEpochBlock(blockHash common.Uint256) (*types.Block, error) {
+	header, txHashes, err := s.loadHeaderWithTx(blockHash)
+	if err != nil {
+		return nil, err
+	}
+	txList := make(types.Transactions, 0, len(txHashes))
+	for _, txHash := range txHashes {
+		tx, _, err := s.loadTransaction(txHash)
+		if err != nil {
+			return nil, fmt.Errorf("loadTransaction %s error %w", txHash.ToHexString(), err)
+		}
+		txList = append(txList, types.ToTransaction(tx))
+	}
+	return types.NewBlockFromComponents(header, txList), nil
+}
+
+// getEpochHeights return heights of blocks including epoch events
+func (s *StateStore) getEpochHeights() (map[uint64]struct{}, error) {
+	iter := s.store.NewIterator([]byte{byte(scom.IX_EPOCH_HEIGHT)})
+	defer iter.Release()
+	heights := make(map[uint64]struct{})
+	for iter.Next() {
+		if key := iter.Key(); len(key) == 9 {
+			heights[binary.BigEndian.Uint64(key[1:])] = struct{}{}
+		}
+	}
+	return heights, iter.Error()
+}
+
+// commitSnapshotBatches commit imported records and start new batches
+func (s *LedgerStoreImp) commitSnapshotBatches(height uint64) error {
+	s.beginAtomicCommit()
+	err := s.commitBlockBatches(height)
+	if err = s.endAtomicCommit(err); err != nil {
+		return err
+	}
+	s.blockStore.NewBatch()
+	s.stateStore.NewBatch()
+	s.eventStore.NewBatch()
+	return nil
+}
+
+// putPrunedTransaction put height of the pruned transaction to batch
+func (s *BlockStore) putPrunedTransaction(txHash common.Uint256, height uint64) error {
+	key, err := s.getTransactionKey(txHash)
+	if err != nil {
+		return err
+	}
+	value := make([]byte, 8)
+	binary.LittleEndian.PutUint64(value, height)
+	s.store.BatchPut(key, value)
+	return nil
+}
+
+// checkBlockMerkleTree compare block merkle tree with compact tree saved by AddBlockMerkleTreeRoot
+func (s *StateStore) checkBlockMerkleTree(value []byte) error {
+	source := common.NewZeroCopySource(value)
+	treeSize, eof := source.NextUint64()
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	hashes := make([]common.Uint256, 0, source.Len()/common.UINT256_SIZE)
+	for source.Len() > 0 {
+		hash, eof := source.NextHash()
+		if eof {
+			return io.ErrUnexpectedEOF
+		}
+		hashes = append(hashes, hash)
+	}
+	root, expected := merkle.NewTree(treeSize, hashes, nil).Root(), s.merkleTree.Root()
+	if treeSize != s.merkleTree.TreeSize() || root != expected {
+		return fmt.Errorf("block merkle root %s of size %d mismatch, expected %s of size %d",
+			root.ToHexString(), treeSize, expected.ToHexString(), s.merkleTree.TreeSize())
+	}
+	return nil
+}
+
+// loadStateMerkleTree load state merkle tree saved in store
+func (s *StateStore) loadStateMerkleTree() error {
+	treeSize, hashes, err := s.GetStateMerkleTree()
+	if err == scom.ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	s.deltaMerkleTree = merkle.NewTree(treeSize, hashes, nil)
+	return nil
+}
+
+// snapshotWriter write snapshot records, writing is stopped on the first error
+type snapshotWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (sw *snapshotWriter) write(data []byte) {
+	if sw.err == nil {
+		_, sw.err = sw.w.Write(data)
+	}
+}
+
+func (sw *snapshotWriter) record(kind byte, fields ...[]byte) {
+	sw.write([]byte{kind})
+	for _, field := range fields {
+		if sw.err == nil {
+			sw.err = serialization.WriteVarBytes(sw.w, field)
+		}
+	}
+}
+
+// entries write records of the store entries with prefix
+func (sw *snapshotWriter) entries(kind byte, store scom.PersistStore, prefix []byte) {
+	if sw.err != nil {
+		return
+	}
+	iter := store.NewIterator(prefix)
+	for sw.err == nil && iter.Next() {
+		sw.record(kind, iter.Key(), iter.Value())
+	}
+	iter.Release()
+	if sw.err == nil {
+		sw.err = iter.Error()
+	}
+}
+
+// readSnapshotRecord read value of the record which must be of kind
+func readSnapshotRecord(r io.Reader, kind byte) ([]byte, error) {
+	recordKind, err := serialization.ReadByte(r)
+	if err != nil {
+		return nil, fmt.Errorf("read snapshot error %s", err)
+	}
+	if recordKind != kind {
+		return nil, fmt.Errorf("unexpected snapshot record %d, expected %d", recordKind, kind)
+	}
+	value, err := serialization.ReadVarBytes(r)
+	if err != nil {
+		return nil, fmt.Errorf("read snapshot error %s", err)
+	}
+	return value, nil
+}
+
+func hasSnapshotPrefix(key []byte, prefixes []scom.DataEntryPrefix) bool {
+	if len(key) == 0 {
+		return false
+	}
+	for _, prefix := range prefixes {
+		if key[0] == byte(prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,108 @@
+package ledgerstore
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing"
+
+	ethCommon "github.com/ethereum/go-ethereum/common"
+	"github.com/eywa-protocol/bls-crypto/bls"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eywa-protocol/chain/common"
+	"github.com/eywa-protocol/chain/common/serialization"
+	"github.com/eywa-protocol/chain/core/payload"
+	scom "github.com/eywa-protocol/chain/core/store/common"
+	"github.com/eywa-protocol/chain/core/types"
+)
+
+func TestSnapshot(t *testing.T) {
+	ledgerStore := openTestLedgerStore(t, t.TempDir(), StoreConfig{})
+	defer ledgerStore.Close()
+	bridge := ethCommon.HexToAddress("0x0c760E9A85d2E957Dd1E189516b6658CfEcD3985")
+	reqId := [32]byte{1}
+	txs := newIndexTestRequest(reqId, 94, bridge, bridge)
+	txHash := txs[0].Hash()
+	block1 := submitTestBlock(t, ledgerStore, 11, txs)
+	_, pubKey := bls.GenerateRandomKey()
+	epoch := payload.NewEpochEvent(1, common.UINT256_EMPTY, []bls.PublicKey{pubKey}, []string{"one"})
+	block2 := submitTestBlock(t, ledgerStore, 12, types.Transactions{types.ToTransaction(epoch)})
+	block3 := submitTestBlock(t, ledgerStore, 13, newIndexTestRequest([32]byte{2}, 95, bridge, bridge))
+
+	require.Error(t, ledgerStore.ExportSnapshot(2, new(bytes.Buffer)))
+	snapshot := new(bytes.Buffer)
+	require.NoError(t, ledgerStore.ExportSnapshot(3, snapshot))
+
+	// tampered snapshot and untrusted block are rejected
+	imported, err := NewLedgerStoreWithConfig(t.TempDir(), StoreConfig{})
+	require.NoError(t, err)
+	defer imported.Close()
+	tampered := append([]byte{}, snapshot.Bytes()...)
+	tampered[len(tampered)-40] ^= 0xff
+	require.Error(t, imported.ImportSnapshot(bytes.NewReader(tampered), block3.Hash()))
+	require.Error(t, imported.ImportSnapshot(bytes.NewReader(snapshot.Bytes()), block1.Hash()))
+
+	// epoch entries are rejected even if snapshot digest is recomputed
+	forged := append([]byte{}, snapshot.Bytes()[:snapshot.Len()-1-sha256.Size]...)
+	record := bytes.NewBuffer([]byte{snapshotStateEntry})
+	require.NoError(t, serialization.WriteVarBytes(record, genEpochKey(2)))
+	require.NoError(t, serialization.WriteVarBytes(record, []byte{1}))
+	forged = append(append(forged, record.Bytes()...), snapshotEnd)
+	digest := sha256.Sum256(forged)
+	require.Error(t, imported.ImportSnapshot(bytes.NewReader(append(forged, digest[:]...)), block3.Hash()))
+
+	require.NoError(t, imported.ImportSnapshot(bytes.NewReader(snapshot.Bytes()), block3.Hash()))
+	require.Error(t, imported.ImportSnapshot(bytes.NewReader(snapshot.Bytes()), block3.Hash()))
+	require.Equal(t, uint64(3), imported.GetCurrentBlockHeight())
+	require.Equal(t, block3.Hash(), imported.GetCurrentBlockHash())
+	require.Equal(t, ledgerStore.GetProcessedHeight(), imported.GetProcessedHeight())
+	require.Equal(t, uint64(3), imported.GetPrunedHeight())
+
+	header, err := imported.GetHeaderByHeight(1)
+	require.NoError(t, err)
+	require.Equal(t, block1.Hash(), *header.Hash())
+	_, err = imported.GetBlockByHeight(1)
+	require.Equal(t, scom.ErrPruned, err)
+	_, _, err = imported.GetTransaction(txHash)
+	require.Equal(t, scom.ErrPruned, err)
+	block, err := imported.GetBlockByHeight(3)
+	require.NoError(t, err)
+	require.Equal(t, block3.Hash(), block.Hash())
+
+	state, err := imported.GetRequestState(reqId)
+	require.NoError(t, err)
+	require.Equal(t, payload.ReqStateReceived, state)
+	history, err := imported.GetRequestHistory(reqId)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	// epoch is applied from the epoch block
+	epochInfo, err := imported.stateStore.GetEpochAtHeight(3)
+	require.NoError(t, err)
+	require.Equal(t, epoch.Number, epochInfo.Number)
+	require.Equal(t, block2.Hash(), epochInfo.BlockHash)
+	require.Equal(t, uint64(2), epochInfo.Height)
+	block, err = imported.GetBlockByHeight(2)
+	require.Equal(t, scom.ErrPruned, err)
+	require.NoError(t, imported.ExportSnapshot(3, new(bytes.Buffer)))
+
+	requests, err := imported.GetRequestsByDstChain(95, 0, 3, 0, 10)
+	require.NoError(t, err)
+	require.Equal(t, [][32]byte{{2}}, requests)
+
+	stateRoot, err := ledgerStore.GetStateMerkleRoot(3)
+	require.NoError(t, err)
+	importedRoot, err := imported.GetStateMerkleRoot(3)
+	require.NoError(t, err)
+	require.Equal(t, stateRoot, importedRoot)
+	require.Equal(t, ledgerStore.GetBlockRootWithPreBlockHashes(4, []common.Uint256{block3.Hash()}),
+		imported.GetBlockRootWithPreBlockHashes(4, []common.Uint256{block3.Hash()}))
+
+	// both ledgers save the same next block
+	block4 := submitTestBlock(t, ledgerStore, 14, types.Transactions{})
+	require.Equal(t, block4.Hash(), submitTestBlock(t, imported, 14, types.Transactions{}).Hash())
+	stateRoot, err = ledgerStore.GetStateMerkleRoot(4)
+	require.NoError(t, err)
+	importedRoot, err = imported.GetStateMerkleRoot(4)
+	require.NoError(t, err)
+	require.Equal(t, stateRoot, importedRoot)
+}
@@ -0,0 +1,261 @@
+package ledgerstore
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/eywa-protocol/chain/common"
+	"github.com/eywa-protocol/chain/core/payload"
+	"github.com/eywa-protocol/chain/core/states"
+	scom "github.com/eywa-protocol/chain/core/store/common"
+	"github.com/eywa-protocol/chain/core/types"
+)
+
+const DefaultConfirmationDepth = uint64(12) // Source blocks required above event block to treat it final
+
+// SaveSourceEvent persist source event state of the transaction as pending finality to store batch
+func (s *BlockStore) SaveSourceEvent(state *states.SourceEventState) error {
+	value := bytes.NewBuffer(nil)
+	if err := state.Serialize(value); err != nil {
+		return err
+	}
+	s.store.BatchPut(s.getSourceEventKey(state.TxHash), value.Bytes())
+	if state.Status == states.FinalityPending {
+		s.store.BatchPut(s.getSourcePendingKey(state.ChainId, state.SrcHeight, state.TxHash), nil)
+	}
+	return nil
+}
+
+// GetSourceEvent return source event state by transaction hash
+func (s *BlockStore) GetSourceEvent(txHash common.Uint256) (*states.SourceEventState, error) {
+	value, err := s.store.Get(s.getSourceEventKey(txHash))
+	if err != nil {
+		return nil, err
+	}
+	state := new(states.SourceEventState)
+	if err := state.Deserialize(bytes.NewReader(value)); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// GetPendingSourceEvents return source event states of the source chain pending finality ordered by source height
+func (s *BlockStore) GetPendingSourceEvents(chainId uint64) ([]*states.SourceEventState, error) {
+	var result []*states.SourceEventState
+	err := s.iteratePendingSourceEvents(chainId, func(state *states.SourceEventState) bool {
+		result = append(result, state)
+		return true
+	})
+	return result, err
+}
+
+// UpdateSourceEventsStatus set status of the pending source events to store batch and remove them from pending index
+func (s *BlockStore) UpdateSourceEventsStatus(events []*states.SourceEventState, status states.FinalityStatus) error {
+	for _, state := range events {
+		s.store.BatchDelete(s.getSourcePendingKey(state.ChainId, state.SrcHeight, state.TxHash))
+		state.Status = status
+		if err := s.SaveSourceEvent(state); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteSourceEvent remove source event state of the transaction from store batch
+func (s *BlockStore) deleteSourceEvent(txHash common.Uint256) error {
+	state, err := s.GetSourceEvent(txHash)
+	if err == scom.ErrNotFound {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	s.store.BatchDelete(s.getSourcePendingKey(state.ChainId, state.SrcHeight, state.TxHash))
+	s.store.BatchDelete(s.getSourceEventKey(txHash))
+	return nil
+}
+
+// iteratePendingSourceEvents call fn for source events pending finality in source height order until fn return false
+func (s *BlockStore) iteratePendingSourceEvents(chainId uint64, fn func(state *states.SourceEventState) bool) error {
+	prefix := make([]byte, 9)
+	prefix[0] = byte(scom.IX_SOURCE_PENDING)
+	binary.BigEndian.PutUint64(prefix[1:], chainId)
+	iter := s.store.NewIterator(prefix)
+	defer iter.Release()
+	for iter.Next() {
+		key := iter.Key()
+		if len(key) != 17+common.UINT256_SIZE {
+			continue
+		}
+		txHash, err := common.Uint256ParseFromBytes(key[17:])
+		if err != nil {
+			return err
+		}
+		state, err := s.GetSourceEvent(txHash)
+		if err != nil {
+			return fmt.Errorf("GetSourceEvent %s error %s", txHash.ToHexString(), err)
+		}
+		if !fn(state) {
+			break
+		}
+	}
+	return iter.Error()
+}
+
+func (s *BlockStore) getSourceEventKey(txHash common.Uint256) []byte {
+	key := make([]byte, 1+common.UINT256_SIZE)
+	key[0] = byte(scom.DATA_SOURCE_EVENT)
+	copy(key[1:], txHash[:])
+	return key
+}
+
+// getSourcePendingKey use big endian chain id and source height to keep pending events ordered in iteration
+func (s *BlockStore) getSourcePendingKey(chainId, srcHeight uint64, txHash common.Uint256) []byte {
+	key := make([]byte, 17+common.UINT256_SIZE)
+	key[0] = byte(scom.IX_SOURCE_PENDING)
+	binary.BigEndian.PutUint64(key[1:], chainId)
+	binary.BigEndian.PutUint64(key[9:], srcHeight)
+	copy(key[17:], txHash[:])
+	return key
+}
+
+// saveSourceEvents record source block of the events included to block as pending finality
+func (s *LedgerStoreImp) saveSourceEvents(block *types.Block) error {
+	for _, tx := range block.Transactions {
+		event, ok := tx.Payload.(payload.SourceEvent)
+		if !ok {
+			continue
+		}
+		srcHeight, srcBlockHash := event.SrcBlock()
+		err := s.blockStore.SaveSourceEvent(&states.SourceEventState{
+			ChainId:      block.Header.ChainID,
+			SrcHeight:    srcHeight,
+			SrcBlockHash: srcBlockHash,
+			SrcTxHash:    tx.Payload.SrcTxHash(),
+			TxHash:       tx.Hash(),
+			RequestId:    tx.Payload.RequestId(),
+			Height:       block.Header.Height,
+			Status:       states.FinalityPending,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetConfirmationDepth return count of source blocks required above event block to treat it final
+func (s *LedgerStoreImp) GetConfirmationDepth(chainId uint64) uint64 {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if depth, ok := s.confirmationDepth[chainId]; ok {
+		return depth
+	}
+	return DefaultConfirmationDepth
+}
+
+// SetConfirmationDepth set count of source blocks required above event block to treat it final for the source chain
+func (s *LedgerStoreImp) SetConfirmationDepth(chainId uint64, depth uint64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.confirmationDepth[chainId] = depth
+}
+
+// GetSourceEvent return source event state of the transaction. Wrap function of BlockStore.GetSourceEvent
+func (s *LedgerStoreImp) GetSourceEvent(txHash common.Uint256) (*states.SourceEventState, error) {
+	return s.blockStore.GetSourceEvent(txHash)
+}
+
+// GetRequestFinality return finality status of the source event which set the current request state
+func (s *LedgerStoreImp) GetRequestFinality(reqId [32]byte) (states.FinalityStatus, error) {
+	txHash, err := s.blockStore.GetRequestTxHash(reqId)
+	if err != nil {
+		return states.FinalityUnknown, err
+	}
+	state, err := s.blockStore.GetSourceEvent(txHash)
+	if err == scom.ErrNotFound {
+		return states.FinalityUnknown, nil
+	} else if err != nil {
+		return states.FinalityUnknown, err
+	}
+	return state.Status, nil
+}
+
+// GetPendingSourceEvents return source events of the source chain pending finality. Wrap function of BlockStore.GetPendingSourceEvents
+func (s *LedgerStoreImp) GetPendingSourceEvents(chainId uint64) ([]*states.SourceEventState, error) {
+	return s.blockStore.GetPendingSourceEvents(chainId)
+}
+
+// UpdateSourceHead mark pending events of the source chain confirmed by head height as final.
+// Return ledger transaction hashes of finalized events
+func (s *LedgerStoreImp) UpdateSourceHead(chainId uint64, head uint64) ([]common.Uint256, error) {
+	depth := s.GetConfirmationDepth(chainId)
+	s.getSavingBlockLock()
+	defer s.releaseSavingBlockLock()
+
+	var final []*states.SourceEventState
+	err := s.blockStore.iteratePendingSourceEvents(chainId, func(state *states.SourceEventState) bool {
+		if state.SrcHeight+depth > head {
+			return false
+		}
+		final = append(final, state)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.commitSourceEventsStatus(final, states.FinalityFinal)
+}
+
+// ReportSourceBlock compare canonical source block hash at height with pending events recorded at this height.
+// Events with other block hash are marked as reorged and their ledger transaction hashes are returned
+func (s *LedgerStoreImp) ReportSourceBlock(chainId uint64, height uint64, blockHash []byte) ([]common.Uint256, error) {
+	s.getSavingBlockLock()
+	defer s.releaseSavingBlockLock()
+
+	var reorged []*states.SourceEventState
+	err := s.blockStore.iteratePendingSourceEvents(chainId, func(state *states.SourceEventState) bool {
+		if state.SrcHeight > height {
+			return false
+		}
+		if state.SrcHeight == height && len(state.SrcBlockHash) > 0 && !bytes.Equal(state.SrcBlockHash, blockHash) {
+			reorged = append(reorged, state)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.commitSourceEventsStatus(reorged, states.FinalityReorged)
+}
+
+// MarkSourceEventReorged flag the source event of the transaction as reorged, so it is excluded from relaying
+func (s *LedgerStoreImp) MarkSourceEventReorged(txHash common.Uint256) error {
+	s.getSavingBlockLock()
+	defer s.releaseSavingBlockLock()
+
+	state, err := s.blockStore.GetSourceEvent(txHash)
+	if err != nil {
+		return err
+	}
+	_, err = s.commitSourceEventsStatus([]*states.SourceEventState{state}, states.FinalityReorged)
+	return err
+}
+
+func (s *LedgerStoreImp) commitSourceEventsStatus(events []*states.SourceEventState, status states.FinalityStatus) ([]common.Uint256, error) {
+	if len(events) == 0 {
+		return nil, nil
+	}
+	s.blockStore.NewBatch()
+	if err := s.blockStore.UpdateSourceEventsStatus(events, status); err != nil {
+		return nil, err
+	}
+	if err := s.blockStore.CommitTo(); err != nil {
+		return nil, err
+	}
+	txHashes := make([]common.Uint256, 0, len(events))
+	for _, state := range events {
+		txHashes = append(txHashes, state.TxHash)
+	}
+	return txHashes, nil
+}
@@ -0,0 +1,89 @@
+package ledgerstore
+
+import (
+	"testing"
+
+	ethCommon "github.com/ethereum/go-ethereum/common"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/eywa-protocol/wrappers"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eywa-protocol/chain/common"
+	"github.com/eywa-protocol/chain/core/payload"
+	"github.com/eywa-protocol/chain/core/states"
+	scom "github.com/eywa-protocol/chain/core/store/common"
+	"github.com/eywa-protocol/chain/core/types"
+)
+
+func newSourceTestEvent(reqId [32]byte, srcHeight uint64, srcBlockHash ethCommon.Hash) *payload.BridgeEvent {
+	return &payload.BridgeEvent{
+		OriginData: wrappers.BridgeOracleRequest{
+			RequestType: "setRequest",
+			RequestId:   reqId,
+			Raw: ethTypes.Log{
+				BlockNumber: srcHeight,
+				BlockHash:   srcBlockHash,
+				TxHash:      ethCommon.Hash{reqId[0]},
+			},
+		}}
+}
+
+func TestSourceFinality(t *testing.T) {
+	ledgerStore, err := NewLedgerStore("test/finality")
+	require.NoError(t, err)
+	defer ledgerStore.Close()
+	ledgerStore.SetConfirmationDepth(0, 5)
+	require.Equal(t, uint64(5), ledgerStore.GetConfirmationDepth(0))
+	require.Equal(t, DefaultConfirmationDepth, ledgerStore.GetConfirmationDepth(1))
+
+	genesisBlock := types.NewBlock(0, common.UINT256_EMPTY, common.UINT256_EMPTY, 10, 0, types.Transactions{})
+	require.NoError(t, ledgerStore.InitLedgerStoreWithGenesisBlock(genesisBlock))
+
+	reqId1, reqId2, reqId3 := [32]byte{1}, [32]byte{2}, [32]byte{3}
+	event1 := types.ToTransaction(newSourceTestEvent(reqId1, 100, ethCommon.Hash{0xa}))
+	event2 := types.ToTransaction(newSourceTestEvent(reqId2, 103, ethCommon.Hash{0xb}))
+	event3 := types.ToTransaction(newSourceTestEvent(reqId3, 110, ethCommon.Hash{0xc}))
+	submitTestBlock(t, ledgerStore, 100, types.Transactions{event1, event2})
+	submitTestBlock(t, ledgerStore, 110, types.Transactions{event3})
+
+	pending, err := ledgerStore.GetPendingSourceEvents(0)
+	require.NoError(t, err)
+	require.Len(t, pending, 3)
+	require.Equal(t, []uint64{100, 103, 110}, []uint64{pending[0].SrcHeight, pending[1].SrcHeight, pending[2].SrcHeight})
+	require.Equal(t, reqId1, pending[0].RequestId)
+	require.Equal(t, uint64(1), pending[0].Height)
+
+	status, err := ledgerStore.GetRequestFinality(reqId1)
+	require.NoError(t, err)
+	require.Equal(t, states.FinalityPending, status)
+
+	final, err := ledgerStore.UpdateSourceHead(0, 107)
+	require.NoError(t, err)
+	require.Equal(t, []common.Uint256{event1.Hash()}, final)
+	status, err = ledgerStore.GetRequestFinality(reqId1)
+	require.NoError(t, err)
+	require.Equal(t, states.FinalityFinal, status)
+
+	// canonical block at height 103 differs from the one the event was observed in
+	reorged, err := ledgerStore.ReportSourceBlock(0, 103, ethCommon.Hash{0xd}.Bytes())
+	require.NoError(t, err)
+	require.Equal(t, []common.Uint256{event2.Hash()}, reorged)
+	reorged, err = ledgerStore.ReportSourceBlock(0, 110, ethCommon.Hash{0xc}.Bytes())
+	require.NoError(t, err)
+	require.Empty(t, reorged)
+	status, err = ledgerStore.GetRequestFinality(reqId2)
+	require.NoError(t, err)
+	require.Equal(t, states.FinalityReorged, status)
+
+	pending, err = ledgerStore.GetPendingSourceEvents(0)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	require.Equal(t, event3.Hash(), pending[0].TxHash)
+
+	require.NoError(t, ledgerStore.RollbackTo(1))
+	_, err = ledgerStore.GetSourceEvent(event3.Hash())
+	require.Equal(t, scom.ErrNotFound, err)
+	pending, err = ledgerStore.GetPendingSourceEvents(0)
+	require.NoError(t, err)
+	require.Empty(t, pending)
+}
@@ -31,13 +31,17 @@ type StateStore struct {
 	stateHashCheckHeight uint64
 }
 
-// NewStateStore return state store instance
-func NewStateStore(dbDir, merklePath string) (*StateStore, error) {
-	var err error
-	store, err := leveldbstore.NewLevelDBStore(dbDir)
+// NewStateStore return state store instance opened with the backend, empty backend means default one
+func NewStateStore(backend, dbDir, merklePath string) (*StateStore, error) {
+	store, err := scom.OpenPersistStore(backend, dbDir)
 	if err != nil {
 		return nil, err
 	}
+	return newStateStore(store, dbDir, merklePath)
+}
+
+// newStateStore return state store instance over opened store
+func newStateStore(store scom.PersistStore, dbDir, merklePath string) (*StateStore, error) {
 	stateStore := &StateStore{
 		dbDir:      dbDir,
 		store:      store,
@@ -160,6 +164,21 @@ func (s *StateStore) GetStateMerkleRoot(height uint64) (result common.Uint256, e
 	return
 }
 
+// getStateMerkleRoots return write set hash and state merkle root saved at block height
+func (s *StateStore) getStateMerkleRoots(height uint64) (common.Uint256, common.Uint256, error) {
+	value, err := s.store.Get(s.genStateMerkleRootKey(height))
+	if err != nil {
+		return common.UINT256_EMPTY, common.UINT256_EMPTY, err
+	}
+	source := common.NewZeroCopySource(value)
+	writeSetHash, _ := source.NextHash()
+	root, eof := source.NextHash()
+	if eof {
+		return common.UINT256_EMPTY, common.UINT256_EMPTY, io.ErrUnexpectedEOF
+	}
+	return writeSetHash, root, nil
+}
+
 func (s *StateStore) AddStateMerkleTreeRoot(blockHeight uint64, writeSetHash common.Uint256) error {
 	if blockHeight < s.stateHashCheckHeight {
 		return nil
@@ -316,6 +335,85 @@ func (s *StateStore) SaveEpochState(bookkeeperState *states.EpochState) error {
 	return s.store.Put(key, value.Bytes())
 }
 
+// SaveEpoch persist epoch info with block hash and height indexes to state store batch.
+// Book keeper state is updated with the epoch public keys as well.
+// Epoch number is checked to follow the previous one before the block is written.
+func (s *StateStore) SaveEpoch(epoch *states.EpochInfo) error {
+	value := bytes.NewBuffer(nil)
+	if err := epoch.Serialize(value); err != nil {
+		return err
+	}
+	s.store.BatchPut(genEpochKey(epoch.Number), value.Bytes())
+
+	number := make([]byte, 4)
+	binary.BigEndian.PutUint32(number, epoch.Number)
+	s.store.BatchPut(genEpochBlockHashKey(epoch.BlockHash), number)
+	s.store.BatchPut(genEpochHeightKey(epoch.Height), number)
+
+	key, err := s.getEpochKey()
+	if err != nil {
+		return err
+	}
+	bookkeeperState := &states.EpochState{
+		CurrEpoch: epoch.PublicKeys,
+	}
+	value = bytes.NewBuffer(nil)
+	if err := bookkeeperState.Serialize(value); err != nil {
+		return err
+	}
+	s.store.BatchPut(key, value.Bytes())
+	return nil
+}
+
+// GetEpochByNumber return epoch info by epoch number
+func (s *StateStore) GetEpochByNumber(number uint32) (*states.EpochInfo, error) {
+	value, err := s.store.Get(genEpochKey(number))
+	if err != nil {
+		return nil, err
+	}
+	epoch := new(states.EpochInfo)
+	if err := epoch.Deserialize(bytes.NewReader(value)); err != nil {
+		return nil, err
+	}
+	return epoch, nil
+}
+
+// GetEpochByBlockHash return epoch info by hash of the block including epoch event
+func (s *StateStore) GetEpochByBlockHash(blockHash common.Uint256) (*states.EpochInfo, error) {
+	value, err := s.store.Get(genEpochBlockHashKey(blockHash))
+	if err != nil {
+		return nil, err
+	}
+	if len(value) != 4 {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return s.GetEpochByNumber(binary.BigEndian.Uint32(value))
+}
+
+// GetEpochAtHeight return epoch info active at the block height.
+// Epoch is active from the height of the block including epoch event until the next epoch
+func (s *StateStore) GetEpochAtHeight(height uint64) (*states.EpochInfo, error) {
+	// epoch height keys sort by height, so the last key of the range is the latest epoch applied at or below height
+	iter := s.store.NewRangeIterator([]byte{byte(scom.IX_EPOCH_HEIGHT)}, append(genEpochHeightKey(height), 0))
+	found := false
+	var number uint32
+	for ok := iter.Last(); ok; ok = iter.Prev() {
+		if len(iter.Key()) == 9 && len(iter.Value()) == 4 {
+			number = binary.BigEndian.Uint32(iter.Value())
+			found = true
+			break
+		}
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, scom.ErrNotFound
+	}
+	return s.GetEpochByNumber(number)
+}
+
 // GetStorageState return the storage value of the key in smart contract.
 func (s *StateStore) GetStorageState(key *states.StorageKey) (*states.StorageItem, error) {
 	storeKey, err := s.getStorageKey(key)
@@ -405,6 +503,28 @@ func (s *StateStore) getProcessedHeight() (uint64, error) {
 	}
 }
 
+// SaveChainParams persist chain parameters fixed at genesis to state store
+func (s *StateStore) SaveChainParams(params *states.ChainParams) error {
+	value := bytes.NewBuffer(nil)
+	if err := params.Serialize(value); err != nil {
+		return err
+	}
+	return s.store.Put(s.getChainParamsKey(), value.Bytes())
+}
+
+// GetChainParams return chain parameters saved at genesis, ErrNotFound if ledger has no parameters
+func (s *StateStore) GetChainParams() (*states.ChainParams, error) {
+	value, err := s.store.Get(s.getChainParamsKey())
+	if err != nil {
+		return nil, err
+	}
+	params := new(states.ChainParams)
+	if err := params.Deserialize(bytes.NewReader(value)); err != nil {
+		return nil, err
+	}
+	return params, nil
+}
+
 func (s *StateStore) getCurrentBlockKey() []byte {
 	return []byte{byte(scom.SYS_CURRENT_BLOCK)}
 }
@@ -413,6 +533,10 @@ func (s *StateStore) getProcessedHeightKey() []byte {
 	return []byte{byte(scom.SYS_PROCESSED_SRC_HEIGHT)}
 }
 
+func (s *StateStore) getChainParamsKey() []byte {
+	return []byte{byte(scom.SYS_CHAIN_PARAMS)}
+}
+
 func (s *StateStore) getEpochKey() ([]byte, error) {
 	key := make([]byte, 1+len(BOOKKEEPER))
 	key[0] = byte(scom.ST_BOOKKEEPER)
@@ -466,6 +590,28 @@ func genCrossStatesRootKey(height uint64) []byte {
 	return key
 }
 
+func genEpochKey(number uint32) []byte {
+	key := make([]byte, 5)
+	key[0] = byte(scom.ST_EPOCH)
+	binary.BigEndian.PutUint32(key[1:], number)
+	return key
+}
+
+func genEpochBlockHashKey(blockHash common.Uint256) []byte {
+	key := make([]byte, 1+common.UINT256_SIZE)
+	key[0] = byte(scom.IX_EPOCH_BLOCK_HASH)
+	copy(key[1:], blockHash[:])
+	return key
+}
+
+// genEpochHeightKey use big endian height to keep epochs ordered by height in iteration
+func genEpochHeightKey(height uint64) []byte {
+	key := make([]byte, 9)
+	key[0] = byte(scom.IX_EPOCH_HEIGHT)
+	binary.BigEndian.PutUint64(key[1:], height)
+	return key
+}
+
 func (s *StateStore) genStateMerkleRootKey(height uint64) []byte {
 	key := make([]byte, 9, 9)
 	key[0] = byte(scom.DATA_STATE_MERKLE_ROOT)
@@ -473,6 +619,125 @@ func (s *StateStore) genStateMerkleRootKey(height uint64) []byte {
 	return key
 }
 
+// RollbackTo remove state merkle roots, cross states and epochs of blocks above height,
+// save block and state merkle trees truncated to height and set the block specified by height and block hash as current.
+// Trees kept in memory and merkle hash file are truncated by truncateMerkleTrees after rollback is committed.
+// Smart contract storage changes are not reverted.
+func (s *StateStore) RollbackTo(height, currHeight uint64, blockHash common.Uint256, processedHeight uint64) error {
+	blockHashes, err := s.merkleTree.CompactHashes(height + 1)
+	if err != nil {
+		return fmt.Errorf("block merkle tree CompactHashes error %s", err)
+	}
+	deltaMerkleTree := merkle.NewTree(0, nil, nil)
+	for h := s.stateHashCheckHeight; h <= height; h++ {
+		value, err := s.store.Get(s.genStateMerkleRootKey(h))
+		if err != nil {
+			return fmt.Errorf("get state merkle root height %d error %s", h, err)
+		}
+		writeSetHash, eof := common.NewZeroCopySource(value).NextHash()
+		if eof {
+			return io.ErrUnexpectedEOF
+		}
+		deltaMerkleTree.Append(writeSetHash.ToArray())
+	}
+
+	s.NewBatch()
+	for h := height + 1; h <= currHeight; h++ {
+		s.store.BatchDelete(s.genStateMerkleRootKey(h))
+		s.store.BatchDelete(genCrossStatesKey(h))
+		s.store.BatchDelete(genCrossStatesRootKey(h))
+	}
+	if err := s.rollbackEpochs(height); err != nil {
+		return fmt.Errorf("rollbackEpochs error %s", err)
+	}
+
+	value := common.NewZeroCopySink(nil)
+	value.WriteUint64(height + 1)
+	for _, hash := range blockHashes {
+		value.WriteHash(hash)
+	}
+	s.store.BatchPut(s.genBlockMerkleTreeKey(), value.Bytes())
+	if height >= s.stateHashCheckHeight {
+		value = common.NewZeroCopySink(nil)
+		value.WriteUint64(deltaMerkleTree.TreeSize())
+		for _, hash := range deltaMerkleTree.Hashes() {
+			value.WriteHash(hash)
+		}
+		s.store.BatchPut(s.genStateMerkleTreeKey(), value.Bytes())
+	} else {
+		s.store.BatchDelete(s.genStateMerkleTreeKey())
+	}
+	if err := s.SaveCurrentBlock(height, blockHash); err != nil {
+		return err
+	}
+	if err := s.SaveProcessedHeight(processedHeight); err != nil {
+		return err
+	}
+	return s.CommitTo()
+}
+
+// truncateMerkleTrees truncate block merkle tree to height and reload state merkle tree saved by RollbackTo.
+// Merkle hash store is consistent with bigger hash file, so it is truncated only after rollback is committed
+func (s *StateStore) truncateMerkleTrees(height uint64) error {
+	if err := s.merkleTree.Truncate(height + 1); err != nil {
+		return fmt.Errorf("block merkle tree Truncate error %s", err)
+	}
+	s.deltaMerkleTree = merkle.NewTree(0, nil, nil)
+	return s.loadStateMerkleTree()
+}
+
+// rollbackEpochs remove epochs applied above height and restore book keeper state of the epoch active at height
+func (s *StateStore) rollbackEpochs(height uint64) error {
+	iter := s.store.NewIterator([]byte{byte(scom.IX_EPOCH_HEIGHT)})
+	var removed []uint32
+	for iter.Next() {
+		key, value := iter.Key(), iter.Value()
+		if len(key) != 9 || len(value) != 4 {
+			continue
+		}
+		if binary.BigEndian.Uint64(key[1:]) > height {
+			s.store.BatchDelete(key)
+			removed = append(removed, binary.BigEndian.Uint32(value))
+		}
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		return err
+	}
+	if len(removed) == 0 {
+		return nil
+	}
+	for _, number := range removed {
+		epoch, err := s.GetEpochByNumber(number)
+		if err != nil {
+			return fmt.Errorf("GetEpochByNumber %d error %s", number, err)
+		}
+		s.store.BatchDelete(genEpochKey(number))
+		s.store.BatchDelete(genEpochBlockHashKey(epoch.BlockHash))
+	}
+
+	key, err := s.getEpochKey()
+	if err != nil {
+		return err
+	}
+	epoch, err := s.GetEpochAtHeight(height)
+	if err == scom.ErrNotFound {
+		s.store.BatchDelete(key)
+		return nil
+	} else if err != nil {
+		return err
+	}
+	bookkeeperState := &states.EpochState{
+		CurrEpoch: epoch.PublicKeys,
+	}
+	value := bytes.NewBuffer(nil)
+	if err := bookkeeperState.Serialize(value); err != nil {
+		return err
+	}
+	s.store.BatchPut(key, value.Bytes())
+	return nil
+}
+
 // ClearAll clear all data in state store
 func (s *StateStore) ClearAll() error {
 	s.store.NewBatch()
@@ -4,7 +4,10 @@ import (
 	"math/rand"
 	"testing"
 
+	"github.com/eywa-protocol/bls-crypto/bls"
 	"github.com/eywa-protocol/chain/common"
+	"github.com/eywa-protocol/chain/core/states"
+	scom "github.com/eywa-protocol/chain/core/store/common"
 	"github.com/eywa-protocol/chain/merkle"
 	"github.com/stretchr/testify/assert"
 )
@@ -53,3 +56,47 @@ func TestStateMerkleRoot(t *testing.T) {
 	}
 
 }
+
+func TestEpochHistory(t *testing.T) {
+	db := NewMemStateStore(0)
+	_, pubKey1 := bls.GenerateRandomKey()
+	_, pubKey2 := bls.GenerateRandomKey()
+	keys := []bls.PublicKey{pubKey1, pubKey2}
+	epochKey := bls.AggregatePublicKeys(keys, bls.CalculateAntiRogueCoefficients(keys))
+
+	_, err := db.GetEpochAtHeight(0)
+	assert.Equal(t, scom.ErrNotFound, err)
+
+	for i, height := range []uint64{0, 10, 20} {
+		db.NewBatch()
+		err := db.SaveEpoch(&states.EpochInfo{
+			Number:         uint32(i + 1),
+			BlockHash:      common.Uint256{byte(i + 1)},
+			Height:         height,
+			EpochPublicKey: epochKey,
+			PublicKeys:     keys,
+			HostIds:        []string{"one", "two"},
+		})
+		assert.Nil(t, err)
+		assert.Nil(t, db.CommitTo())
+	}
+
+	epoch, err := db.GetEpochByNumber(2)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(10), epoch.Height)
+	assert.Equal(t, []string{"one", "two"}, epoch.HostIds)
+
+	epoch, err = db.GetEpochByBlockHash(common.Uint256{3})
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(3), epoch.Number)
+
+	for height, number := range map[uint64]uint32{0: 1, 9: 1, 10: 2, 19: 2, 20: 3, 1000: 3} {
+		epoch, err = db.GetEpochAtHeight(height)
+		assert.Nil(t, err)
+		assert.Equal(t, number, epoch.Number)
+	}
+
+	bookkeeperState, err := db.GetEpochState()
+	assert.Nil(t, err)
+	assert.Equal(t, len(keys), len(bookkeeperState.CurrEpoch))
+}
@@ -0,0 +1,20 @@
+package ledgerstore
+
+import (
+	// register persist store backends selectable by StoreConfig
+	_ "github.com/eywa-protocol/chain/core/store/leveldbstore"
+	_ "github.com/eywa-protocol/chain/core/store/memstore"
+)
+
+// StoreConfig select persist store backend of each ledger store, empty backend means scom.DefaultBackend.
+// Registered backends are goleveldb and memory, see scom.Backends
+type StoreConfig struct {
+	BlockBackend string // Backend of block store
+	StateBackend string // Backend of state store
+	EventBackend string // Backend of event store
+	SingleDB     bool   // Keep all stores in one database opened with BlockBackend and commit each block in one batch
+
+	// Count of recent blocks kept with transactions, event notifies and cross states, older ones are pruned
+	// in background. 0 disables pruning and keeps all blocks
+	PruneKeepBlocks uint64
+}
@@ -17,6 +17,14 @@ type LevelDBStore struct {
 	batch *leveldb.Batch
 }
 
+const BackendName = "goleveldb" // Name of the backend in store backends registry
+
+func init() {
+	common.RegisterBackend(BackendName, func(path string) (common.PersistStore, error) {
+		return NewLevelDBStore(path)
+	})
+}
+
 // BITSPERKEY used to compute the size of bloom filter bits array .
 // too small will lead to high false positive rate.
 const BITSPERKEY = 10
@@ -139,3 +147,8 @@ func (s *LevelDBStore) NewIterator(prefix []byte) common.StoreIterator {
 
 	return iter
 }
+
+// NewRangeIterator return a iterator of leveldb with the keys in range [start, end)
+func (s *LevelDBStore) NewRangeIterator(start, end []byte) common.StoreIterator {
+	return s.db.NewIterator(&util.Range{Start: start, Limit: end}, nil)
+}
@@ -4,6 +4,11 @@ import (
 	"fmt"
 	"os"
 	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/eywa-protocol/chain/core/store/common"
+	"github.com/eywa-protocol/chain/core/store/storetest"
 )
 
 var testLevelDB *LevelDBStore
@@ -123,3 +128,16 @@ func TestIterator(t *testing.T) {
 	}
 
 }
+
+func TestConformance(t *testing.T) {
+	storetest.RunConformance(t, func(t *testing.T) common.PersistStore {
+		store, err := NewLevelDBStore(t.TempDir())
+		require.NoError(t, err)
+		return store
+	})
+	storetest.RunConformance(t, func(t *testing.T) common.PersistStore {
+		store, err := NewMemLevelDBStore()
+		require.NoError(t, err)
+		return store
+	})
+}
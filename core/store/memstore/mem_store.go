@@ -0,0 +1,243 @@
+package memstore
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/eywa-protocol/chain/core/store/common"
+)
+
+const BackendName = "memory" // Name of the backend in store backends registry
+
+func init() {
+	common.RegisterBackend(BackendName, func(path string) (common.PersistStore, error) {
+		return NewMemStore(), nil
+	})
+}
+
+// batchOp is put or delete operation of commit batch
+type batchOp struct {
+	key    string
+	value  []byte
+	delete bool
+}
+
+// MemStore is pure in memory persist store, data is lost on close
+type MemStore struct {
+	lock   sync.RWMutex
+	data   map[string][]byte
+	batch  []batchOp
+	closed bool
+}
+
+// NewMemStore return empty MemStore instance
+func NewMemStore() *MemStore {
+	return &MemStore{data: make(map[string][]byte)}
+}
+
+// Put a key-value pair to store
+func (s *MemStore) Put(key []byte, value []byte) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.closed {
+		return common.ErrClosed
+	}
+	s.data[string(key)] = copyBytes(value)
+	return nil
+}
+
+// Get the value of a key from store
+func (s *MemStore) Get(key []byte) ([]byte, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if s.closed {
+		return nil, common.ErrClosed
+	}
+	value, ok := s.data[string(key)]
+	if !ok {
+		return nil, common.ErrNotFound
+	}
+	return copyBytes(value), nil
+}
+
+// Has return whether the key is exist in store
+func (s *MemStore) Has(key []byte) (bool, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if s.closed {
+		return false, common.ErrClosed
+	}
+	_, ok := s.data[string(key)]
+	return ok, nil
+}
+
+// Delete the key in store
+func (s *MemStore) Delete(key []byte) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.closed {
+		return common.ErrClosed
+	}
+	delete(s.data, string(key))
+	return nil
+}
+
+// NewBatch start commit batch, uncommitted batch is discarded
+func (s *MemStore) NewBatch() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.batch = make([]batchOp, 0)
+}
+
+// BatchPut put a key-value pair to batch
+func (s *MemStore) BatchPut(key []byte, value []byte) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.batch = append(s.batch, batchOp{key: string(key), value: copyBytes(value)})
+}
+
+// BatchDelete delete a key in batch
+func (s *MemStore) BatchDelete(key []byte) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.batch = append(s.batch, batchOp{key: string(key), delete: true})
+}
+
+// BatchCommit apply batch operations to store in the order they were added
+func (s *MemStore) BatchCommit() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.closed {
+		return common.ErrClosed
+	}
+	for _, op := range s.batch {
+		if op.delete {
+			delete(s.data, op.key)
+		} else {
+			s.data[op.key] = op.value
+		}
+	}
+	s.batch = nil
+	return nil
+}
+
+// Close store and release its data
+func (s *MemStore) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.closed {
+		return common.ErrClosed
+	}
+	s.closed = true
+	s.data = nil
+	s.batch = nil
+	return nil
+}
+
+// NewIterator return iterator over snapshot of the keys with prefix in ascending order
+func (s *MemStore) NewIterator(prefix []byte) common.StoreIterator {
+	return s.newIterator(func(key string) bool {
+		return strings.HasPrefix(key, string(prefix))
+	})
+}
+
+// NewRangeIterator return iterator over snapshot of the keys in range [start, end) in ascending order
+func (s *MemStore) NewRangeIterator(start, end []byte) common.StoreIterator {
+	return s.newIterator(func(key string) bool {
+		return key >= string(start) && (end == nil || key < string(end))
+	})
+}
+
+func (s *MemStore) newIterator(match func(key string) bool) common.StoreIterator {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if s.closed {
+		return &memIterator{pos: -1, err: common.ErrClosed}
+	}
+	keys := make([]string, 0)
+	for key := range s.data {
+		if match(key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	iter := &memIterator{
+		keys:   make([][]byte, len(keys)),
+		values: make([][]byte, len(keys)),
+		pos:    -1,
+	}
+	for i, key := range keys {
+		iter.keys[i] = []byte(key)
+		iter.values[i] = copyBytes(s.data[key])
+	}
+	return iter
+}
+
+// memIterator iterate snapshot of store keys
+type memIterator struct {
+	keys   [][]byte
+	values [][]byte
+	pos    int
+	err    error
+}
+
+func (it *memIterator) Next() bool {
+	if it.pos < len(it.keys) {
+		it.pos++
+	}
+	return it.pos < len(it.keys)
+}
+
+func (it *memIterator) Prev() bool {
+	if it.pos >= 0 {
+		it.pos--
+	}
+	return it.pos >= 0
+}
+
+func (it *memIterator) First() bool {
+	it.pos = 0
+	return len(it.keys) > 0
+}
+
+func (it *memIterator) Last() bool {
+	it.pos = len(it.keys) - 1
+	return it.pos >= 0
+}
+
+func (it *memIterator) Seek(key []byte) bool {
+	it.pos = sort.Search(len(it.keys), func(i int) bool {
+		return bytes.Compare(it.keys[i], key) >= 0
+	})
+	return it.pos < len(it.keys)
+}
+
+func (it *memIterator) Key() []byte {
+	if it.pos < 0 || it.pos >= len(it.keys) {
+		return nil
+	}
+	return it.keys[it.pos]
+}
+
+func (it *memIterator) Value() []byte {
+	if it.pos < 0 || it.pos >= len(it.keys) {
+		return nil
+	}
+	return it.values[it.pos]
+}
+
+func (it *memIterator) Release() {
+	it.keys = nil
+	it.values = nil
+	it.pos = -1
+}
+
+func (it *memIterator) Error() error {
+	return it.err
+}
+
+func copyBytes(value []byte) []byte {
+	return append([]byte{}, value...)
+}
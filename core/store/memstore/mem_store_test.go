@@ -0,0 +1,27 @@
+package memstore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/eywa-protocol/chain/core/store/common"
+	"github.com/eywa-protocol/chain/core/store/storetest"
+)
+
+func TestConformance(t *testing.T) {
+	storetest.RunConformance(t, func(t *testing.T) common.PersistStore {
+		return NewMemStore()
+	})
+}
+
+func TestRegisteredBackend(t *testing.T) {
+	require.Contains(t, common.Backends(), BackendName)
+	store, err := common.OpenPersistStore(BackendName, "")
+	require.NoError(t, err)
+	require.IsType(t, &MemStore{}, store)
+	require.NoError(t, store.Close())
+
+	_, err = common.OpenPersistStore("unknown", "")
+	require.Error(t, err)
+}
@@ -13,14 +13,26 @@ const (
 	FromBoth           = iota
 )
 
+type iterDirection byte
+
+const (
+	dirSOI      iterDirection = iota // before the first item
+	dirEOI                           // after the last item
+	dirForward                       // positioned by First, Seek or Next
+	dirBackward                      // positioned by Last or Prev
+)
+
+// JoinIter merge memdb and backend iterators, memdb items override backend items with the same key
+// and items with empty value in memdb are deleted ones, they are skipped
 type JoinIter struct {
-	backend     common.StoreIterator
-	memdb       common.StoreIterator
-	key, value  []byte
-	keyOrigin   KeyOrigin
-	nextMemEnd  bool
-	nextBackEnd bool
-	cmp         comparer.BasicComparer
+	backend    common.StoreIterator
+	memdb      common.StoreIterator
+	key, value []byte
+	keyOrigin  KeyOrigin
+	memValid   bool
+	backValid  bool
+	dir        iterDirection
+	cmp        comparer.BasicComparer
 }
 
 func NewJoinIter(memIter, backendIter common.StoreIterator) *JoinIter {
@@ -32,62 +44,21 @@ func NewJoinIter(memIter, backendIter common.StoreIterator) *JoinIter {
 }
 
 func (iter *JoinIter) First() bool {
-	f := iter.first()
-	if f == false {
-		return false
-	}
-	for len(iter.value) == 0 {
-		if iter.next() == false {
-			return false
-		}
-	}
+	iter.memValid = iter.memdb.First()
+	iter.backValid = iter.backend.First()
+	return iter.forward()
+}
 
-	return true
+func (iter *JoinIter) Last() bool {
+	iter.memValid = iter.memdb.Last()
+	iter.backValid = iter.backend.Last()
+	return iter.backward()
 }
 
-func (iter *JoinIter) first() bool {
-	var bkey, bval, mkey, mval []byte
-	back := iter.backend.First()
-	mem := iter.memdb.First()
-	// check error
-	if iter.Error() != nil {
-		return false
-	}
-	if back {
-		bkey = iter.backend.Key()
-		bval = iter.backend.Value()
-		if mem == false {
-			iter.key = bkey
-			iter.value = bval
-			iter.keyOrigin = FromBack
-			return true
-		}
-		mkey = iter.memdb.Key()
-		mval = iter.memdb.Value()
-		cmp := iter.cmp.Compare(mkey, bkey)
-		if cmp < 1 {
-			iter.key = mkey
-			iter.value = mval
-			if cmp == 0 {
-				iter.keyOrigin = FromBoth
-			} else {
-				iter.keyOrigin = FromMem
-			}
-		} else {
-			iter.key = bkey
-			iter.value = bval
-			iter.keyOrigin = FromBack
-		}
-		return true
-	} else {
-		if mem {
-			iter.key = iter.memdb.Key()
-			iter.value = iter.memdb.Value()
-			iter.keyOrigin = FromMem
-			return true
-		}
-		return false
-	}
+func (iter *JoinIter) Seek(key []byte) bool {
+	iter.memValid = iter.memdb.Seek(key)
+	iter.backValid = iter.backend.Seek(key)
+	return iter.forward()
 }
 
 func (iter *JoinIter) Key() []byte {
@@ -99,71 +70,132 @@ func (iter *JoinIter) Value() []byte {
 }
 
 func (iter *JoinIter) Next() bool {
-	f := iter.next()
-	if f == false {
+	switch iter.dir {
+	case dirSOI:
+		return iter.First()
+	case dirEOI:
 		return false
+	case dirBackward:
+		// position both iterators after the current key
+		key := append([]byte{}, iter.key...)
+		iter.memValid = iter.memdb.Seek(key)
+		if iter.memValid && iter.cmp.Compare(iter.memdb.Key(), key) == 0 {
+			iter.memValid = iter.memdb.Next()
+		}
+		iter.backValid = iter.backend.Seek(key)
+		if iter.backValid && iter.cmp.Compare(iter.backend.Key(), key) == 0 {
+			iter.backValid = iter.backend.Next()
+		}
+	default:
+		iter.advance()
 	}
+	return iter.forward()
+}
 
-	for len(iter.value) == 0 {
-		if iter.next() == false {
-			return false
+func (iter *JoinIter) Prev() bool {
+	switch iter.dir {
+	case dirSOI:
+		return false
+	case dirEOI:
+		return iter.Last()
+	case dirForward:
+		// position both iterators before the current key
+		key := append([]byte{}, iter.key...)
+		if iter.memdb.Seek(key) {
+			iter.memValid = iter.memdb.Prev()
+		} else {
+			iter.memValid = iter.memdb.Last()
+		}
+		if iter.backend.Seek(key) {
+			iter.backValid = iter.backend.Prev()
+		} else {
+			iter.backValid = iter.backend.Last()
 		}
+	default:
+		iter.retreat()
 	}
+	return iter.backward()
+}
 
-	return true
+// advance move forward iterators the current item was taken from
+func (iter *JoinIter) advance() {
+	if iter.keyOrigin != FromBack {
+		iter.memValid = iter.memdb.Next()
+	}
+	if iter.keyOrigin != FromMem {
+		iter.backValid = iter.backend.Next()
+	}
 }
 
-func (iter *JoinIter) next() bool {
-	if (iter.keyOrigin == FromMem || iter.keyOrigin == FromBoth) && iter.nextMemEnd == false {
-		iter.nextMemEnd = !iter.memdb.Next()
+// retreat move backward iterators the current item was taken from
+func (iter *JoinIter) retreat() {
+	if iter.keyOrigin != FromBack {
+		iter.memValid = iter.memdb.Prev()
 	}
-	if (iter.keyOrigin == FromBack || iter.keyOrigin == FromBoth) && iter.nextBackEnd == false {
-		iter.nextBackEnd = !iter.backend.Next()
+	if iter.keyOrigin != FromMem {
+		iter.backValid = iter.backend.Prev()
 	}
+}
 
-	// check error
-	if iter.Error() != nil {
-		return false
+// forward take the smallest item of iterators skipping deleted ones
+func (iter *JoinIter) forward() bool {
+	for iter.take(false) {
+		iter.dir = dirForward
+		if len(iter.value) != 0 {
+			return true
+		}
+		iter.advance()
 	}
+	iter.dir = dirEOI
+	return false
+}
 
-	if iter.nextBackEnd {
-		if iter.nextMemEnd {
-			iter.key = nil
-			iter.value = nil
-			return false
-		} else {
-			iter.key = iter.memdb.Key()
-			iter.value = iter.memdb.Value()
-			iter.keyOrigin = FromMem
+// backward take the largest item of iterators skipping deleted ones
+func (iter *JoinIter) backward() bool {
+	for iter.take(true) {
+		iter.dir = dirBackward
+		if len(iter.value) != 0 {
+			return true
 		}
-	} else {
-		if iter.nextMemEnd {
-			iter.key = iter.backend.Key()
-			iter.value = iter.backend.Value()
+		iter.retreat()
+	}
+	iter.dir = dirSOI
+	return false
+}
+
+// take set current item to the smallest or the largest item of iterators, memdb item wins on equal keys
+func (iter *JoinIter) take(largest bool) bool {
+	if iter.Error() != nil || !iter.memValid && !iter.backValid {
+		iter.key = nil
+		iter.value = nil
+		return false
+	}
+	switch {
+	case !iter.backValid:
+		iter.keyOrigin = FromMem
+	case !iter.memValid:
+		iter.keyOrigin = FromBack
+	default:
+		cmp := iter.cmp.Compare(iter.memdb.Key(), iter.backend.Key())
+		if largest {
+			cmp = -cmp
+		}
+		switch {
+		case cmp < 0:
+			iter.keyOrigin = FromMem
+		case cmp == 0:
+			iter.keyOrigin = FromBoth
+		default:
 			iter.keyOrigin = FromBack
-		} else {
-			bkey := iter.backend.Key()
-			mkey := iter.memdb.Key()
-			cmp := iter.cmp.Compare(mkey, bkey)
-			switch cmp {
-			case -1:
-				iter.key = mkey
-				iter.value = iter.memdb.Value()
-				iter.keyOrigin = FromMem
-			case 0:
-				iter.key = mkey
-				iter.value = iter.memdb.Value()
-				iter.keyOrigin = FromBoth
-			case 1:
-				iter.key = bkey
-				iter.value = iter.backend.Value()
-				iter.keyOrigin = FromBack
-			default:
-				panic("unreachable")
-			}
 		}
 	}
-
+	if iter.keyOrigin == FromBack {
+		iter.key = iter.backend.Key()
+		iter.value = iter.backend.Value()
+	} else {
+		iter.key = iter.memdb.Key()
+		iter.value = iter.memdb.Value()
+	}
 	return true
 }
 
@@ -98,3 +98,11 @@ func (self *OverlayDB) NewIterator(key []byte) common.StoreIterator {
 
 	return NewJoinIter(memIter, backIter)
 }
+
+// NewRangeIterator return iterator of the keys in range [start, end), params are referenced by iterator
+func (self *OverlayDB) NewRangeIterator(start, end []byte) common.StoreIterator {
+	backIter := self.store.NewRangeIterator(start, end)
+	memIter := self.memdb.NewIterator(&util.Range{Start: start, Limit: end})
+
+	return NewJoinIter(memIter, backIter)
+}
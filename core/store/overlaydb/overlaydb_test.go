@@ -51,6 +51,79 @@ func TestNewOverlayDB(t *testing.T) {
 	}
 }
 
+func TestJoinIterator(t *testing.T) {
+	store, err := leveldbstore.NewMemLevelDBStore()
+	assert.Nil(t, err)
+	for i := 0; i < 10; i++ {
+		assert.Nil(t, store.Put(makeKey(i), []byte("back"+strconv.Itoa(i))))
+	}
+
+	overlay := NewOverlayDB(store)
+	overlay.Delete(makeKey(0))
+	overlay.Delete(makeKey(2))
+	overlay.Delete(makeKey(9))
+	overlay.Delete(makeKey(11))
+	overlay.Put(makeKey(5), []byte("mem5"))
+	overlay.Put(makeKey(12), []byte("mem12"))
+
+	expected := []int{1, 3, 4, 5, 6, 7, 8, 12}
+	value := func(i int) []byte {
+		if i == 5 || i == 12 {
+			return []byte("mem" + strconv.Itoa(i))
+		}
+		return []byte("back" + strconv.Itoa(i))
+	}
+
+	iter := overlay.NewIterator([]byte("key"))
+	for _, i := range expected {
+		assert.True(t, iter.Next())
+		assert.Equal(t, makeKey(i), iter.Key())
+		assert.Equal(t, value(i), iter.Value())
+	}
+	assert.False(t, iter.Next())
+	for j := len(expected) - 1; j >= 0; j-- {
+		assert.True(t, iter.Prev())
+		assert.Equal(t, makeKey(expected[j]), iter.Key())
+		assert.Equal(t, value(expected[j]), iter.Value())
+	}
+	assert.False(t, iter.Prev())
+	assert.True(t, iter.Next())
+	assert.Equal(t, makeKey(1), iter.Key())
+
+	// change direction in the middle
+	assert.True(t, iter.Seek(makeKey(2)))
+	assert.Equal(t, makeKey(3), iter.Key())
+	assert.True(t, iter.Next())
+	assert.Equal(t, makeKey(4), iter.Key())
+	assert.True(t, iter.Prev())
+	assert.Equal(t, makeKey(3), iter.Key())
+	assert.True(t, iter.Prev())
+	assert.Equal(t, makeKey(1), iter.Key())
+	assert.True(t, iter.Next())
+	assert.Equal(t, makeKey(3), iter.Key())
+
+	assert.True(t, iter.Last())
+	assert.Equal(t, makeKey(12), iter.Key())
+	assert.True(t, iter.Prev())
+	assert.Equal(t, makeKey(8), iter.Key())
+	assert.False(t, iter.Seek(makeKey(13)))
+	assert.True(t, iter.Prev())
+	assert.Equal(t, makeKey(12), iter.Key())
+	assert.Nil(t, iter.Error())
+	iter.Release()
+
+	iter = overlay.NewRangeIterator(makeKey(2), makeKey(9))
+	assert.True(t, iter.Last())
+	assert.Equal(t, makeKey(8), iter.Key())
+	var keys [][]byte
+	for iter.First(); iter.Key() != nil; iter.Next() {
+		keys = append(keys, append([]byte{}, iter.Key()...))
+	}
+	assert.Equal(t, [][]byte{makeKey(3), makeKey(4), makeKey(5), makeKey(6), makeKey(7), makeKey(8)}, keys)
+	assert.Nil(t, iter.Error())
+	iter.Release()
+}
+
 func BenchmarkOverlayDBSerialPut(b *testing.B) {
 	store, _ := leveldbstore.NewMemLevelDBStore()
 
@@ -0,0 +1,291 @@
+package sharedstore
+
+import (
+	"sync"
+
+	"github.com/eywa-protocol/chain/core/store/common"
+)
+
+// batchOp is put or delete operation of commit batch
+type batchOp struct {
+	key    []byte
+	value  []byte
+	delete bool
+}
+
+// SharedStore keep several logical stores in one physical store, each under its one byte namespace prefix.
+// Batches of all namespaces committed between Begin and Commit are written to the physical store in one atomic batch
+type SharedStore struct {
+	store   common.PersistStore // Physical store
+	lock    sync.Mutex
+	atomic  bool      // Namespace batches are deferred until Commit
+	pending []batchOp // Operations of deferred namespace batches
+	views   int       // Count of open namespaces, physical store is closed with the last one
+}
+
+// NewSharedStore return SharedStore over the physical store
+func NewSharedStore(store common.PersistStore) *SharedStore {
+	return &SharedStore{store: store}
+}
+
+// Namespace return logical store keeping its keys under the prefix
+func (s *SharedStore) Namespace(prefix byte) common.PersistStore {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.views++
+	return &namespaceStore{shared: s, prefix: prefix}
+}
+
+// Begin defer commit of namespace batches until Commit or Discard
+func (s *SharedStore) Begin() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.atomic = true
+	s.pending = nil
+}
+
+// Commit write namespace batches committed since Begin in one batch
+func (s *SharedStore) Commit() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	pending := s.pending
+	s.atomic = false
+	s.pending = nil
+	return s.write(pending)
+}
+
+// NewBatch return batch of namespace keys committed to physical store on its own
+func (s *SharedStore) NewBatch() *Batch {
+	return &Batch{shared: s}
+}
+
+// Discard drop namespace batches committed since Begin
+func (s *SharedStore) Discard() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.atomic = false
+	s.pending = nil
+}
+
+// commitBatch write namespace batch or defer it until Commit
+func (s *SharedStore) commitBatch(ops []batchOp) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.atomic {
+		s.pending = append(s.pending, ops...)
+		return nil
+	}
+	return s.write(ops)
+}
+
+// write operations to physical store batch, must be called with lock held
+func (s *SharedStore) write(ops []batchOp) error {
+	s.store.NewBatch()
+	for _, op := range ops {
+		if op.delete {
+			s.store.BatchDelete(op.key)
+		} else {
+			s.store.BatchPut(op.key, op.value)
+		}
+	}
+	return s.store.BatchCommit()
+}
+
+// Batch collect operations of several namespaces. It is neither deferred by Begin nor dropped by Discard,
+// so writers running concurrently with atomic commit keep their changes apart from it
+type Batch struct {
+	shared *SharedStore
+	ops    []batchOp
+}
+
+// Put a key-value pair of the namespace to batch
+func (b *Batch) Put(prefix byte, key []byte, value []byte) {
+	b.ops = append(b.ops, batchOp{key: namespaceKey(prefix, key), value: append([]byte{}, value...)})
+}
+
+// Delete a key of the namespace in batch
+func (b *Batch) Delete(prefix byte, key []byte) {
+	b.ops = append(b.ops, batchOp{key: namespaceKey(prefix, key), delete: true})
+}
+
+// Commit write batch to physical store
+func (b *Batch) Commit() error {
+	b.shared.lock.Lock()
+	defer b.shared.lock.Unlock()
+	ops := b.ops
+	b.ops = nil
+	return b.shared.write(ops)
+}
+
+// namespaceKey return key of physical store
+func namespaceKey(prefix byte, key []byte) []byte {
+	prefixed := make([]byte, 1+len(key))
+	prefixed[0] = prefix
+	copy(prefixed[1:], key)
+	return prefixed
+}
+
+// release namespace, the last one closes physical store
+func (s *SharedStore) release() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.views--
+	if s.views > 0 {
+		return nil
+	}
+	return s.store.Close()
+}
+
+// namespaceStore is logical store of SharedStore
+type namespaceStore struct {
+	shared *SharedStore
+	prefix byte
+	lock   sync.Mutex
+	batch  []batchOp
+	closed bool
+}
+
+func (s *namespaceStore) key(key []byte) []byte {
+	return namespaceKey(s.prefix, key)
+}
+
+func (s *namespaceStore) isClosed() bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.closed
+}
+
+// Put a key-value pair to store
+func (s *namespaceStore) Put(key []byte, value []byte) error {
+	if s.isClosed() {
+		return common.ErrClosed
+	}
+	return s.shared.store.Put(s.key(key), value)
+}
+
+// Get the value of a key from store
+func (s *namespaceStore) Get(key []byte) ([]byte, error) {
+	if s.isClosed() {
+		return nil, common.ErrClosed
+	}
+	return s.shared.store.Get(s.key(key))
+}
+
+// Has return whether the key is exist in store
+func (s *namespaceStore) Has(key []byte) (bool, error) {
+	if s.isClosed() {
+		return false, common.ErrClosed
+	}
+	return s.shared.store.Has(s.key(key))
+}
+
+// Delete the key in store
+func (s *namespaceStore) Delete(key []byte) error {
+	if s.isClosed() {
+		return common.ErrClosed
+	}
+	return s.shared.store.Delete(s.key(key))
+}
+
+// NewBatch start commit batch, uncommitted batch is discarded
+func (s *namespaceStore) NewBatch() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.batch = make([]batchOp, 0)
+}
+
+// BatchPut put a key-value pair to batch
+func (s *namespaceStore) BatchPut(key []byte, value []byte) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.batch = append(s.batch, batchOp{key: s.key(key), value: append([]byte{}, value...)})
+}
+
+// BatchDelete delete a key in batch
+func (s *namespaceStore) BatchDelete(key []byte) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.batch = append(s.batch, batchOp{key: s.key(key), delete: true})
+}
+
+// BatchCommit commit batch to physical store or defer it until SharedStore.Commit
+func (s *namespaceStore) BatchCommit() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.closed {
+		return common.ErrClosed
+	}
+	if err := s.shared.commitBatch(s.batch); err != nil {
+		return err
+	}
+	s.batch = nil
+	return nil
+}
+
+// Close namespace, physical store is closed with the last namespace
+func (s *namespaceStore) Close() error {
+	s.lock.Lock()
+	if s.closed {
+		s.lock.Unlock()
+		return common.ErrClosed
+	}
+	s.closed = true
+	s.batch = nil
+	s.lock.Unlock()
+	return s.shared.release()
+}
+
+// NewIterator return iterator of the namespace keys with prefix, keys are returned without namespace prefix
+func (s *namespaceStore) NewIterator(prefix []byte) common.StoreIterator {
+	if s.isClosed() {
+		return &errIterator{err: common.ErrClosed}
+	}
+	return &namespaceIterator{StoreIterator: s.shared.store.NewIterator(s.key(prefix)), prefix: s.prefix}
+}
+
+// NewRangeIterator return iterator of the namespace keys in range [start, end), keys are returned without namespace prefix
+func (s *namespaceStore) NewRangeIterator(start, end []byte) common.StoreIterator {
+	if s.isClosed() {
+		return &errIterator{err: common.ErrClosed}
+	}
+	var limit []byte
+	if end != nil {
+		limit = s.key(end)
+	} else if s.prefix < 0xff {
+		limit = []byte{s.prefix + 1}
+	}
+	return &namespaceIterator{StoreIterator: s.shared.store.NewRangeIterator(s.key(start), limit), prefix: s.prefix}
+}
+
+// namespaceIterator strip namespace prefix from keys of physical store iterator
+type namespaceIterator struct {
+	common.StoreIterator
+	prefix byte
+}
+
+func (it *namespaceIterator) Seek(key []byte) bool {
+	return it.StoreIterator.Seek(namespaceKey(it.prefix, key))
+}
+
+func (it *namespaceIterator) Key() []byte {
+	key := it.StoreIterator.Key()
+	if len(key) == 0 {
+		return key
+	}
+	return key[1:]
+}
+
+// errIterator is empty iterator failed with error
+type errIterator struct {
+	err error
+}
+
+func (it *errIterator) Next() bool           { return false }
+func (it *errIterator) Prev() bool           { return false }
+func (it *errIterator) First() bool          { return false }
+func (it *errIterator) Last() bool           { return false }
+func (it *errIterator) Seek(key []byte) bool { return false }
+func (it *errIterator) Key() []byte          { return nil }
+func (it *errIterator) Value() []byte        { return nil }
+func (it *errIterator) Release()             {}
+func (it *errIterator) Error() error         { return it.err }
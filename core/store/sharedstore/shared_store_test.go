@@ -0,0 +1,80 @@
+package sharedstore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/eywa-protocol/chain/core/store/common"
+	"github.com/eywa-protocol/chain/core/store/memstore"
+	"github.com/eywa-protocol/chain/core/store/storetest"
+)
+
+func TestConformance(t *testing.T) {
+	storetest.RunConformance(t, func(t *testing.T) common.PersistStore {
+		shared := NewSharedStore(memstore.NewMemStore())
+		// sibling namespace must not leak into the tested one
+		sibling := shared.Namespace(0x01)
+		require.NoError(t, sibling.Put([]byte("sibling"), []byte("value")))
+		return shared.Namespace(0x02)
+	})
+}
+
+func TestAtomicCommit(t *testing.T) {
+	physical := memstore.NewMemStore()
+	shared := NewSharedStore(physical)
+	blocks, states := shared.Namespace(0x01), shared.Namespace(0x02)
+
+	shared.Begin()
+	blocks.NewBatch()
+	blocks.BatchPut([]byte("height"), []byte{1})
+	require.NoError(t, blocks.BatchCommit())
+	states.NewBatch()
+	states.BatchPut([]byte("height"), []byte{1})
+	require.NoError(t, states.BatchCommit())
+	_, err := blocks.Get([]byte("height"))
+	require.Equal(t, common.ErrNotFound, err)
+	require.NoError(t, shared.Commit())
+
+	value, err := states.Get([]byte("height"))
+	require.NoError(t, err)
+	require.Equal(t, []byte{1}, value)
+	value, err = physical.Get([]byte{0x01, 'h', 'e', 'i', 'g', 'h', 't'})
+	require.NoError(t, err)
+	require.Equal(t, []byte{1}, value)
+
+	shared.Begin()
+	blocks.NewBatch()
+	blocks.BatchPut([]byte("height"), []byte{2})
+	require.NoError(t, blocks.BatchCommit())
+	shared.Discard()
+	value, err = blocks.Get([]byte("height"))
+	require.NoError(t, err)
+	require.Equal(t, []byte{1}, value)
+
+	// own batch is written apart from atomic commit
+	shared.Begin()
+	blocks.NewBatch()
+	blocks.BatchPut([]byte("height"), []byte{3})
+	require.NoError(t, blocks.BatchCommit())
+	batch := shared.NewBatch()
+	batch.Put(0x02, []byte("pruned"), []byte{1})
+	batch.Delete(0x02, []byte("height"))
+	require.NoError(t, batch.Commit())
+	shared.Discard()
+	value, err = states.Get([]byte("pruned"))
+	require.NoError(t, err)
+	require.Equal(t, []byte{1}, value)
+	_, err = states.Get([]byte("height"))
+	require.Equal(t, common.ErrNotFound, err)
+	value, err = blocks.Get([]byte("height"))
+	require.NoError(t, err)
+	require.Equal(t, []byte{1}, value)
+
+	require.NoError(t, blocks.Close())
+	_, err = physical.Get([]byte{0x02})
+	require.Equal(t, common.ErrNotFound, err, "physical store closed with open namespace")
+	require.NoError(t, states.Close())
+	_, err = physical.Get([]byte{0x02})
+	require.Equal(t, common.ErrClosed, err)
+}
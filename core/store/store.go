@@ -1,7 +1,10 @@
 package store
 
 import (
+	"io"
+
 	"github.com/eywa-protocol/chain/common"
+	"github.com/eywa-protocol/chain/core/events"
 	"github.com/eywa-protocol/chain/core/payload"
 	"github.com/eywa-protocol/chain/core/states"
 	"github.com/eywa-protocol/chain/core/store/overlaydb"
@@ -19,14 +22,57 @@ type ExecuteResult struct {
 	Notify          []*event.ExecuteNotify
 }
 
+// PrefixStats is count and size of store entries with the same key prefix
+type PrefixStats struct {
+	Store  string // Name of block, state or event store
+	Prefix byte   // First byte of entry keys
+	Keys   uint64 // Count of entries
+	Size   uint64 // Total size of entry keys and values
+}
+
+// Checks of VerifyChain reported in ChainIssue
+const (
+	CheckHeader       = "header"        // Header is saved under its hash at its height
+	CheckPrevHash     = "prev_hash"     // Header is linked to the previous block hash
+	CheckSourceHeight = "source_height" // Source height grows with block height
+	CheckSignature    = "signature"     // Header is signed by quorum of the epoch in force
+	CheckTxRoot       = "tx_root"       // Saved transactions match header transactions root
+	CheckRequestIndex = "request_index" // Request id entry points to saved transaction of the request
+	CheckBlockMerkle  = "block_merkle"  // Block merkle tree, its saved root and merkle tree file agree
+	CheckStateRoot    = "state_root"    // Saved state merkle root matches recomputed write set
+)
+
+// ChainIssue is an inconsistency found by VerifyChain
+type ChainIssue struct {
+	Height uint64 // Height of inconsistent block
+	Check  string // One of Check constants
+	Key    string `json:",omitempty"` // Hex of inconsistent store key if issue is not bound to block
+	Error  string
+}
+
+// ChainReport is the result of VerifyChain
+type ChainReport struct {
+	From     uint64
+	To       uint64
+	Headers  uint64 // Count of verified headers
+	Blocks   uint64 // Count of verified blocks with transactions, pruned blocks are not counted
+	Requests uint64 // Count of verified request id entries
+	Issues   []*ChainIssue
+}
+
 // LedgerStore provides func with store package.
 type LedgerStore interface {
 	InitLedgerStoreWithGenesisBlock(genesisblock *types.Block) error
+	InitLedgerStoreWithChainParams(genesisBlock *types.Block, params *states.ChainParams) error
+	LoadLedgerStore() error
 	Close() error
 	AddHeaders(headers []*types.Header) error
 	AddBlock(block *types.Block, stateMerkleRoot common.Uint256) error
 	ExecuteBlock(b *types.Block) (ExecuteResult, error)   // called by consensus
 	SubmitBlock(b *types.Block, exec ExecuteResult) error // called by consensus
+	RollbackTo(height uint64) error
+	ExportSnapshot(height uint64, w io.Writer) error
+	ImportSnapshot(r io.Reader, trustedHash common.Uint256) error
 	GetStateMerkleRoot(height uint64) (result common.Uint256, err error)
 	GetCrossStateRoot(height uint64) (result common.Uint256, err error)
 	GetCurrentBlockHash() common.Uint256
@@ -41,16 +87,43 @@ type LedgerStore interface {
 	GetTransaction(txHash common.Uint256) (payload.Payload, uint64, error)
 	GetTransactionByReqId(reqId [32]byte) (payload.Payload, uint64, error)
 	GetRequestState(reqId [32]byte) (payload.ReqState, error)
+	GetRequestTxHash(reqId [32]byte) (common.Uint256, error)
+	GetRequestHistory(reqId [32]byte) ([]*states.RequestStateEntry, error)
+	GetOpenRequests(olderThanHeight uint64, dstChainId uint64) ([][32]byte, error)
+	GetRequestsByDstChain(dstChainId uint64, fromHeight, toHeight uint64, offset, limit int) ([][32]byte, error)
+	GetRequestsBySrcTxHash(srcTxHash []byte) ([][32]byte, error)
+	GetRequestsByBridgeAddress(address []byte, offset, limit int) ([][32]byte, error)
+	GetRequestExpiry() uint64
+	SetRequestExpiry(expiry uint64)
+	GetPrunedHeight() uint64
+	GetEventHub() *events.Hub
+	GetDBStats() ([]*PrefixStats, error)
+	VerifyChain(from, to uint64) (*ChainReport, error)
+	GetBlockEvents(height uint64) ([]*events.Event, error)
 	IsContainBlock(blockHash common.Uint256) (bool, error)
 	IsContainTransaction(txHash common.Uint256) (bool, error)
 	GetBlockRootWithPreBlockHashes(startHeight uint64, txRoots []common.Uint256) common.Uint256
 	GetMerkleProof(raw []byte, m, n uint64) ([]byte, error)
 	GetCrossStatesProof(height uint64, key []byte) ([]byte, error)
 	GetEpochState() (*states.EpochState, error)
+	GetEpochByNumber(number uint32) (*states.EpochInfo, error)
+	GetEpochByBlockHash(blockHash common.Uint256) (*states.EpochInfo, error)
+	GetEpochAtHeight(height uint64) (*states.EpochInfo, error)
 	GetStorageItem(key *states.StorageKey) (*states.StorageItem, error)
 	PreExecuteContract(tx payload.Payload) (*cstates.PreExecResult, error)
 	GetEventNotifyByTx(tx common.Uint256) (*event.ExecuteNotify, error)
 	GetEventNotifyByBlock(height uint64) ([]*event.ExecuteNotify, error)
 	GetProcessedHeight() uint64
 	SetProcessedHeight(srcBlockHeight uint64)
+	GetChainParams() (*states.ChainParams, error)
+	GetQuorumThreshold() uint64
+	SetQuorumThreshold(threshold uint64)
+	GetConfirmationDepth(chainId uint64) uint64
+	SetConfirmationDepth(chainId uint64, depth uint64)
+	GetSourceEvent(txHash common.Uint256) (*states.SourceEventState, error)
+	GetRequestFinality(reqId [32]byte) (states.FinalityStatus, error)
+	GetPendingSourceEvents(chainId uint64) ([]*states.SourceEventState, error)
+	UpdateSourceHead(chainId uint64, head uint64) ([]common.Uint256, error)
+	ReportSourceBlock(chainId uint64, height uint64, blockHash []byte) ([]common.Uint256, error)
+	MarkSourceEventReorged(txHash common.Uint256) error
 }
@@ -0,0 +1,313 @@
+// Package storetest provides conformance suite every persist store backend must pass
+package storetest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/eywa-protocol/chain/core/store/common"
+)
+
+// OpenFunc open new empty store for a test
+type OpenFunc func(t *testing.T) common.PersistStore
+
+// RunConformance run persist store conformance suite against stores opened by open
+func RunConformance(t *testing.T, open OpenFunc) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, store common.PersistStore)
+	}{
+		{"PutGetDelete", testPutGetDelete},
+		{"Batch", testBatch},
+		{"BatchOrder", testBatchOrder},
+		{"Iterator", testIterator},
+		{"IteratorSnapshot", testIteratorSnapshot},
+		{"PrefixScan", testPrefixScan},
+		{"ReverseIterator", testReverseIterator},
+		{"Seek", testSeek},
+		{"RangeIterator", testRangeIterator},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			store := open(t)
+			defer store.Close()
+			tt.test(t, store)
+		})
+	}
+	t.Run("Close", func(t *testing.T) {
+		testClose(t, open(t))
+	})
+}
+
+func testPutGetDelete(t *testing.T, store common.PersistStore) {
+	_, err := store.Get([]byte("foo"))
+	require.Equal(t, common.ErrNotFound, err)
+	has, err := store.Has([]byte("foo"))
+	require.NoError(t, err)
+	require.False(t, has)
+
+	value := []byte("bar")
+	require.NoError(t, store.Put([]byte("foo"), value))
+	value[0] = 'c'
+	got, err := store.Get([]byte("foo"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("bar"), got)
+	has, err = store.Has([]byte("foo"))
+	require.NoError(t, err)
+	require.True(t, has)
+
+	require.NoError(t, store.Put([]byte("foo"), []byte("baz")))
+	got, err = store.Get([]byte("foo"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("baz"), got)
+
+	require.NoError(t, store.Put([]byte("empty"), nil))
+	got, err = store.Get([]byte("empty"))
+	require.NoError(t, err)
+	require.Len(t, got, 0)
+
+	require.NoError(t, store.Delete([]byte("foo")))
+	require.NoError(t, store.Delete([]byte("missing")))
+	_, err = store.Get([]byte("foo"))
+	require.Equal(t, common.ErrNotFound, err)
+}
+
+func testBatch(t *testing.T, store common.PersistStore) {
+	require.NoError(t, store.Put([]byte("k0"), []byte("v0")))
+
+	store.NewBatch()
+	store.BatchPut([]byte("k1"), []byte("v1"))
+	store.BatchPut([]byte("k2"), []byte("v2"))
+	store.BatchDelete([]byte("k0"))
+	_, err := store.Get([]byte("k1"))
+	require.Equal(t, common.ErrNotFound, err, "batch is visible before commit")
+	_, err = store.Get([]byte("k0"))
+	require.NoError(t, err, "batch is visible before commit")
+
+	require.NoError(t, store.BatchCommit())
+	got, err := store.Get([]byte("k1"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("v1"), got)
+	got, err = store.Get([]byte("k2"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("v2"), got)
+	_, err = store.Get([]byte("k0"))
+	require.Equal(t, common.ErrNotFound, err)
+
+	// new batch discards uncommitted one
+	store.NewBatch()
+	store.BatchPut([]byte("k3"), []byte("v3"))
+	store.NewBatch()
+	store.BatchPut([]byte("k4"), []byte("v4"))
+	require.NoError(t, store.BatchCommit())
+	_, err = store.Get([]byte("k3"))
+	require.Equal(t, common.ErrNotFound, err)
+	_, err = store.Get([]byte("k4"))
+	require.NoError(t, err)
+}
+
+func testBatchOrder(t *testing.T, store common.PersistStore) {
+	store.NewBatch()
+	store.BatchPut([]byte("deleted"), []byte("v"))
+	store.BatchDelete([]byte("deleted"))
+	store.BatchDelete([]byte("put"))
+	store.BatchPut([]byte("put"), []byte("v1"))
+	store.BatchPut([]byte("put"), []byte("v2"))
+	require.NoError(t, store.BatchCommit())
+
+	_, err := store.Get([]byte("deleted"))
+	require.Equal(t, common.ErrNotFound, err)
+	got, err := store.Get([]byte("put"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("v2"), got)
+}
+
+func testIterator(t *testing.T, store common.PersistStore) {
+	iter := store.NewIterator(nil)
+	require.False(t, iter.Next())
+	require.False(t, iter.First())
+	iter.Release()
+	require.NoError(t, iter.Error())
+
+	keys := [][]byte{{0x02}, {0x01, 0xff}, {0x01}, {0x01, 0x00, 0x01}, {0xff}}
+	for i, key := range keys {
+		require.NoError(t, store.Put(key, []byte{byte(i)}))
+	}
+	expected := [][]byte{{0x01}, {0x01, 0x00, 0x01}, {0x01, 0xff}, {0x02}, {0xff}}
+	require.Equal(t, expected, collectKeys(t, store, nil))
+
+	iter = store.NewIterator(nil)
+	require.True(t, iter.Next())
+	require.True(t, iter.Next())
+	require.True(t, iter.First())
+	require.Equal(t, []byte{0x01}, iter.Key())
+	require.Equal(t, []byte{2}, iter.Value())
+	iter.Release()
+	require.NoError(t, iter.Error())
+}
+
+func testIteratorSnapshot(t *testing.T, store common.PersistStore) {
+	for i := 0; i < 3; i++ {
+		require.NoError(t, store.Put([]byte(fmt.Sprintf("key%d", i)), []byte("old")))
+	}
+	iter := store.NewIterator([]byte("key"))
+	defer iter.Release()
+	require.NoError(t, store.Put([]byte("key1"), []byte("new")))
+	require.NoError(t, store.Put([]byte("key3"), []byte("new")))
+	require.NoError(t, store.Delete([]byte("key2")))
+
+	count := 0
+	for iter.Next() {
+		require.Equal(t, []byte("old"), iter.Value(), "key %s", iter.Key())
+		count++
+	}
+	require.NoError(t, iter.Error())
+	require.Equal(t, 3, count)
+}
+
+func testPrefixScan(t *testing.T, store common.PersistStore) {
+	store.NewBatch()
+	for _, key := range []string{"a", "ab", "abc", "abd", "ac", "b", "ba"} {
+		store.BatchPut([]byte(key), []byte(key))
+	}
+	require.NoError(t, store.BatchCommit())
+
+	require.Equal(t, [][]byte{[]byte("ab"), []byte("abc"), []byte("abd")}, collectKeys(t, store, []byte("ab")))
+	require.Equal(t, [][]byte{[]byte("b"), []byte("ba")}, collectKeys(t, store, []byte("b")))
+	require.Empty(t, collectKeys(t, store, []byte("c")))
+	require.Len(t, collectKeys(t, store, []byte{}), 7)
+}
+
+func testReverseIterator(t *testing.T, store common.PersistStore) {
+	iter := store.NewIterator(nil)
+	require.False(t, iter.Last())
+	require.False(t, iter.Prev())
+	iter.Release()
+
+	for _, key := range []string{"a", "b", "c", "d"} {
+		require.NoError(t, store.Put([]byte(key), []byte(key)))
+	}
+	iter = store.NewIterator(nil)
+	defer iter.Release()
+	require.False(t, iter.Prev(), "prev before the first item")
+	require.True(t, iter.Last())
+	require.Equal(t, []byte("d"), iter.Key())
+	require.Equal(t, []byte("d"), iter.Value())
+	require.True(t, iter.Prev())
+	require.Equal(t, []byte("c"), iter.Key())
+	require.True(t, iter.Next())
+	require.Equal(t, []byte("d"), iter.Key())
+	require.False(t, iter.Next())
+	require.True(t, iter.Prev(), "prev after the last item")
+	require.Equal(t, []byte("d"), iter.Key())
+	for _, key := range []string{"c", "b", "a"} {
+		require.True(t, iter.Prev())
+		require.Equal(t, []byte(key), iter.Key())
+	}
+	require.False(t, iter.Prev())
+	require.True(t, iter.Next(), "next before the first item")
+	require.Equal(t, []byte("a"), iter.Key())
+	require.NoError(t, iter.Error())
+
+	prefixed := store.NewIterator([]byte("b"))
+	defer prefixed.Release()
+	require.True(t, prefixed.Last())
+	require.Equal(t, []byte("b"), prefixed.Key())
+	require.False(t, prefixed.Prev())
+}
+
+func testSeek(t *testing.T, store common.PersistStore) {
+	for _, key := range []string{"a1", "a3", "b1", "b3"} {
+		require.NoError(t, store.Put([]byte(key), []byte(key)))
+	}
+	iter := store.NewIterator(nil)
+	defer iter.Release()
+	require.True(t, iter.Seek([]byte("a3")))
+	require.Equal(t, []byte("a3"), iter.Key())
+	require.True(t, iter.Seek([]byte("a4")))
+	require.Equal(t, []byte("b1"), iter.Key())
+	require.True(t, iter.Next())
+	require.Equal(t, []byte("b3"), iter.Key())
+	require.True(t, iter.Seek([]byte("")))
+	require.Equal(t, []byte("a1"), iter.Key())
+	require.False(t, iter.Seek([]byte("c")))
+	require.True(t, iter.Prev())
+	require.Equal(t, []byte("b3"), iter.Key())
+	require.NoError(t, iter.Error())
+
+	// seek is bounded by prefix
+	prefixed := store.NewIterator([]byte("b"))
+	defer prefixed.Release()
+	require.True(t, prefixed.Seek([]byte("a")))
+	require.Equal(t, []byte("b1"), prefixed.Key())
+	require.False(t, prefixed.Seek([]byte("b4")))
+	require.NoError(t, prefixed.Error())
+}
+
+func testRangeIterator(t *testing.T, store common.PersistStore) {
+	for _, key := range []string{"a", "b", "ba", "c", "d"} {
+		require.NoError(t, store.Put([]byte(key), []byte(key)))
+	}
+	keys := func(start, end []byte) []string {
+		iter := store.NewRangeIterator(start, end)
+		defer iter.Release()
+		var keys []string
+		for iter.Next() {
+			keys = append(keys, string(iter.Key()))
+		}
+		require.NoError(t, iter.Error())
+		return keys
+	}
+	require.Equal(t, []string{"b", "ba"}, keys([]byte("b"), []byte("c")))
+	require.Equal(t, []string{"a", "b", "ba"}, keys(nil, []byte("bb")))
+	require.Equal(t, []string{"ba", "c", "d"}, keys([]byte("b0"), nil))
+	require.Len(t, keys(nil, nil), 5)
+	require.Empty(t, keys([]byte("c"), []byte("c")))
+
+	iter := store.NewRangeIterator([]byte("b"), []byte("d"))
+	defer iter.Release()
+	require.True(t, iter.Last())
+	require.Equal(t, []byte("c"), iter.Key())
+	require.True(t, iter.Seek([]byte("a")))
+	require.Equal(t, []byte("b"), iter.Key())
+	require.False(t, iter.Prev())
+	require.False(t, iter.Seek([]byte("d")))
+	require.NoError(t, iter.Error())
+}
+
+func testClose(t *testing.T, store common.PersistStore) {
+	require.NoError(t, store.Put([]byte("foo"), []byte("bar")))
+	require.NoError(t, store.Close())
+
+	_, err := store.Get([]byte("foo"))
+	require.Error(t, err)
+	_, err = store.Has([]byte("foo"))
+	require.Error(t, err)
+	require.Error(t, store.Put([]byte("foo"), []byte("bar")))
+	require.Error(t, store.Delete([]byte("foo")))
+	store.NewBatch()
+	store.BatchPut([]byte("foo"), []byte("baz"))
+	require.Error(t, store.BatchCommit())
+
+	iter := store.NewIterator(nil)
+	require.False(t, iter.Next())
+	require.Error(t, iter.Error())
+	iter.Release()
+
+	require.Error(t, store.Close())
+}
+
+// collectKeys return copies of the keys with prefix in iteration order
+func collectKeys(t *testing.T, store common.PersistStore, prefix []byte) [][]byte {
+	iter := store.NewIterator(prefix)
+	defer iter.Release()
+	var keys [][]byte
+	for iter.Next() {
+		keys = append(keys, append([]byte{}, iter.Key()...))
+	}
+	require.NoError(t, iter.Error())
+	return keys
+}
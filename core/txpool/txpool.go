@@ -0,0 +1,329 @@
+package txpool
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/eywa-protocol/chain/common"
+	"github.com/eywa-protocol/chain/core/payload"
+	scom "github.com/eywa-protocol/chain/core/store/common"
+	"github.com/eywa-protocol/chain/core/types"
+)
+
+const (
+	DefaultMaxCount = 10000            // Default count of pending transactions
+	DefaultMaxSize  = 64 * 1024 * 1024 // Default total size of pending transactions in bytes
+	DefaultMaxAge   = time.Hour        // Default time transaction is kept pending before eviction
+)
+
+var (
+	ErrDuplicateTx      = errors.New("transaction already pending or saved to ledger")
+	ErrDuplicateRequest = errors.New("request state already pending or saved to ledger")
+	ErrPoolFull         = errors.New("transaction pool is full")
+)
+
+// Ledger is used to check transactions against committed chain state
+type Ledger interface {
+	IsContainTransaction(txHash common.Uint256) (bool, error)
+	GetRequestState(reqId [32]byte) (payload.ReqState, error)
+}
+
+// Config limits pool resources, zero values are replaced by defaults
+type Config struct {
+	MaxCount    int                             // Count of pending transactions
+	MaxSize     int                             // Total size of pending transactions in bytes
+	MaxTypeSize map[payload.TransactionType]int // Total size of pending transactions of the type in bytes, MaxSize if not set
+	MaxAge      time.Duration                   // Time after which not committed transaction is evicted
+}
+
+// entry is pending transaction with attributes used for ordering and limits
+type entry struct {
+	tx        payload.Payload
+	hash      common.Uint256
+	srcHeight uint64 // Source block height, 0 for transactions not observed on source chain
+	size      int
+	seq       uint64 // Arrival order, keeps ordering stable for the same source height
+	added     time.Time
+}
+
+func (e *entry) hasRequest() bool {
+	return e.tx.RequestState() != payload.ReqStateUnknown
+}
+
+// TxPool collect payloads from watchers until they are included to block.
+// Changes of the same request are kept in arrival order and validated as a chain of state transitions
+// starting from the request state saved to ledger
+type TxPool struct {
+	ledger   Ledger
+	config   Config
+	txs      map[common.Uint256]*entry
+	requests map[[32]byte][]*entry // Pending changes of the request in arrival order
+	size     int
+	typeSize map[payload.TransactionType]int
+	seq      uint64
+	now      func() time.Time
+	lock     sync.RWMutex
+}
+
+func NewTxPool(ledger Ledger, config Config) *TxPool {
+	if config.MaxCount <= 0 {
+		config.MaxCount = DefaultMaxCount
+	}
+	if config.MaxSize <= 0 {
+		config.MaxSize = DefaultMaxSize
+	}
+	if config.MaxAge <= 0 {
+		config.MaxAge = DefaultMaxAge
+	}
+	return &TxPool{
+		ledger:   ledger,
+		config:   config,
+		txs:      make(map[common.Uint256]*entry),
+		requests: make(map[[32]byte][]*entry),
+		typeSize: make(map[payload.TransactionType]int),
+		now:      time.Now,
+	}
+}
+
+// Add validate the payload and put it to pool
+func (p *TxPool) Add(tx payload.Payload) error {
+	data := tx.RawData()
+	if data == nil {
+		return fmt.Errorf("transaction %s serialization error", tx.TxType())
+	}
+	transaction := types.ToTransaction(tx)
+	e := &entry{
+		tx:    tx,
+		hash:  transaction.Hash(),
+		size:  len(data),
+		added: p.now(),
+	}
+	if event, ok := tx.(payload.SourceEvent); ok {
+		e.srcHeight, _ = event.SrcBlock()
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if _, ok := p.txs[e.hash]; ok {
+		return ErrDuplicateTx
+	}
+	if saved, err := p.ledger.IsContainTransaction(e.hash); err != nil {
+		return fmt.Errorf("IsContainTransaction error %s", err)
+	} else if saved {
+		return ErrDuplicateTx
+	}
+	if e.hasRequest() {
+		if err := p.checkRequest(tx); err != nil {
+			return err
+		}
+	}
+	if err := p.checkLimits(e); err != nil {
+		return err
+	}
+	p.seq++
+	e.seq = p.seq
+	p.put(e)
+	return nil
+}
+
+// checkRequest validate request state change of the transaction follows the last pending or saved request state
+func (p *TxPool) checkRequest(tx payload.Payload) error {
+	reqId, next := tx.RequestId(), tx.RequestState()
+	var state payload.ReqState
+	if pending := p.requests[reqId]; len(pending) > 0 {
+		state = pending[len(pending)-1].tx.RequestState()
+	} else {
+		var err error
+		if state, err = p.getRequestState(reqId); err != nil {
+			return err
+		}
+	}
+	if state == next {
+		return ErrDuplicateRequest
+	}
+	if !state.CanTransitTo(next) {
+		return fmt.Errorf("request %x illegal state transition %s => %s", reqId, state, next)
+	}
+	return nil
+}
+
+func (p *TxPool) getRequestState(reqId [32]byte) (payload.ReqState, error) {
+	state, err := p.ledger.GetRequestState(reqId)
+	if err == scom.ErrNotFound {
+		return payload.ReqStateUnknown, nil
+	} else if err != nil {
+		return payload.ReqStateUnknown, fmt.Errorf("GetRequestState error %s", err)
+	}
+	return state, nil
+}
+
+func (p *TxPool) checkLimits(e *entry) error {
+	txType := e.tx.TxType()
+	if len(p.txs) >= p.config.MaxCount {
+		return ErrPoolFull
+	}
+	if p.size+e.size > p.config.MaxSize {
+		return fmt.Errorf("%w: size %d above limit %d", ErrPoolFull, p.size+e.size, p.config.MaxSize)
+	}
+	if limit, ok := p.config.MaxTypeSize[txType]; ok && p.typeSize[txType]+e.size > limit {
+		return fmt.Errorf("%w: %s size %d above limit %d", ErrPoolFull, txType, p.typeSize[txType]+e.size, limit)
+	}
+	return nil
+}
+
+func (p *TxPool) put(e *entry) {
+	p.txs[e.hash] = e
+	if e.hasRequest() {
+		reqId := e.tx.RequestId()
+		p.requests[reqId] = append(p.requests[reqId], e)
+	}
+	p.size += e.size
+	p.typeSize[e.tx.TxType()] += e.size
+}
+
+// remove delete the entry from pool, the following changes of its request are deleted as well if dropNext is set
+func (p *TxPool) remove(e *entry, dropNext bool) int {
+	if _, ok := p.txs[e.hash]; !ok {
+		return 0
+	}
+	delete(p.txs, e.hash)
+	p.size -= e.size
+	p.typeSize[e.tx.TxType()] -= e.size
+	if !e.hasRequest() {
+		return 1
+	}
+	removed := 1
+	reqId := e.tx.RequestId()
+	pending := p.requests[reqId]
+	for i, item := range pending {
+		if item != e {
+			continue
+		}
+		next := pending[i+1:]
+		if dropNext {
+			for _, item := range next {
+				delete(p.txs, item.hash)
+				p.size -= item.size
+				p.typeSize[item.tx.TxType()] -= item.size
+			}
+			removed += len(next)
+			next = nil
+		}
+		pending = append(pending[:i:i], next...)
+		break
+	}
+	if len(pending) == 0 {
+		delete(p.requests, reqId)
+	} else {
+		p.requests[reqId] = pending
+	}
+	return removed
+}
+
+// Len return count of pending transactions
+func (p *TxPool) Len() int {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	return len(p.txs)
+}
+
+// Size return total size of pending transactions in bytes
+func (p *TxPool) Size() int {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	return p.size
+}
+
+// Has report whether the transaction is pending
+func (p *TxPool) Has(txHash common.Uint256) bool {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	_, ok := p.txs[txHash]
+	return ok
+}
+
+// Batch return up to maxCount pending transactions for the next block ordered by source height.
+// Request change is included only after the previous pending change of the request, otherwise it waits for the next block.
+// Transactions stay in pool until RemoveBlock is called for the committed block
+func (p *TxPool) Batch(maxCount int) types.Transactions {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	entries := make([]*entry, 0, len(p.txs))
+	for _, e := range p.txs {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].srcHeight != entries[j].srcHeight {
+			return entries[i].srcHeight < entries[j].srcHeight
+		}
+		return entries[i].seq < entries[j].seq
+	})
+
+	txs := make(types.Transactions, 0, maxCount)
+	included := make(map[[32]byte]int) // Count of request changes included to batch
+	for _, e := range entries {
+		if len(txs) >= maxCount {
+			break
+		}
+		if e.hasRequest() {
+			reqId := e.tx.RequestId()
+			if p.requests[reqId][included[reqId]] != e {
+				continue
+			}
+			included[reqId]++
+		}
+		txs = append(txs, types.ToTransaction(e.tx))
+	}
+	return txs
+}
+
+// RemoveBlock delete transactions of the committed block from pool
+func (p *TxPool) RemoveBlock(block *types.Block) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for _, tx := range block.Transactions {
+		if e, ok := p.txs[tx.Hash()]; ok {
+			p.remove(e, false)
+		}
+	}
+}
+
+// Evict delete transactions pending longer than MaxAge and ones which can't be applied to ledger anymore
+// because the transaction or the request state was saved by other block. Return count of deleted transactions
+func (p *TxPool) Evict() (int, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	evicted := 0
+	deadline := p.now().Add(-p.config.MaxAge)
+	for _, e := range p.txs {
+		if e.added.Before(deadline) {
+			evicted += p.remove(e, true)
+			continue
+		}
+		saved, err := p.ledger.IsContainTransaction(e.hash)
+		if err != nil {
+			return evicted, fmt.Errorf("IsContainTransaction error %s", err)
+		}
+		if saved {
+			evicted += p.remove(e, false)
+		}
+	}
+	for reqId, pending := range p.requests {
+		state, err := p.getRequestState(reqId)
+		if err != nil {
+			return evicted, err
+		}
+		// state reached by other transaction makes the same pending change redundant
+		for len(pending) > 0 && pending[0].tx.RequestState() == state {
+			evicted += p.remove(pending[0], false)
+			pending = p.requests[reqId]
+		}
+		if len(pending) > 0 && !state.CanTransitTo(pending[0].tx.RequestState()) {
+			evicted += p.remove(pending[0], true)
+		}
+	}
+	return evicted, nil
+}
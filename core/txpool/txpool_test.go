@@ -0,0 +1,116 @@
+package txpool
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	ethCommon "github.com/ethereum/go-ethereum/common"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/eywa-protocol/bls-crypto/bls"
+	"github.com/eywa-protocol/wrappers"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eywa-protocol/chain/common"
+	"github.com/eywa-protocol/chain/core/genesis"
+	"github.com/eywa-protocol/chain/core/ledger"
+	"github.com/eywa-protocol/chain/core/payload"
+	"github.com/eywa-protocol/chain/core/types"
+)
+
+var _ Ledger = (*ledger.Ledger)(nil)
+
+func newTestRequest(reqId [32]byte, srcHeight uint64, txHash byte) *payload.BridgeEvent {
+	return &payload.BridgeEvent{
+		OriginData: wrappers.BridgeOracleRequest{
+			RequestType: "setRequest",
+			RequestId:   reqId,
+			ChainId:     big.NewInt(94),
+			Raw:         ethTypes.Log{BlockNumber: srcHeight, TxHash: ethCommon.Hash{reqId[0], txHash}},
+		}}
+}
+
+func saveTestBlock(t *testing.T, l *ledger.Ledger, sourceHeight uint64, txs types.Transactions) *types.Block {
+	block, err := l.CreateBlockFromEvents(txs, sourceHeight, common.UINT256_EMPTY)
+	require.NoError(t, err)
+	require.NoError(t, l.ExecAndSaveBlock(block))
+	return block
+}
+
+func txTypes(txs types.Transactions) []payload.TransactionType {
+	result := make([]payload.TransactionType, 0, len(txs))
+	for _, tx := range txs {
+		result = append(result, tx.TxType())
+	}
+	return result
+}
+
+func TestTxPool(t *testing.T) {
+	l, err := ledger.NewLedger(t.TempDir(), 0)
+	require.NoError(t, err)
+	defer l.Close()
+	genesisBlock, err := genesis.BuildGenesisBlock(0, 10)
+	require.NoError(t, err)
+	require.NoError(t, l.Init(genesisBlock))
+	reqA, reqB, reqC, reqD := [32]byte{1}, [32]byte{2}, [32]byte{3}, [32]byte{4}
+	saveTestBlock(t, l, 11, types.Transactions{types.ToTransaction(newTestRequest(reqA, 100, 1))})
+
+	pool := NewTxPool(l, Config{MaxTypeSize: map[payload.TransactionType]int{payload.EpochType: 1}})
+	now := time.Now()
+	pool.now = func() time.Time { return now }
+
+	// duplicates of saved and pending transactions and request states
+	require.Equal(t, ErrDuplicateTx, pool.Add(newTestRequest(reqA, 100, 1)))
+	require.Equal(t, ErrDuplicateRequest, pool.Add(newTestRequest(reqA, 100, 2)))
+	require.NoError(t, pool.Add(payload.NewRequestStateEvent(reqA, payload.ReqStateSigned, 94, nil, "", 0)))
+	require.Equal(t, ErrDuplicateTx, pool.Add(payload.NewRequestStateEvent(reqA, payload.ReqStateSigned, 94, nil, "", 0)))
+	require.Equal(t, ErrDuplicateRequest, pool.Add(payload.NewRequestStateEvent(reqA, payload.ReqStateSigned, 94, nil, "", 1)))
+	require.Error(t, pool.Add(payload.NewRequestStateEvent(reqA, payload.ReqStateConfirmed, 94, nil, "", 0)))
+	require.Error(t, pool.Add(payload.NewRequestStateEvent(reqB, payload.ReqStateSigned, 94, nil, "", 0)))
+	require.NoError(t, pool.Add(payload.NewRequestStateEvent(reqA, payload.ReqStateSubmitted, 94, nil, "", 0)))
+	require.NoError(t, pool.Add(newTestRequest(reqB, 105, 1)))
+	require.NoError(t, pool.Add(payload.NewRequestStateEvent(reqB, payload.ReqStateSigned, 94, nil, "", 0)))
+	require.NoError(t, pool.Add(newTestRequest(reqC, 103, 1)))
+	require.Equal(t, 5, pool.Len())
+
+	// type size limit
+	_, pubKey := bls.GenerateRandomKey()
+	epoch := payload.NewEpochEvent(1, common.UINT256_EMPTY, []bls.PublicKey{pubKey}, []string{"one"})
+	require.True(t, errors.Is(pool.Add(epoch), ErrPoolFull))
+
+	// ordered by source height, signing of request B waits for the request to be received
+	batch := pool.Batch(10)
+	require.Equal(t, []payload.TransactionType{
+		payload.RequestStateEventType, payload.RequestStateEventType, payload.BridgeEventType, payload.BridgeEventType,
+	}, txTypes(batch))
+	require.Equal(t, reqC, batch[2].Payload.RequestId())
+	require.Equal(t, reqB, batch[3].Payload.RequestId())
+	require.Len(t, pool.Batch(2), 2)
+
+	block := saveTestBlock(t, l, 12, batch)
+	pool.RemoveBlock(block)
+	require.Equal(t, 1, pool.Len())
+	batch = pool.Batch(10)
+	require.Len(t, batch, 1)
+	require.Equal(t, payload.ReqStateSigned, batch[0].Payload.RequestState())
+
+	// request state saved by other transaction and expired entries are evicted
+	require.NoError(t, pool.Add(newTestRequest(reqD, 110, 1)))
+	require.NoError(t, pool.Add(payload.NewRequestStateEvent(reqD, payload.ReqStateSigned, 94, nil, "", 0)))
+	saveTestBlock(t, l, 13, types.Transactions{types.ToTransaction(payload.NewRequestStateEvent(reqB, payload.ReqStateSigned, 94, nil, "", 1))})
+	evicted, err := pool.Evict()
+	require.NoError(t, err)
+	require.Equal(t, 1, evicted)
+	require.Equal(t, 2, pool.Len())
+	now = now.Add(DefaultMaxAge + time.Second)
+	evicted, err = pool.Evict()
+	require.NoError(t, err)
+	require.Equal(t, 2, evicted)
+	require.Equal(t, 0, pool.Len())
+	require.Equal(t, 0, pool.Size())
+
+	pool = NewTxPool(l, Config{MaxCount: 1})
+	require.NoError(t, pool.Add(newTestRequest(reqD, 110, 1)))
+	require.Equal(t, ErrPoolFull, pool.Add(newTestRequest([32]byte{5}, 110, 1)))
+}
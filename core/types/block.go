@@ -6,6 +6,7 @@ import (
 
 	"github.com/eywa-protocol/bls-crypto/bls"
 	"github.com/eywa-protocol/chain/common"
+	"github.com/eywa-protocol/chain/core/payload"
 	"github.com/eywa-protocol/chain/merkle"
 )
 
@@ -95,7 +96,20 @@ func (b *Block) VerifyIntegrity() error {
 }
 
 func (b *Block) MerkleProve(i int) ([]byte, error) {
-	return b.merkleTree.MerkleInclusionLeafPath(b.Transactions[i].Payload.RawData(), uint64(i), uint64(len(b.Transactions)))
+	return b.merkleTree.MerkleInclusionLeafPath(b.Transactions[i].Payload.RawData(), uint64(i), b.merkleTree.TreeSize())
+}
+
+// EpochProve return inclusion path of typed epoch leaf of the block, verified by merkle.MerkleProveTypedLeaf
+func (b *Block) EpochProve() ([]byte, error) {
+	if b.merkleTree == nil || b.merkleTree.TreeSize() == uint64(len(b.Transactions)) {
+		return nil, errors.New("block has no epoch leaf")
+	}
+	for _, tx := range b.Transactions {
+		if tx.Payload.TxType() == payload.EpochType {
+			return b.merkleTree.MerkleInclusionLeafPath(epochLeaf(tx), uint64(len(b.Transactions)), b.merkleTree.TreeSize())
+		}
+	}
+	return nil, errors.New("block has no epoch event")
 }
 
 func (b *Block) ToArray() ([]byte, error) {
@@ -117,16 +131,43 @@ func (b *Block) HashString() string {
 	return hash.ToHexString()
 }
 
+// rebuildMerkleRoot set transactions root to the tree of transaction leaves followed by typed leaf of epoch event.
+// Merkle leaves of transactions carry no type, so only the typed leaf proves epoch to light clients.
+// Blocks saved before typed epoch leaf keep root of transaction leaves, their epoch can't be proved
 func (b *Block) rebuildMerkleRoot() {
 	txs := b.Transactions
 	if len(txs) == 0 {
 		b.Header.TransactionsRoot = common.Uint256{}
 		return
 	}
+	tree := transactionsTree(txs, true)
+	if tree.Root() != b.Header.TransactionsRoot && tree.TreeSize() != uint64(len(txs)) {
+		if legacy := transactionsTree(txs, false); legacy.Root() == b.Header.TransactionsRoot {
+			tree = legacy
+		}
+	}
+	b.merkleTree = tree
+	b.Header.TransactionsRoot = tree.Root()
+}
+
+func transactionsTree(txs Transactions, epochLeaves bool) *merkle.CompactMerkleTree {
 	tree := merkle.NewTree(0, nil, merkle.NewMemHashStore())
 	for _, tx := range txs {
 		tree.Append(tx.Payload.RawData())
 	}
-	b.merkleTree = tree
-	b.Header.TransactionsRoot = tree.Root()
+	if !epochLeaves {
+		return tree
+	}
+	for _, tx := range txs {
+		if tx.Payload.TxType() == payload.EpochType {
+			tree.AppendHash(merkle.HashTypedLeaf(epochLeaf(tx)))
+			break
+		}
+	}
+	return tree
+}
+
+// epochLeaf return typed leaf data of epoch event transaction: transaction type followed by its raw data
+func epochLeaf(tx transaction) []byte {
+	return append([]byte{byte(payload.EpochType)}, tx.Payload.RawData()...)
 }
@@ -8,6 +8,7 @@ import (
 	"github.com/eywa-protocol/bls-crypto/bls"
 	"github.com/eywa-protocol/chain/common"
 	"github.com/eywa-protocol/chain/core/payload"
+	"github.com/eywa-protocol/chain/merkle"
 	"github.com/eywa-protocol/wrappers"
 	"github.com/gagliardetto/solana-go"
 	"github.com/stretchr/testify/assert"
@@ -127,3 +128,52 @@ func Test_BlockMarshal(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, *block, received)
 }
+
+func Test_BlockEpochLeaf(t *testing.T) {
+	hash := common.Uint256{0xCA, 0xFE, 0xBA, 0xBE}
+	_, pubKey := bls.GenerateRandomKey()
+	epoch := payload.NewEpochEvent(2, common.UINT256_EMPTY, []bls.PublicKey{pubKey}, []string{"one"})
+	invoke := &payload.InvokeCode{Code: epoch.RawData()}
+	block := NewBlock(1111, hash, hash, 100, 10, Transactions{ToTransaction(invoke), ToTransaction(epoch)})
+
+	// epoch is proved by typed leaf only
+	path, err := block.EpochProve()
+	assert.NoError(t, err)
+	data, err := merkle.MerkleProveTypedLeaf(path, block.Header.TransactionsRoot[:])
+	assert.NoError(t, err)
+	assert.Equal(t, append([]byte{byte(payload.EpochType)}, epoch.RawData()...), data)
+	for i := range block.Transactions {
+		path, err = block.MerkleProve(i)
+		assert.NoError(t, err)
+		data, err = merkle.MerkleProve(path, block.Header.TransactionsRoot[:])
+		assert.NoError(t, err)
+		assert.Equal(t, epoch.RawData(), data)
+		_, err = merkle.MerkleProveTypedLeaf(path, block.Header.TransactionsRoot[:])
+		assert.Error(t, err)
+	}
+	_, err = NewBlock(1111, hash, hash, 100, 10, Transactions{ToTransaction(invoke)}).EpochProve()
+	assert.Error(t, err)
+
+	// block saved before typed epoch leaf keeps its root and hash
+	tree := merkle.NewTree(0, nil, merkle.NewMemHashStore())
+	tree.Append(epoch.RawData())
+	header := &Header{
+		ChainID:          1111,
+		PrevBlockHash:    hash,
+		EpochBlockHash:   hash,
+		TransactionsRoot: tree.Root(),
+		SourceHeight:     100,
+		Height:           10,
+		Signature:        bls.NewZeroMultisig(),
+	}
+	legacy := NewBlockFromComponents(header, Transactions{ToTransaction(epoch)})
+	assert.Equal(t, tree.Root(), legacy.Header.TransactionsRoot)
+	raw, err := legacy.ToArray()
+	assert.NoError(t, err)
+	received, err := BlockFromRawBytes(raw)
+	assert.NoError(t, err)
+	assert.NoError(t, received.VerifyIntegrity())
+	assert.Equal(t, legacy.Hash(), received.Hash())
+	_, err = received.EpochProve()
+	assert.Error(t, err)
+}
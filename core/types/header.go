@@ -4,6 +4,7 @@ import (
 	"crypto/sha256"
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"io"
 
 	"github.com/eywa-protocol/bls-crypto/bls"
@@ -139,9 +140,44 @@ func (bd *Header) RawData() []byte {
 }
 
 func (bd *Header) Hash() *common.Uint256 {
+	if bd.hash == nil {
+		bd.сalculateHash()
+	}
 	return bd.hash
 }
 
+// VerifySignature checks the header multisig against the aggregated public key of the epoch.
+// Signature mask must reference only epoch participants and contain at least quorum signers.
+func (bd *Header) VerifySignature(epochPublicKey bls.PublicKey, epochSize int, quorum int) error {
+	mask := &bd.Signature.PartMask
+	if mask.BitLen() > epochSize {
+		return fmt.Errorf("[Header] signature mask references %d participants, epoch size %d", mask.BitLen(), epochSize)
+	}
+	signers := 0
+	for i := 0; i < epochSize; i++ {
+		if mask.Bit(i) == 1 {
+			signers++
+		}
+	}
+	if signers < quorum {
+		return fmt.Errorf("[Header] not enough signers %d, quorum %d", signers, quorum)
+	}
+	hash := bd.Hash()
+	if !bd.Signature.PartSignature.VerifyMultisig(epochPublicKey, bd.Signature.PartPublicKey, hash.ToArray(), mask) {
+		return errors.New("[Header] multisig verification failed")
+	}
+	return nil
+}
+
+// QuorumSize return count of signers required from epoch of epochSize participants for threshold percent
+func QuorumSize(epochSize int, threshold uint64) int {
+	quorum := (uint64(epochSize)*threshold + 99) / 100
+	if quorum > uint64(epochSize) {
+		return epochSize
+	}
+	return int(quorum)
+}
+
 func (bd *Header) сalculateHash() {
 	hash := common.Uint256(sha256.Sum256(bd.RawData()))
 	bd.hash = &hash
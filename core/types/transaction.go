@@ -96,6 +96,14 @@ func (tx *transaction) Deserialization(source *common.ZeroCopySource) error {
 		}
 		tx.Payload = &parsed
 
+	case payload.RequestStateEventType:
+		var parsed payload.RequestStateEvent
+		err := parsed.Deserialization(source)
+		if err != nil {
+			return err
+		}
+		tx.Payload = &parsed
+
 	default:
 		return fmt.Errorf("failed to unmarshal unknown tx type %d", txType)
 	}
@@ -35,6 +35,22 @@ const (
 	ErrGasPrice             ErrCode = 45020
 	ErrVerifySignature      ErrCode = 45021
 	ErrInValidShard         ErrCode = 45022
+
+	ErrParseRequest   ErrCode = -32700
+	ErrInvalidRequest ErrCode = -32600
+	ErrMethodNotFound ErrCode = -32601
+	ErrInvalidParams  ErrCode = -32602
+	ErrInternal       ErrCode = -32603
+
+	ErrUnknownBlock       ErrCode = 44001
+	ErrUnknownTransaction ErrCode = 44002
+	ErrUnknownRequest     ErrCode = 44003
+	ErrUnknownEpoch       ErrCode = 44004
+	ErrPrunedData         ErrCode = 44005
+
+	ErrUnknownSubscription ErrCode = 44101
+	ErrSubscriptionClosed  ErrCode = 44102
+	ErrSubscriptionLimit   ErrCode = 44103
 )
 
 func (err ErrCode) Error() string {
@@ -85,6 +101,32 @@ func (err ErrCode) Error() string {
 		return "transaction verify signature fail"
 	case ErrInValidShard:
 		return "transaction shardId unmatch"
+	case ErrParseRequest:
+		return "parse error"
+	case ErrInvalidRequest:
+		return "invalid request"
+	case ErrMethodNotFound:
+		return "method not found"
+	case ErrInvalidParams:
+		return "invalid params"
+	case ErrInternal:
+		return "internal error"
+	case ErrUnknownBlock:
+		return "unknown block"
+	case ErrUnknownTransaction:
+		return "unknown transaction"
+	case ErrUnknownRequest:
+		return "unknown request"
+	case ErrUnknownEpoch:
+		return "unknown epoch"
+	case ErrPrunedData:
+		return "data pruned"
+	case ErrUnknownSubscription:
+		return "unknown subscription"
+	case ErrSubscriptionClosed:
+		return "subscription closed"
+	case ErrSubscriptionLimit:
+		return "subscription limit reached"
 
 	}
 
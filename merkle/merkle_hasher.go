@@ -114,6 +114,13 @@ func HashLeaf(data []byte) common.Uint256 {
 	return sha256.Sum256(tmp)
 }
 
+// HashTypedLeaf hash leaf data starting with its type. The prefix differs from the one of HashLeaf and HashChildren,
+// so typed leaf can't be proved by a path of untyped data with the same bytes
+func HashTypedLeaf(data []byte) common.Uint256 {
+	tmp := append([]byte{2}, data...)
+	return sha256.Sum256(tmp)
+}
+
 func HashChildren(left, right common.Uint256) common.Uint256 {
 	data := append([]byte{1}, left[:]...)
 	data = append(data, right[:]...)
@@ -178,12 +185,21 @@ func MerkleHashes(preLeaves []common.Uint256, depth int) [][]common.Uint256 {
 }
 
 func MerkleProve(path []byte, root []byte) ([]byte, error) {
+	return merkleProve(path, root, HashLeaf)
+}
+
+// MerkleProveTypedLeaf verify path of the leaf hashed by HashTypedLeaf and return the leaf data
+func MerkleProveTypedLeaf(path []byte, root []byte) ([]byte, error) {
+	return merkleProve(path, root, HashTypedLeaf)
+}
+
+func merkleProve(path []byte, root []byte, hashLeaf func([]byte) common.Uint256) ([]byte, error) {
 	source := common.NewZeroCopySource(path)
 	value, eof := source.NextVarBytes()
 	if eof {
 		return nil, errors.New("read bytes error")
 	}
-	hash := HashLeaf(value)
+	hash := hashLeaf(value)
 	size := int((source.Size() - source.Pos()) / (common.UINT256_SIZE + 1))
 	for i := 0; i < size; i++ {
 		f, eof := source.NextByte()
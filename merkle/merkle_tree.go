@@ -136,11 +136,11 @@ func (t *CompactMerkleTree) GetRootWithNewLeaves(newLeaf []common.Uint256) commo
 func (t *CompactMerkleTree) Append(leafv []byte) []common.Uint256 {
 	leaf := t.hasher.hash_leaf(leafv)
 
-	return t.appendHash(leaf)
+	return t.AppendHash(leaf)
 }
 
 // AppendHash appends a leaf hash to the merkle tree and returns the audit path
-func (t *CompactMerkleTree) appendHash(leaf common.Uint256) []common.Uint256 {
+func (t *CompactMerkleTree) AppendHash(leaf common.Uint256) []common.Uint256 {
 	size := len(t.hashes)
 	auditPath := make([]common.Uint256, size, size)
 	storehashes := make([]common.Uint256, 0)
@@ -169,6 +169,41 @@ func (t *CompactMerkleTree) appendHash(leaf common.Uint256) []common.Uint256 {
 	return auditPath
 }
 
+// CompactHashes returns compact hashes of the merkle tree of tree_size read from HashStore
+func (t *CompactMerkleTree) CompactHashes(tree_size uint64) ([]common.Uint256, error) {
+	if tree_size > t.treeSize {
+		return nil, fmt.Errorf("tree size %d larger than current %d", tree_size, t.treeSize)
+	} else if t.hashStore == nil {
+		return nil, errors.New("hash store not available")
+	}
+	hashespos := getSubTreePos(tree_size)
+	hashes := make([]common.Uint256, len(hashespos), len(hashespos))
+	for i, pos := range hashespos {
+		hash, err := t.hashStore.GetHash(pos - 1)
+		if err != nil {
+			return nil, err
+		}
+		hashes[i] = hash
+	}
+
+	return hashes, nil
+}
+
+// Truncate shrinks the merkle tree to tree_size leaves and drops truncated hashes from HashStore
+func (t *CompactMerkleTree) Truncate(tree_size uint64) error {
+	hashes, err := t.CompactHashes(tree_size)
+	if err != nil {
+		return err
+	}
+	err = t.hashStore.Truncate(tree_size)
+	if err != nil {
+		return err
+	}
+	t._update(tree_size, hashes)
+
+	return nil
+}
+
 func (t *CompactMerkleTree) DumpStatus() {
 	logrus.Errorf("tree root: %x \n", t.rootHash)
 	logrus.Errorf("tree size: %d \n", t.treeSize)
@@ -182,6 +182,30 @@ func TestMerkleInclusionLeafPath(t *testing.T) {
 	}
 }
 
+func TestMerkleProveTypedLeaf(t *testing.T) {
+	tree := NewTree(0, nil, NewMemHashStore())
+	data := []byte{1, 2, 3}
+	tree.Append(data)
+	tree.AppendHash(HashTypedLeaf(data))
+	root := tree.Root()
+
+	path, err := tree.MerkleInclusionLeafPath(data, 1, 2)
+	assert.Nil(t, err)
+	val, err := MerkleProveTypedLeaf(path, root.ToArray())
+	assert.Nil(t, err)
+	assert.Equal(t, data, val)
+	_, err = MerkleProve(path, root.ToArray())
+	assert.NotNil(t, err)
+
+	// untyped leaf of the same data is not a typed one
+	path, err = tree.MerkleInclusionLeafPath(data, 0, 2)
+	assert.Nil(t, err)
+	_, err = MerkleProve(path, root.ToArray())
+	assert.Nil(t, err)
+	_, err = MerkleProveTypedLeaf(path, root.ToArray())
+	assert.NotNil(t, err)
+}
+
 func TestMerkleConsistencyProofLen(t *testing.T) {
 	n := uint64(7)
 	store, _ := NewFileHashStore("merkletree.db", 0)
@@ -306,3 +330,38 @@ func TestAudit(t *testing.T) {
 		assert.Equal(t, []byte(fmt.Sprintf("%d", i)), value)
 	}
 }
+
+func TestMerkleTruncate(t *testing.T) {
+	N := uint64(100)
+	store, _ := NewFileHashStore("merkletree_truncate.db", 0)
+	defer os.Remove("merkletree_truncate.db")
+	defer store.Close()
+	tree := NewTree(0, nil, store)
+	memTree := NewTree(0, nil, NewMemHashStore())
+	roots := make([]common.Uint256, 0, N)
+	for i := uint64(0); i < N; i++ {
+		tree.Append([]byte{byte(i + 1)})
+		memTree.Append([]byte{byte(i + 1)})
+		roots = append(roots, tree.Root())
+	}
+
+	for _, size := range []uint64{N, 77, 64, 33, 1} {
+		err := tree.Truncate(size)
+		assert.NoError(t, err)
+		assert.Equal(t, size, tree.TreeSize())
+		assert.Equal(t, roots[size-1], tree.Root())
+	}
+	assert.Error(t, tree.Truncate(2))
+
+	for i := uint64(1); i < N; i++ {
+		tree.Append([]byte{byte(i + 1)})
+	}
+	assert.Equal(t, roots[N-1], tree.Root())
+
+	assert.NoError(t, memTree.Truncate(50))
+	assert.Equal(t, roots[49], memTree.Root())
+	for i := uint64(50); i < N; i++ {
+		memTree.Append([]byte{byte(i + 1)})
+	}
+	assert.Equal(t, roots[N-1], memTree.Root())
+}
@@ -92,9 +92,7 @@ func (self *CacheDB) delete(prefix common.DataEntryPrefix, key []byte) {
 }
 
 func (self *CacheDB) NewIterator(key []byte) common.StoreIterator {
-	pkey := make([]byte, 1+len(key))
-	pkey[0] = byte(common.ST_STORAGE)
-	copy(pkey[1:], key)
+	pkey := storageKey(key)
 	prefixRange := util.BytesPrefix(pkey)
 	backIter := self.backend.NewIterator(pkey)
 	memIter := self.memdb.NewIterator(prefixRange)
@@ -102,6 +100,28 @@ func (self *CacheDB) NewIterator(key []byte) common.StoreIterator {
 	return &Iter{overlaydb.NewJoinIter(memIter, backIter)}
 }
 
+// NewRangeIterator return iterator of the storage keys in range [start, end), nil end means all keys from start
+func (self *CacheDB) NewRangeIterator(start, end []byte) common.StoreIterator {
+	pstart := storageKey(start)
+	var pend []byte
+	if end != nil {
+		pend = storageKey(end)
+	} else {
+		pend = util.BytesPrefix([]byte{byte(common.ST_STORAGE)}).Limit
+	}
+	backIter := self.backend.NewRangeIterator(pstart, pend)
+	memIter := self.memdb.NewIterator(&util.Range{Start: pstart, Limit: pend})
+
+	return &Iter{overlaydb.NewJoinIter(memIter, backIter)}
+}
+
+func storageKey(key []byte) []byte {
+	pkey := make([]byte, 1+len(key))
+	pkey[0] = byte(common.ST_STORAGE)
+	copy(pkey[1:], key)
+	return pkey
+}
+
 type Iter struct {
 	*overlaydb.JoinIter
 }
@@ -113,3 +133,7 @@ func (self *Iter) Key() []byte {
 	}
 	return key
 }
+
+func (self *Iter) Seek(key []byte) bool {
+	return self.JoinIter.Seek(storageKey(key))
+}
@@ -0,0 +1,331 @@
+package rpc
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"strings"
+
+	"github.com/eywa-protocol/chain/common"
+	scom "github.com/eywa-protocol/chain/core/store/common"
+	"github.com/eywa-protocol/chain/core/types"
+	"github.com/eywa-protocol/chain/errors"
+)
+
+func (s *Server) registerLedgerHandlers() {
+	s.Register("getblockcount", s.getBlockCount)
+	s.Register("getheights", s.getHeights)
+	s.Register("getcurrentblockhash", s.getCurrentBlockHash)
+	s.Register("getblockhash", s.getBlockHash)
+	s.Register("getblock", s.getBlock)
+	s.Register("getheader", s.getHeader)
+	s.Register("getrawtransaction", s.getRawTransaction)
+	s.Register("getblockheightbytxhash", s.getBlockHeightByTxHash)
+	s.Register("getrequeststate", s.getRequestState)
+	s.Register("getmerkleproof", s.getMerkleProof)
+	s.Register("getcrossstatesproof", s.getCrossStatesProof)
+	s.Register("getepochstate", s.getEpochState)
+}
+
+// getBlockCount return count of blocks in ledger
+func (s *Server) getBlockCount(params []json.RawMessage) (interface{}, *Error) {
+	return s.ledger.GetCurrentBlockHeight() + 1, nil
+}
+
+// getHeights return current block, header and processed source heights
+func (s *Server) getHeights(params []json.RawMessage) (interface{}, *Error) {
+	blockHash := s.ledger.GetCurrentBlockHash()
+	return &HeightsInfo{
+		BlockHeight:     s.ledger.GetCurrentBlockHeight(),
+		BlockHash:       blockHash.ToHexString(),
+		HeaderHeight:    s.ledger.GetCurrentHeaderHeight(),
+		ProcessedHeight: s.ledger.GetProcessedHeight(),
+	}, nil
+}
+
+func (s *Server) getCurrentBlockHash(params []json.RawMessage) (interface{}, *Error) {
+	blockHash := s.ledger.GetCurrentBlockHash()
+	return blockHash.ToHexString(), nil
+}
+
+// getBlockHash params: height
+func (s *Server) getBlockHash(params []json.RawMessage) (interface{}, *Error) {
+	height, rpcErr := uint64Param(params, 0)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	if height > s.ledger.GetCurrentBlockHeight() {
+		return nil, NewError(errors.ErrUnknownBlock, "")
+	}
+	blockHash := s.ledger.GetBlockHash(height)
+	return blockHash.ToHexString(), nil
+}
+
+// getBlock params: block hash or height, verbose. Not verbose block is returned as serialized hex
+func (s *Server) getBlock(params []json.RawMessage) (interface{}, *Error) {
+	blockHash, rpcErr := s.blockHashParam(params, 0)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	verbose, rpcErr := boolParam(params, 1)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	block, err := s.ledger.GetBlockByHash(blockHash)
+	if err != nil {
+		return nil, lookupError(err, errors.ErrUnknownBlock)
+	}
+	if !verbose {
+		sink := common.NewZeroCopySink(nil)
+		if err := block.Serialization(sink); err != nil {
+			return nil, NewError(errors.ErrInternal, err.Error())
+		}
+		return hex.EncodeToString(sink.Bytes()), nil
+	}
+	info, err := NewBlockInfo(block)
+	if err != nil {
+		return nil, NewError(errors.ErrInternal, err.Error())
+	}
+	return info, nil
+}
+
+// getHeader params: block hash or height, verbose. Not verbose header is returned as serialized hex
+func (s *Server) getHeader(params []json.RawMessage) (interface{}, *Error) {
+	blockHash, rpcErr := s.blockHashParam(params, 0)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	verbose, rpcErr := boolParam(params, 1)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	header, err := s.ledger.GetHeaderByHash(blockHash)
+	if err != nil {
+		return nil, lookupError(err, errors.ErrUnknownBlock)
+	}
+	if !verbose {
+		sink := common.NewZeroCopySink(nil)
+		if err := header.Serialization(sink); err != nil {
+			return nil, NewError(errors.ErrInternal, err.Error())
+		}
+		return hex.EncodeToString(sink.Bytes()), nil
+	}
+	return NewHeaderInfo(header), nil
+}
+
+// getRawTransaction params: transaction hash, verbose. Not verbose transaction is returned as serialized hex
+func (s *Server) getRawTransaction(params []json.RawMessage) (interface{}, *Error) {
+	txHash, rpcErr := hashParam(params, 0)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	verbose, rpcErr := boolParam(params, 1)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	tx, height, err := s.ledger.GetTransactionWithHeight(txHash)
+	if err != nil {
+		return nil, lookupError(err, errors.ErrUnknownTransaction)
+	}
+	if !verbose {
+		return hex.EncodeToString(types.ToTransaction(tx).ToArray()), nil
+	}
+	info, err := NewTransactionInfo(tx, height)
+	if err != nil {
+		return nil, NewError(errors.ErrInternal, err.Error())
+	}
+	return info, nil
+}
+
+// getBlockHeightByTxHash params: transaction hash
+func (s *Server) getBlockHeightByTxHash(params []json.RawMessage) (interface{}, *Error) {
+	txHash, rpcErr := hashParam(params, 0)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	_, height, err := s.ledger.GetTransactionWithHeight(txHash)
+	if err != nil {
+		return nil, lookupError(err, errors.ErrUnknownTransaction)
+	}
+	return height, nil
+}
+
+// getRequestState params: request id hex. Return request state, last transaction and state history
+func (s *Server) getRequestState(params []json.RawMessage) (interface{}, *Error) {
+	reqId, rpcErr := requestIdParam(params, 0)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	state, err := s.ledger.GetRequestState(reqId)
+	if err != nil {
+		return nil, lookupError(err, errors.ErrUnknownRequest)
+	}
+	// transaction hash is kept in request record when the transaction is pruned
+	txHash, err := s.ledger.GetRequestTxHash(reqId)
+	if err != nil {
+		return nil, lookupError(err, errors.ErrUnknownRequest)
+	}
+	history, err := s.ledger.GetRequestHistory(reqId)
+	if err != nil {
+		return nil, NewError(errors.ErrInternal, err.Error())
+	}
+	info := &RequestInfo{
+		RequestId: hex.EncodeToString(reqId[:]),
+		State:     state.String(),
+		TxHash:    txHash.ToHexString(),
+		History:   make([]*RequestStateInfo, 0, len(history)),
+	}
+	for _, entry := range history {
+		info.History = append(info.History, NewRequestStateInfo(entry))
+	}
+	return info, nil
+}
+
+// getMerkleProof params: proof height, root height. Return hex encoded proof of the block hash at proof height
+func (s *Server) getMerkleProof(params []json.RawMessage) (interface{}, *Error) {
+	proofHeight, rpcErr := uint64Param(params, 0)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	rootHeight, rpcErr := uint64Param(params, 1)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	if proofHeight > rootHeight || rootHeight > s.ledger.GetCurrentBlockHeight() {
+		return nil, NewError(errors.ErrInvalidParams, fmt.Sprintf("invalid proof height %d and root height %d", proofHeight, rootHeight))
+	}
+	proof, err := s.ledger.GetMerkleProof(proofHeight, rootHeight)
+	if err != nil {
+		return nil, NewError(errors.ErrInternal, err.Error())
+	}
+	return hex.EncodeToString(proof), nil
+}
+
+// getCrossStatesProof params: height, key hex. Return hex encoded proof
+func (s *Server) getCrossStatesProof(params []json.RawMessage) (interface{}, *Error) {
+	height, rpcErr := uint64Param(params, 0)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	key, rpcErr := bytesParam(params, 1)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	proof, err := s.ledger.GetCrossStatesProof(height, key)
+	if err != nil {
+		return nil, lookupError(err, errors.ErrUnknownBlock)
+	}
+	return hex.EncodeToString(proof), nil
+}
+
+// getEpochState return public keys of current epoch participants
+func (s *Server) getEpochState(params []json.RawMessage) (interface{}, *Error) {
+	state, err := s.ledger.GetEpochState()
+	if err != nil {
+		return nil, lookupError(err, errors.ErrUnknownEpoch)
+	}
+	return NewEpochStateInfo(state), nil
+}
+
+// blockHashParam parse block height or hash param at index
+func (s *Server) blockHashParam(params []json.RawMessage, index int) (common.Uint256, *Error) {
+	if index >= len(params) {
+		return common.UINT256_EMPTY, NewError(errors.ErrInvalidParams, fmt.Sprintf("missing param %d", index))
+	}
+	var height uint64
+	if err := json.Unmarshal(params[index], &height); err == nil {
+		if height > s.ledger.GetCurrentBlockHeight() {
+			return common.UINT256_EMPTY, NewError(errors.ErrUnknownBlock, "")
+		}
+		return s.ledger.GetBlockHash(height), nil
+	}
+	return hashParam(params, index)
+}
+
+func hashParam(params []json.RawMessage, index int) (common.Uint256, *Error) {
+	str, rpcErr := stringParam(params, index)
+	if rpcErr != nil {
+		return common.UINT256_EMPTY, rpcErr
+	}
+	hash, err := common.Uint256FromHexString(strings.TrimPrefix(str, "0x"))
+	if err != nil {
+		return common.UINT256_EMPTY, NewError(errors.ErrInvalidParams, fmt.Sprintf("param %d: %s", index, err))
+	}
+	return hash, nil
+}
+
+func requestIdParam(params []json.RawMessage, index int) ([32]byte, *Error) {
+	var reqId [32]byte
+	data, rpcErr := bytesParam(params, index)
+	if rpcErr != nil {
+		return reqId, rpcErr
+	}
+	if len(data) != len(reqId) {
+		return reqId, NewError(errors.ErrInvalidParams, fmt.Sprintf("param %d: request id length %d", index, len(data)))
+	}
+	copy(reqId[:], data)
+	return reqId, nil
+}
+
+func bytesParam(params []json.RawMessage, index int) ([]byte, *Error) {
+	str, rpcErr := stringParam(params, index)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	data, err := hex.DecodeString(strings.TrimPrefix(str, "0x"))
+	if err != nil {
+		return nil, NewError(errors.ErrInvalidParams, fmt.Sprintf("param %d: %s", index, err))
+	}
+	return data, nil
+}
+
+func stringParam(params []json.RawMessage, index int) (string, *Error) {
+	if index >= len(params) {
+		return "", NewError(errors.ErrInvalidParams, fmt.Sprintf("missing param %d", index))
+	}
+	var str string
+	if err := json.Unmarshal(params[index], &str); err != nil {
+		return "", NewError(errors.ErrInvalidParams, fmt.Sprintf("param %d: %s", index, err))
+	}
+	return str, nil
+}
+
+func uint64Param(params []json.RawMessage, index int) (uint64, *Error) {
+	if index >= len(params) {
+		return 0, NewError(errors.ErrInvalidParams, fmt.Sprintf("missing param %d", index))
+	}
+	var value uint64
+	if err := json.Unmarshal(params[index], &value); err != nil {
+		return 0, NewError(errors.ErrInvalidParams, fmt.Sprintf("param %d: %s", index, err))
+	}
+	return value, nil
+}
+
+// boolParam parse optional bool param, 0 and 1 are accepted as well
+func boolParam(params []json.RawMessage, index int) (bool, *Error) {
+	if index >= len(params) {
+		return false, nil
+	}
+	var value bool
+	if err := json.Unmarshal(params[index], &value); err == nil {
+		return value, nil
+	}
+	var number uint64
+	if err := json.Unmarshal(params[index], &number); err != nil || number > 1 {
+		return false, NewError(errors.ErrInvalidParams, fmt.Sprintf("param %d: bool expected", index))
+	}
+	return number == 1, nil
+}
+
+// lookupError map not found store error to the code and pruned store error to ErrPrunedData, other errors are internal.
+// Store errors are matched through wrapping
+func lookupError(err error, notFound errors.ErrCode) *Error {
+	if stderrors.Is(err, scom.ErrNotFound) {
+		return NewError(notFound, "")
+	}
+	if stderrors.Is(err, scom.ErrPruned) {
+		return NewError(errors.ErrPrunedData, "")
+	}
+	return NewError(errors.ErrInternal, err.Error())
+}
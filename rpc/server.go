@@ -0,0 +1,256 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/eywa-protocol/chain/core/ledger"
+	"github.com/eywa-protocol/chain/errors"
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	JsonRpcVersion = "2.0"
+
+	MaxRequestSize  = 1 << 20 // Max size of http request body
+	shutdownTimeout = 5 * time.Second
+
+	DefaultMaxSubscriptions = 32 // Default count of subscriptions of one websocket connection
+)
+
+// Config of rpc server, zero values are replaced by defaults
+type Config struct {
+	AllowedOrigins   []string // Origins of websocket clients allowed besides the same origin, "*" allows any origin
+	MaxSubscriptions int      // Count of subscriptions of one websocket connection
+}
+
+// Request is JSON-RPC 2.0 request
+type Request struct {
+	JsonRpc string            `json:"jsonrpc"`
+	Method  string            `json:"method"`
+	Params  []json.RawMessage `json:"params,omitempty"`
+	Id      json.RawMessage   `json:"id,omitempty"`
+}
+
+// Response is JSON-RPC 2.0 response
+type Response struct {
+	JsonRpc string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+	Id      json.RawMessage `json:"id"`
+}
+
+// Error is JSON-RPC 2.0 error object
+type Error struct {
+	Code    errors.ErrCode `json:"code"`
+	Message string         `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// NewError return error with code and message, code description is used if message is empty
+func NewError(code errors.ErrCode, message string) *Error {
+	if message == "" {
+		message = code.Error()
+	}
+	return &Error{Code: code, Message: message}
+}
+
+// Handler serve method call with positional params
+type Handler func(params []json.RawMessage) (interface{}, *Error)
+
+// Server is JSON-RPC 2.0 over http server of ledger read api
+type Server struct {
+	ledger     *ledger.Ledger
+	config     Config
+	upgrader   websocket.Upgrader
+	handlers   map[string]Handler
+	httpServer *http.Server
+	wsConns    map[*wsConn]struct{} // Open websocket connections
+	lock       sync.Mutex
+}
+
+// NewServer return server serving ledger read api methods with default config
+func NewServer(l *ledger.Ledger) *Server {
+	return NewServerWithConfig(l, Config{})
+}
+
+// NewServerWithConfig return server serving ledger read api methods
+func NewServerWithConfig(l *ledger.Ledger, config Config) *Server {
+	if config.MaxSubscriptions <= 0 {
+		config.MaxSubscriptions = DefaultMaxSubscriptions
+	}
+	s := &Server{
+		ledger:   l,
+		config:   config,
+		handlers: make(map[string]Handler),
+		wsConns:  make(map[*wsConn]struct{}),
+	}
+	s.upgrader = websocket.Upgrader{
+		ReadBufferSize:  4096,
+		WriteBufferSize: 4096,
+		CheckOrigin:     s.checkOrigin,
+	}
+	s.registerLedgerHandlers()
+	return s
+}
+
+// Register add method handler, existing handler of the method is replaced
+func (s *Server) Register(method string, handler Handler) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.handlers[method] = handler
+}
+
+func (s *Server) getHandler(method string) (Handler, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	handler, ok := s.handlers[method]
+	return handler, ok
+}
+
+// Start listen address and serve requests in background
+func (s *Server) Start(address string) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	s.lock.Lock()
+	s.httpServer = &http.Server{Handler: s}
+	httpServer := s.httpServer
+	s.lock.Unlock()
+
+	go func() {
+		if err := httpServer.Serve(listener); err != nil && err != http.ErrServerClosed {
+			logrus.Errorf("rpc server serve error: %s", err)
+		}
+	}()
+	logrus.Infof("rpc server listen on %s", listener.Addr())
+	return nil
+}
+
+// Stop gracefully shutdown started server and close websocket connections
+func (s *Server) Stop() error {
+	s.lock.Lock()
+	httpServer := s.httpServer
+	s.httpServer = nil
+	conns := make([]*wsConn, 0, len(s.wsConns))
+	for conn := range s.wsConns {
+		conns = append(conns, conn)
+	}
+	s.lock.Unlock()
+	for _, conn := range conns {
+		conn.close()
+	}
+	if httpServer == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	return httpServer.Shutdown(ctx)
+}
+
+// ServeHTTP implements http.Handler, single and batch requests are supported, websocket upgrade requests are served by serveWebSocket
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if websocket.IsWebSocketUpgrade(r) {
+		s.serveWebSocket(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, MaxRequestSize))
+	if err != nil {
+		s.writeResponse(w, &Response{JsonRpc: JsonRpcVersion, Error: NewError(errors.ErrInvalidRequest, err.Error())})
+		return
+	}
+
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		var batch []json.RawMessage
+		if err := json.Unmarshal(body, &batch); err != nil {
+			s.writeResponse(w, &Response{JsonRpc: JsonRpcVersion, Error: NewError(errors.ErrParseRequest, err.Error())})
+			return
+		}
+		if len(batch) == 0 {
+			s.writeResponse(w, &Response{JsonRpc: JsonRpcVersion, Error: NewError(errors.ErrInvalidRequest, "empty batch")})
+			return
+		}
+		responses := make([]*Response, 0, len(batch))
+		for _, raw := range batch {
+			if resp := s.handle(raw, nil); resp != nil {
+				responses = append(responses, resp)
+			}
+		}
+		if len(responses) == 0 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		s.writeResponse(w, responses)
+		return
+	}
+	resp := s.handle(body, nil)
+	if resp == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	s.writeResponse(w, resp)
+}
+
+// handle serve single request, connection handlers take precedence over registered ones.
+// Notification, the valid request without id, is served without response and nil is returned
+func (s *Server) handle(raw []byte, connHandlers map[string]Handler) *Response {
+	var req Request
+	if err := json.Unmarshal(raw, &req); err != nil {
+		return &Response{JsonRpc: JsonRpcVersion, Error: NewError(errors.ErrParseRequest, err.Error())}
+	}
+	resp := &Response{JsonRpc: JsonRpcVersion, Id: req.Id}
+	if req.JsonRpc != JsonRpcVersion || req.Method == "" {
+		resp.Error = NewError(errors.ErrInvalidRequest, "")
+		return resp
+	}
+	handler, ok := connHandlers[req.Method]
+	if !ok {
+		handler, ok = s.getHandler(req.Method)
+	}
+	if !ok {
+		if len(req.Id) == 0 {
+			return nil
+		}
+		resp.Error = NewError(errors.ErrMethodNotFound, "method not found: "+req.Method)
+		return resp
+	}
+	result, rpcErr := handler(req.Params)
+	if len(req.Id) == 0 {
+		return nil
+	}
+	if rpcErr != nil {
+		resp.Error = rpcErr
+		return resp
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		resp.Error = NewError(errors.ErrInternal, err.Error())
+		return resp
+	}
+	resp.Result = data
+	return resp
+}
+
+func (s *Server) writeResponse(w http.ResponseWriter, resp interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		logrus.Errorf("rpc server write response error: %s", err)
+	}
+}
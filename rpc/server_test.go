@@ -0,0 +1,170 @@
+package rpc
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/eywa-protocol/wrappers"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eywa-protocol/chain/common"
+	"github.com/eywa-protocol/chain/core/ledger"
+	"github.com/eywa-protocol/chain/core/payload"
+	scom "github.com/eywa-protocol/chain/core/store/common"
+	"github.com/eywa-protocol/chain/core/types"
+	"github.com/eywa-protocol/chain/errors"
+)
+
+func newTestLedger(t *testing.T) *ledger.Ledger {
+	l, err := ledger.NewLedger(t.TempDir(), 0)
+	require.NoError(t, err)
+	genesisBlock := types.NewBlock(0, common.UINT256_EMPTY, common.UINT256_EMPTY, 10, 0, types.Transactions{})
+	require.NoError(t, l.Init(genesisBlock))
+	return l
+}
+
+func call(t *testing.T, url string, method string, params ...interface{}) *Response {
+	body, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": JsonRpcVersion,
+		"method":  method,
+		"params":  params,
+		"id":      1,
+	})
+	require.NoError(t, err)
+	resp, err := http.Post(url, "application/json", bytes.NewReader(body))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	var rpcResp Response
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&rpcResp))
+	return &rpcResp
+}
+
+func TestServer(t *testing.T) {
+	l := newTestLedger(t)
+	defer l.Close()
+	reqId := [32]byte{1, 2, 3}
+	event := &payload.BridgeEvent{
+		OriginData: wrappers.BridgeOracleRequest{
+			RequestType: "setRequest",
+			RequestId:   reqId,
+			ChainId:     big.NewInt(94),
+		}}
+	block, err := l.CreateBlockFromEvents(types.Transactions{types.ToTransaction(event)}, 11, common.UINT256_EMPTY)
+	require.NoError(t, err)
+	require.NoError(t, l.ExecAndSaveBlock(block))
+
+	server := httptest.NewServer(NewServer(l))
+	defer server.Close()
+
+	resp := call(t, server.URL, "getblockcount")
+	require.Nil(t, resp.Error)
+	require.JSONEq(t, "2", string(resp.Result))
+
+	blockHash := block.Hash()
+	resp = call(t, server.URL, "getblockhash", 1)
+	require.Nil(t, resp.Error)
+	require.JSONEq(t, `"`+blockHash.ToHexString()+`"`, string(resp.Result))
+
+	resp = call(t, server.URL, "getblock", blockHash.ToHexString(), true)
+	require.Nil(t, resp.Error)
+	var blockInfo BlockInfo
+	require.NoError(t, json.Unmarshal(resp.Result, &blockInfo))
+	require.Equal(t, uint64(1), blockInfo.Header.Height)
+	require.Len(t, blockInfo.Transactions, 1)
+	require.Equal(t, payload.BridgeEventType.String(), blockInfo.Transactions[0].Type)
+	require.Equal(t, "received", blockInfo.Transactions[0].RequestState)
+
+	resp = call(t, server.URL, "getblock", 1)
+	require.Nil(t, resp.Error)
+	var rawBlock string
+	require.NoError(t, json.Unmarshal(resp.Result, &rawBlock))
+	raw, err := hex.DecodeString(rawBlock)
+	require.NoError(t, err)
+	decoded, err := types.BlockFromRawBytes(raw)
+	require.NoError(t, err)
+	require.Equal(t, blockHash, decoded.Hash())
+
+	txHash := block.Transactions[0].Hash()
+	resp = call(t, server.URL, "getblockheightbytxhash", txHash.ToHexString())
+	require.Nil(t, resp.Error)
+	require.JSONEq(t, "1", string(resp.Result))
+
+	resp = call(t, server.URL, "getrequeststate", hex.EncodeToString(reqId[:]))
+	require.Nil(t, resp.Error)
+	var requestInfo RequestInfo
+	require.NoError(t, json.Unmarshal(resp.Result, &requestInfo))
+	require.Equal(t, "received", requestInfo.State)
+	require.Equal(t, txHash.ToHexString(), requestInfo.TxHash)
+	require.Len(t, requestInfo.History, 1)
+
+	resp = call(t, server.URL, "getmerkleproof", 0, 1)
+	require.Nil(t, resp.Error)
+
+	resp = call(t, server.URL, "getblock", 5)
+	require.NotNil(t, resp.Error)
+	require.Equal(t, errors.ErrUnknownBlock, resp.Error.Code)
+
+	resp = call(t, server.URL, "getrequeststate", hex.EncodeToString(make([]byte, 32)))
+	require.NotNil(t, resp.Error)
+	require.Equal(t, errors.ErrUnknownRequest, resp.Error.Code)
+
+	resp = call(t, server.URL, "getblockhash", "one")
+	require.NotNil(t, resp.Error)
+	require.Equal(t, errors.ErrInvalidParams, resp.Error.Code)
+
+	resp = call(t, server.URL, "unknownmethod")
+	require.NotNil(t, resp.Error)
+	require.Equal(t, errors.ErrMethodNotFound, resp.Error.Code)
+}
+
+func TestLookupError(t *testing.T) {
+	require.Equal(t, errors.ErrUnknownBlock, lookupError(fmt.Errorf("GetHeader error %w", scom.ErrNotFound), errors.ErrUnknownBlock).Code)
+	require.Equal(t, errors.ErrPrunedData, lookupError(fmt.Errorf("GetTransaction error %w", scom.ErrPruned), errors.ErrUnknownBlock).Code)
+	require.Equal(t, errors.ErrInternal, lookupError(fmt.Errorf("error %s", scom.ErrNotFound), errors.ErrUnknownBlock).Code)
+}
+
+func TestServerBatch(t *testing.T) {
+	l := newTestLedger(t)
+	defer l.Close()
+	server := httptest.NewServer(NewServer(l))
+	defer server.Close()
+
+	body := `[{"jsonrpc":"2.0","method":"getblockcount","id":1},{"jsonrpc":"2.0","method":"getheights","id":2},{"method":"getblockcount","id":3}]`
+	resp, err := http.Post(server.URL, "application/json", bytes.NewReader([]byte(body)))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	var responses []*Response
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&responses))
+	require.Len(t, responses, 3)
+	require.JSONEq(t, "1", string(responses[0].Result))
+	require.Nil(t, responses[1].Error)
+	require.Equal(t, errors.ErrInvalidRequest, responses[2].Error.Code)
+
+	resp, err = http.Post(server.URL, "application/json", bytes.NewReader([]byte("{")))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	var parseResp Response
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&parseResp))
+	require.Equal(t, errors.ErrParseRequest, parseResp.Error.Code)
+
+	// notifications get no response
+	body = `[{"jsonrpc":"2.0","method":"getblockcount","id":1},{"jsonrpc":"2.0","method":"getheights"},{"jsonrpc":"2.0","method":"unknownmethod"}]`
+	resp, err = http.Post(server.URL, "application/json", bytes.NewReader([]byte(body)))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	responses = nil
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&responses))
+	require.Len(t, responses, 1)
+	require.JSONEq(t, "1", string(responses[0].Id))
+
+	resp, err = http.Post(server.URL, "application/json", bytes.NewReader([]byte(`{"jsonrpc":"2.0","method":"getblockcount"}`)))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+}
@@ -0,0 +1,172 @@
+package rpc
+
+import (
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/eywa-protocol/bls-crypto/bls"
+	"github.com/eywa-protocol/chain/core/payload"
+	"github.com/eywa-protocol/chain/core/states"
+	"github.com/eywa-protocol/chain/core/types"
+)
+
+// SignatureInfo is json view of header multisig
+type SignatureInfo struct {
+	PartSignature string
+	PartPublicKey string
+	PartMask      string
+}
+
+// HeaderInfo is json view of block header
+type HeaderInfo struct {
+	Hash             string
+	ChainId          uint64
+	PrevBlockHash    string
+	EpochBlockHash   string
+	TransactionsRoot string
+	SourceHeight     uint64
+	Height           uint64
+	Signature        SignatureInfo
+}
+
+// TransactionInfo is json view of transaction
+type TransactionInfo struct {
+	Hash         string
+	Height       uint64
+	Type         string
+	RequestId    string `json:",omitempty"`
+	RequestState string `json:",omitempty"`
+	Payload      json.RawMessage
+}
+
+// BlockInfo is json view of block
+type BlockInfo struct {
+	Hash         string
+	Header       *HeaderInfo
+	Transactions []*TransactionInfo
+}
+
+// RequestInfo is json view of request state with its history
+type RequestInfo struct {
+	RequestId string
+	State     string
+	TxHash    string
+	History   []*RequestStateInfo
+}
+
+// RequestStateInfo is json view of request state change
+type RequestStateInfo struct {
+	PrevState string
+	State     string
+	Height    uint64
+	TxHash    string
+}
+
+// RequestStateEventInfo is json view of request state change notification
+type RequestStateEventInfo struct {
+	RequestId  string
+	DstChainId uint64
+	*RequestStateInfo
+}
+
+// EpochStateInfo is json view of epoch participants public keys
+type EpochStateInfo struct {
+	CurrEpoch []string
+	NextEpoch []string
+}
+
+// HeightsInfo is json view of ledger current heights
+type HeightsInfo struct {
+	BlockHeight     uint64
+	BlockHash       string
+	HeaderHeight    uint64
+	ProcessedHeight uint64
+}
+
+func NewHeaderInfo(header *types.Header) *HeaderInfo {
+	hash := header.Hash()
+	return &HeaderInfo{
+		Hash:             hash.ToHexString(),
+		ChainId:          header.ChainID,
+		PrevBlockHash:    header.PrevBlockHash.ToHexString(),
+		EpochBlockHash:   header.EpochBlockHash.ToHexString(),
+		TransactionsRoot: header.TransactionsRoot.ToHexString(),
+		SourceHeight:     header.SourceHeight,
+		Height:           header.Height,
+		Signature: SignatureInfo{
+			PartSignature: hex.EncodeToString(header.Signature.PartSignature.Marshal()),
+			PartPublicKey: hex.EncodeToString(header.Signature.PartPublicKey.Marshal()),
+			PartMask:      hex.EncodeToString(bls.MarshalBitmask(header.Signature.PartMask)),
+		},
+	}
+}
+
+func NewTransactionInfo(tx payload.Payload, height uint64) (*TransactionInfo, error) {
+	data, err := tx.ToJson()
+	if err != nil {
+		return nil, err
+	}
+	transaction := types.ToTransaction(tx)
+	txHash := transaction.Hash()
+	info := &TransactionInfo{
+		Hash:    txHash.ToHexString(),
+		Height:  height,
+		Type:    tx.TxType().String(),
+		Payload: data,
+	}
+	if state := tx.RequestState(); state != payload.ReqStateUnknown {
+		reqId := tx.RequestId()
+		info.RequestId = hex.EncodeToString(reqId[:])
+		info.RequestState = state.String()
+	}
+	return info, nil
+}
+
+func NewBlockInfo(block *types.Block) (*BlockInfo, error) {
+	blockHash := block.Hash()
+	info := &BlockInfo{
+		Hash:         blockHash.ToHexString(),
+		Header:       NewHeaderInfo(block.Header),
+		Transactions: make([]*TransactionInfo, 0, len(block.Transactions)),
+	}
+	for _, tx := range block.Transactions {
+		txInfo, err := NewTransactionInfo(tx.Payload, block.Header.Height)
+		if err != nil {
+			return nil, err
+		}
+		info.Transactions = append(info.Transactions, txInfo)
+	}
+	return info, nil
+}
+
+func NewRequestStateInfo(entry *states.RequestStateEntry) *RequestStateInfo {
+	return &RequestStateInfo{
+		PrevState: entry.PrevState.String(),
+		State:     entry.State.String(),
+		Height:    entry.Height,
+		TxHash:    entry.TxHash.ToHexString(),
+	}
+}
+
+func NewRequestStateEventInfo(entry *states.RequestStateEntry, dstChainId uint64) *RequestStateEventInfo {
+	return &RequestStateEventInfo{
+		RequestId:        hex.EncodeToString(entry.RequestId[:]),
+		DstChainId:       dstChainId,
+		RequestStateInfo: NewRequestStateInfo(entry),
+	}
+}
+
+func NewEpochStateInfo(state *states.EpochState) *EpochStateInfo {
+	return &EpochStateInfo{
+		CurrEpoch: publicKeysToHex(state.CurrEpoch),
+		NextEpoch: publicKeysToHex(state.NextEpoch),
+	}
+}
+
+func publicKeysToHex(keys []bls.PublicKey) []string {
+	result := make([]string, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, hex.EncodeToString(key.Marshal()))
+	}
+	return result
+}
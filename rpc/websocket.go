@@ -0,0 +1,334 @@
+package rpc
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eywa-protocol/chain/core/events"
+	"github.com/eywa-protocol/chain/core/payload"
+	"github.com/eywa-protocol/chain/errors"
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	SubscriptionMethod = "subscription" // Method of notifications sent to websocket subscribers
+
+	wsSendBufferSize = 256 // Count of messages buffered for websocket client, client is disconnected on overflow
+	wsWriteTimeout   = 10 * time.Second
+	wsPongTimeout    = 60 * time.Second
+	wsPingPeriod     = wsPongTimeout * 9 / 10
+)
+
+// Notification is JSON-RPC 2.0 notification sent to websocket subscriber
+type Notification struct {
+	JsonRpc string              `json:"jsonrpc"`
+	Method  string              `json:"method"`
+	Params  *SubscriptionResult `json:"params"`
+}
+
+// SubscriptionResult is event of subscription or error the subscription was closed with
+type SubscriptionResult struct {
+	Subscription string          `json:"subscription"`
+	Result       json.RawMessage `json:"result,omitempty"`
+	Error        *Error          `json:"error,omitempty"`
+}
+
+// SubscriptionFilter is json view of subscription events filter
+type SubscriptionFilter struct {
+	TxTypes     []string `json:"txTypes,omitempty"`     // Transaction type names of transaction events
+	RequestIds  []string `json:"requestIds,omitempty"`  // Hex request ids of request state events
+	DstChainIds []uint64 `json:"dstChainIds,omitempty"` // Destination chain ids of request state events
+}
+
+// wsConn is websocket connection serving JSON-RPC requests and subscriptions
+type wsConn struct {
+	server   *Server
+	conn     *websocket.Conn
+	handlers map[string]Handler
+	send     chan []byte
+	done     chan struct{}
+	once     sync.Once
+	lock     sync.Mutex
+	subs     map[uint64]*events.Subscription
+	closed   bool
+}
+
+// serveWebSocket upgrade connection and serve it until client disconnect or server stop
+func (s *Server) serveWebSocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		logrus.Debugf("rpc server websocket upgrade error: %s", err)
+		return
+	}
+	c := &wsConn{
+		server: s,
+		conn:   conn,
+		send:   make(chan []byte, wsSendBufferSize),
+		done:   make(chan struct{}),
+		subs:   make(map[uint64]*events.Subscription),
+	}
+	c.handlers = map[string]Handler{
+		"subscribe":   c.subscribe,
+		"unsubscribe": c.unsubscribe,
+	}
+	s.lock.Lock()
+	s.wsConns[c] = struct{}{}
+	s.lock.Unlock()
+
+	go c.writeLoop()
+	c.readLoop()
+}
+
+// checkOrigin allow websocket requests without origin, of the same origin and of configured origins,
+// so web pages of other sites can't use the connection of the browser
+func (s *Server) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	for _, allowed := range s.config.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
+
+func (c *wsConn) readLoop() {
+	defer c.close()
+	c.conn.SetReadLimit(MaxRequestSize)
+	_ = c.conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+	})
+	for {
+		_, message, err := c.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		if resp := c.server.handle(message, c.handlers); resp != nil {
+			c.write(resp)
+		}
+	}
+}
+
+func (c *wsConn) writeLoop() {
+	ticker := time.NewTicker(wsPingPeriod)
+	defer func() {
+		ticker.Stop()
+		c.close()
+	}()
+	for {
+		select {
+		case data := <-c.send:
+			_ = c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+			if err := c.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+				return
+			}
+		case <-ticker.C:
+			_ = c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// write queue message without blocking, connection of client not reading messages is closed
+func (c *wsConn) write(msg interface{}) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		logrus.Errorf("rpc server websocket marshal error: %s", err)
+		return
+	}
+	select {
+	case <-c.done:
+	case c.send <- data:
+	default:
+		logrus.Warnf("rpc server websocket client %s is too slow, closing connection", c.conn.RemoteAddr())
+		c.close()
+	}
+}
+
+// close connection and its subscriptions, it is safe to call it several times
+func (c *wsConn) close() {
+	c.once.Do(func() {
+		close(c.done)
+		_ = c.conn.Close()
+
+		c.lock.Lock()
+		c.closed = true
+		subs := c.subs
+		c.subs = nil
+		c.lock.Unlock()
+		for _, sub := range subs {
+			sub.Unsubscribe()
+		}
+
+		c.server.lock.Lock()
+		delete(c.server.wsConns, c)
+		c.server.lock.Unlock()
+	})
+}
+
+// subscribe params: event type name, optional filter. Return subscription id
+func (c *wsConn) subscribe(params []json.RawMessage) (interface{}, *Error) {
+	name, rpcErr := stringParam(params, 0)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	eventType, err := events.ParseEventType(name)
+	if err != nil {
+		return nil, NewError(errors.ErrInvalidParams, err.Error())
+	}
+	filter, rpcErr := filterParam(params, 1)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	filter.Types = []events.EventType{eventType}
+
+	sub := c.server.ledger.GetEventHub().Subscribe(filter, events.DefaultBufferSize, events.OverflowDisconnect)
+	c.lock.Lock()
+	if c.closed {
+		c.lock.Unlock()
+		sub.Unsubscribe()
+		return nil, NewError(errors.ErrSubscriptionClosed, "")
+	}
+	if len(c.subs) >= c.server.config.MaxSubscriptions {
+		c.lock.Unlock()
+		sub.Unsubscribe()
+		return nil, NewError(errors.ErrSubscriptionLimit, "")
+	}
+	c.subs[sub.Id()] = sub
+	c.lock.Unlock()
+
+	go c.forward(sub)
+	return strconv.FormatUint(sub.Id(), 10), nil
+}
+
+// unsubscribe params: subscription id
+func (c *wsConn) unsubscribe(params []json.RawMessage) (interface{}, *Error) {
+	str, rpcErr := stringParam(params, 0)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	id, err := strconv.ParseUint(str, 10, 64)
+	if err != nil {
+		return nil, NewError(errors.ErrInvalidParams, fmt.Sprintf("param 0: %s", err))
+	}
+	c.lock.Lock()
+	sub, ok := c.subs[id]
+	delete(c.subs, id)
+	c.lock.Unlock()
+	if !ok {
+		return nil, NewError(errors.ErrUnknownSubscription, "")
+	}
+	sub.Unsubscribe()
+	return true, nil
+}
+
+// forward send subscription events to client until subscription is closed
+func (c *wsConn) forward(sub *events.Subscription) {
+	id := strconv.FormatUint(sub.Id(), 10)
+	for e := range sub.Events() {
+		result, err := newEventResult(e)
+		if err != nil {
+			logrus.Errorf("rpc server subscription %s event error: %s", id, err)
+			continue
+		}
+		c.write(&Notification{
+			JsonRpc: JsonRpcVersion,
+			Method:  SubscriptionMethod,
+			Params:  &SubscriptionResult{Subscription: id, Result: result},
+		})
+	}
+	// subscription closed by hub is reported to client, it may subscribe again
+	if err := sub.Err(); err != nil {
+		c.lock.Lock()
+		if c.subs != nil {
+			delete(c.subs, sub.Id())
+		}
+		c.lock.Unlock()
+		c.write(&Notification{
+			JsonRpc: JsonRpcVersion,
+			Method:  SubscriptionMethod,
+			Params:  &SubscriptionResult{Subscription: id, Error: NewError(errors.ErrSubscriptionClosed, err.Error())},
+		})
+	}
+}
+
+func newEventResult(e *events.Event) (json.RawMessage, error) {
+	var result interface{}
+	var err error
+	switch e.Type {
+	case events.EventHeader:
+		result = NewHeaderInfo(e.Header)
+	case events.EventBlock:
+		result, err = NewBlockInfo(e.Block)
+	case events.EventTransaction:
+		result, err = NewTransactionInfo(e.Tx, e.Height)
+	case events.EventRequestState:
+		result = NewRequestStateEventInfo(e.RequestState, e.DstChainId)
+	default:
+		return nil, fmt.Errorf("unknown event type %d", e.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(result)
+}
+
+// filterParam parse optional subscription filter
+func filterParam(params []json.RawMessage, index int) (events.Filter, *Error) {
+	var filter events.Filter
+	if index >= len(params) {
+		return filter, nil
+	}
+	var view SubscriptionFilter
+	if err := json.Unmarshal(params[index], &view); err != nil {
+		return filter, NewError(errors.ErrInvalidParams, fmt.Sprintf("param %d: %s", index, err))
+	}
+	for _, name := range view.TxTypes {
+		txType, ok := parseTxType(name)
+		if !ok {
+			return filter, NewError(errors.ErrInvalidParams, fmt.Sprintf("param %d: unknown transaction type %s", index, name))
+		}
+		filter.TxTypes = append(filter.TxTypes, txType)
+	}
+	for _, str := range view.RequestIds {
+		data, err := hex.DecodeString(strings.TrimPrefix(str, "0x"))
+		if err != nil || len(data) != 32 {
+			return filter, NewError(errors.ErrInvalidParams, fmt.Sprintf("param %d: invalid request id %s", index, str))
+		}
+		var reqId [32]byte
+		copy(reqId[:], data)
+		filter.RequestIds = append(filter.RequestIds, reqId)
+	}
+	filter.DstChainIds = view.DstChainIds
+	return filter, nil
+}
+
+// parseTxType return transaction type by its name
+func parseTxType(name string) (payload.TransactionType, bool) {
+	for i := 0; i <= 0xff; i++ {
+		txType := payload.TransactionType(i)
+		if txType.String() == name && name != "unknown" {
+			return txType, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,147 @@
+package rpc
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eywa-protocol/wrappers"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eywa-protocol/chain/common"
+	"github.com/eywa-protocol/chain/core/payload"
+	"github.com/eywa-protocol/chain/core/types"
+	"github.com/eywa-protocol/chain/errors"
+)
+
+// wsMessage is response or notification received over websocket
+type wsMessage struct {
+	Response
+	Method string              `json:"method"`
+	Params *SubscriptionResult `json:"params"`
+}
+
+func wsCall(t *testing.T, conn *websocket.Conn, method string, params ...interface{}) *wsMessage {
+	require.NoError(t, conn.WriteJSON(map[string]interface{}{
+		"jsonrpc": JsonRpcVersion,
+		"method":  method,
+		"params":  params,
+		"id":      1,
+	}))
+	return wsRead(t, conn)
+}
+
+func wsRead(t *testing.T, conn *websocket.Conn) *wsMessage {
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	var msg wsMessage
+	require.NoError(t, conn.ReadJSON(&msg))
+	return &msg
+}
+
+func TestWebSocketSubscriptions(t *testing.T) {
+	l := newTestLedger(t)
+	defer l.Close()
+	rpcServer := NewServer(l)
+	server := httptest.NewServer(rpcServer)
+	defer server.Close()
+	defer rpcServer.Stop()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	msg := wsCall(t, conn, "getblockcount")
+	require.Nil(t, msg.Error)
+	require.JSONEq(t, "1", string(msg.Result))
+
+	msg = wsCall(t, conn, "subscribe", "header")
+	require.Nil(t, msg.Error)
+	var headerSub string
+	require.NoError(t, json.Unmarshal(msg.Result, &headerSub))
+
+	reqId := [32]byte{1, 2, 3}
+	msg = wsCall(t, conn, "subscribe", "request_state", SubscriptionFilter{RequestIds: []string{hex.EncodeToString(reqId[:])}})
+	require.Nil(t, msg.Error)
+	var requestSub string
+	require.NoError(t, json.Unmarshal(msg.Result, &requestSub))
+
+	msg = wsCall(t, conn, "subscribe", "transaction", SubscriptionFilter{TxTypes: []string{"epoch"}})
+	require.Nil(t, msg.Error)
+
+	msg = wsCall(t, conn, "subscribe", "transaction", SubscriptionFilter{TxTypes: []string{"nosuchtype"}})
+	require.NotNil(t, msg.Error)
+	require.Equal(t, errors.ErrInvalidParams, msg.Error.Code)
+
+	msg = wsCall(t, conn, "subscribe", "nosuchevent")
+	require.NotNil(t, msg.Error)
+	require.Equal(t, errors.ErrInvalidParams, msg.Error.Code)
+
+	event := &payload.BridgeEvent{
+		OriginData: wrappers.BridgeOracleRequest{
+			RequestType: "setRequest",
+			RequestId:   reqId,
+			ChainId:     big.NewInt(94),
+		}}
+	block, err := l.CreateBlockFromEvents(types.Transactions{types.ToTransaction(event)}, 11, common.UINT256_EMPTY)
+	require.NoError(t, err)
+	require.NoError(t, l.ExecAndSaveBlock(block))
+
+	notifications := make(map[string]*SubscriptionResult)
+	for len(notifications) < 2 {
+		msg = wsRead(t, conn)
+		require.Equal(t, SubscriptionMethod, msg.Method)
+		notifications[msg.Params.Subscription] = msg.Params
+	}
+	var header HeaderInfo
+	require.NoError(t, json.Unmarshal(notifications[headerSub].Result, &header))
+	blockHash := block.Hash()
+	require.Equal(t, blockHash.ToHexString(), header.Hash)
+	var request RequestStateEventInfo
+	require.NoError(t, json.Unmarshal(notifications[requestSub].Result, &request))
+	require.Equal(t, hex.EncodeToString(reqId[:]), request.RequestId)
+	require.Equal(t, uint64(94), request.DstChainId)
+	require.Equal(t, "received", request.State)
+
+	msg = wsCall(t, conn, "unsubscribe", headerSub)
+	require.Nil(t, msg.Error)
+	msg = wsCall(t, conn, "unsubscribe", headerSub)
+	require.NotNil(t, msg.Error)
+	require.Equal(t, errors.ErrUnknownSubscription, msg.Error.Code)
+	require.Equal(t, 2, l.GetEventHub().Count())
+
+	require.NoError(t, rpcServer.Stop())
+	require.Eventually(t, func() bool { return l.GetEventHub().Count() == 0 }, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestWebSocketLimits(t *testing.T) {
+	l := newTestLedger(t)
+	defer l.Close()
+	rpcServer := NewServerWithConfig(l, Config{AllowedOrigins: []string{"https://explorer.example"}, MaxSubscriptions: 1})
+	server := httptest.NewServer(rpcServer)
+	defer server.Close()
+	defer rpcServer.Stop()
+	wsUrl := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	// page of other origin can't connect
+	_, _, err := websocket.DefaultDialer.Dial(wsUrl, http.Header{"Origin": []string{"https://other.example"}})
+	require.Error(t, err)
+	conn, _, err := websocket.DefaultDialer.Dial(wsUrl, http.Header{"Origin": []string{server.URL}})
+	require.NoError(t, err)
+	conn.Close()
+	conn, _, err = websocket.DefaultDialer.Dial(wsUrl, http.Header{"Origin": []string{"https://explorer.example"}})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	msg := wsCall(t, conn, "subscribe", "header")
+	require.Nil(t, msg.Error)
+	msg = wsCall(t, conn, "subscribe", "block")
+	require.NotNil(t, msg.Error)
+	require.Equal(t, errors.ErrSubscriptionLimit, msg.Error.Code)
+	require.Equal(t, 1, l.GetEventHub().Count())
+}